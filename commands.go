@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/GroceryTrak/GroceryTrakService/config"
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/handlers"
+	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
+)
+
+const (
+	defaultWorkerConcurrency = 2
+	defaultSpoonacularRate   = 1
+	defaultEnrichmentMaxAge  = 30 * 24 * time.Hour
+)
+
+// runCommand executes a CLI subcommand and reports whether one was given
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "create-admin":
+		createAdminCommand(args[1:])
+	case "dedupe-items":
+		dedupeItemsCommand(args[1:])
+	case "merge-items":
+		mergeItemsCommand(args[1:])
+	case "worker":
+		workerCommand(args[1:])
+	default:
+		log.Fatalf("Unknown command: %s", args[0])
+	}
+	return true
+}
+
+// createAdminCommand creates or promotes an admin account.
+// Usage: create-admin -username admin [-password secret]; the password defaults to ADMIN_PASSWORD.
+func createAdminCommand(args []string) {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	username := fs.String("username", os.Getenv("ADMIN_USERNAME"), "admin username")
+	password := fs.String("password", os.Getenv("ADMIN_PASSWORD"), "admin password")
+	fs.Parse(args)
+
+	config.InitPostgreSQL()
+
+	authRepo := repository.NewAuthRepository(config.DB)
+	if err := authRepo.EnsureAdmin(*username, *password); err != nil {
+		log.Fatalf("Failed to create admin: %v", err)
+	}
+	log.Printf("Admin %s is ready", *username)
+}
+
+// dedupeItemsCommand lists groups of items that are probably the same product.
+// Usage: dedupe-items [-threshold 0.4]
+func dedupeItemsCommand(args []string) {
+	fs := flag.NewFlagSet("dedupe-items", flag.ExitOnError)
+	threshold := fs.Float64("threshold", 0, "lowest name similarity (0-1) to report, defaults to 0.4")
+	fs.Parse(args)
+
+	config.InitPostgreSQL()
+
+	itemRepo := repository.NewItemRepository(config.DB, nil)
+	duplicates, err := itemRepo.FindDuplicateItems(*threshold)
+	if err != nil {
+		log.Fatalf("Failed to find duplicate items: %v", err)
+	}
+
+	for _, group := range duplicates.Groups {
+		fmt.Printf("score %.2f, keep %d:\n", group.Score, group.SuggestedID)
+		for _, item := range group.Items {
+			fmt.Printf("  %d\t%s\t(spoonacular %d)\n", item.ID, item.Name, item.SpoonacularID)
+		}
+	}
+	log.Printf("Found %d groups of duplicate items", len(duplicates.Groups))
+}
+
+// mergeItemsCommand merges duplicate items into the one to keep.
+// Usage: merge-items -into 12 -from 31,47
+func mergeItemsCommand(args []string) {
+	fs := flag.NewFlagSet("merge-items", flag.ExitOnError)
+	into := fs.Uint("into", 0, "ID of the item to keep")
+	from := fs.String("from", "", "comma separated IDs of the duplicates to merge into it")
+	fs.Parse(args)
+
+	req := dtos.ItemMergeRequest{ItemID: *into}
+	for _, field := range strings.Split(*from, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			log.Fatalf("Invalid item ID %q", field)
+		}
+		req.DuplicateIDs = append(req.DuplicateIDs, uint(id))
+	}
+
+	config.InitPostgreSQL()
+	config.InitRedis()
+
+	itemRepo := repository.NewItemRepository(config.DB, repository.NewItemQueueRepository(config.RedisClient))
+	item, err := itemRepo.MergeItems(req)
+	if err != nil {
+		log.Fatalf("Failed to merge items: %v", err)
+	}
+	log.Printf("Merged %d items into %d (%s)", len(req.DuplicateIDs), item.ID, item.Name)
+}
+
+// workerCommand runs only the item enrichment worker and scheduler, so that they can be scaled
+// apart from the API. Any number of worker instances can run against the same Redis.
+// Usage: worker [-concurrency 2]; the concurrency defaults to WORKER_CONCURRENCY.
+func workerCommand(args []string) {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	concurrency := fs.Int("concurrency", 0, "number of items enriched at once, defaults to WORKER_CONCURRENCY or 2")
+	fs.Parse(args)
+
+	config.InitRedis()
+	config.InitPostgreSQL()
+	config.InitSpoonacularClient()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := newEnrichmentScheduler().Start(ctx); err != nil && err != context.Canceled {
+			log.Printf("Enrichment scheduler error: %v", err)
+		}
+	}()
+
+	if err := newQueueWorker(*concurrency).Start(ctx); err != nil && err != context.Canceled {
+		log.Fatalf("Queue handler error: %v", err)
+	}
+	log.Println("Worker exited properly")
+}
+
+// newQueueWorker sets up the item enrichment worker. Spoonacular calls are limited to
+// SPOONACULAR_RATE_LIMIT a second across every instance; concurrency defaults to
+// WORKER_CONCURRENCY.
+func newQueueWorker(concurrency int) *handlers.ItemQueueHandler {
+	if concurrency <= 0 {
+		concurrency, _ = strconv.Atoi(os.Getenv("WORKER_CONCURRENCY"))
+	}
+	if concurrency <= 0 {
+		concurrency = defaultWorkerConcurrency
+	}
+	rate, err := strconv.ParseFloat(os.Getenv("SPOONACULAR_RATE_LIMIT"), 64)
+	if err != nil || rate <= 0 {
+		rate = defaultSpoonacularRate
+	}
+
+	queue := repository.NewItemQueueRepository(config.RedisClient)
+	return handlers.NewItemQueueHandler(
+		queue,
+		config.SpoonacularClient,
+		repository.NewItemRepository(config.DB, queue),
+		// A burst of two lets one item's search and details go out back to back
+		repository.NewRateLimitRepository(config.RedisClient, rate, 2),
+		concurrency,
+	)
+}
+
+// newEnrichmentScheduler sets up the scheduler that refreshes items enriched longer than
+// ENRICHMENT_MAX_AGE ago, a duration such as 720h, defaulting to 30 days
+func newEnrichmentScheduler() *handlers.EnrichmentScheduler {
+	maxAge, err := time.ParseDuration(os.Getenv("ENRICHMENT_MAX_AGE"))
+	if err != nil || maxAge <= 0 {
+		maxAge = defaultEnrichmentMaxAge
+	}
+
+	queue := repository.NewItemQueueRepository(config.RedisClient)
+	return handlers.NewEnrichmentScheduler(queue, repository.NewItemRepository(config.DB, queue), maxAge)
+}
+
+// bootstrapAdmin creates the admin from ADMIN_USERNAME and ADMIN_PASSWORD when both are set and
+// no user has that username yet. Resetting an existing account is left to create-admin.
+func bootstrapAdmin() {
+	username := os.Getenv("ADMIN_USERNAME")
+	password := os.Getenv("ADMIN_PASSWORD")
+	if username == "" || password == "" {
+		return
+	}
+
+	authRepo := repository.NewAuthRepository(config.DB)
+	created, err := authRepo.CreateAdminIfMissing(username, password)
+	if err != nil {
+		log.Fatalf("Failed to bootstrap admin: %v", err)
+	}
+	if created {
+		log.Printf("Admin %s created", username)
+	} else {
+		log.Printf("User %s already exists and was left unchanged; use create-admin to promote it or reset its password", username)
+	}
+}
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/GroceryTrak/GroceryTrakService/internal/clients"
+	"github.com/GroceryTrak/GroceryTrakService/internal/detector"
+	"github.com/GroceryTrak/GroceryTrakService/internal/matching"
 	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"github.com/GroceryTrak/GroceryTrakService/internal/productdb"
 	"github.com/joho/godotenv"
 	"github.com/redis/go-redis/v9"
 
@@ -22,6 +26,8 @@ var (
 	DB                *gorm.DB
 	Ctx               = context.Background()
 	SpoonacularClient *clients.SpoonacularClient
+	Detector          detector.Detector
+	ProductDB         productdb.Client
 )
 
 func LoadConfig() {
@@ -31,13 +37,51 @@ func LoadConfig() {
 	}
 }
 
+// InitSpoonacularClient needs Redis, where the API key's daily quota is tracked. Until Spoonacular
+// reports the real figure, the quota is taken from SPOONACULAR_DAILY_QUOTA.
 func InitSpoonacularClient() {
+	dailyQuota, err := strconv.ParseFloat(os.Getenv("SPOONACULAR_DAILY_QUOTA"), 64)
+	if err != nil || dailyQuota <= 0 {
+		dailyQuota = clients.DefaultDailyQuota
+	}
+
 	SpoonacularClient = clients.NewSpoonacularClient(
 		os.Getenv("SPOONACULAR_API_URL"),
 		os.Getenv("SPOONACULAR_API_KEY"),
+		RedisClient,
+		dailyQuota,
 	)
 }
 
+// InitDetector picks the item detector from DETECTOR (openai, huggingface or fake). Without a
+// working configuration detection is disabled rather than failing startup.
+func InitDetector() {
+	var err error
+	Detector, err = detector.New(detector.Config{
+		Name:           os.Getenv("DETECTOR"),
+		OpenAIAPIKey:   os.Getenv("OPENAI_API_KEY"),
+		OpenAIModel:    os.Getenv("OPENAI_MODEL"),
+		HuggingFaceURL: os.Getenv("HUGGINGFACE_URL"),
+	})
+	if err != nil {
+		log.Printf("Item detection is disabled: %v", err)
+	}
+}
+
+// InitProductDB picks the barcode product database from PRODUCT_DB (openfoodfacts or fixture).
+// Without a working configuration unknown barcodes can only be linked to items by hand.
+func InitProductDB() {
+	var err error
+	ProductDB, err = productdb.New(productdb.Config{
+		Name:             os.Getenv("PRODUCT_DB"),
+		OpenFoodFactsURL: os.Getenv("OPENFOODFACTS_URL"),
+		FixturePath:      os.Getenv("PRODUCT_DB_FIXTURE"),
+	})
+	if err != nil {
+		log.Printf("Product database lookups are disabled: %v", err)
+	}
+}
+
 func InitRedis() {
 	log.Printf("ENV: '%s'\n", os.Getenv("ENV"))
 	options := &redis.Options{
@@ -79,27 +123,90 @@ func InitPostgreSQL() {
 
 	// Drop all tables (development only)
 	if os.Getenv("ENV") == "development" {
-		DB.Migrator().DropTable(&models.Recipe{}, &models.Item{}, &models.ItemNutrient{}, &models.RecipeNutrient{}, &models.UserItem{}, &models.RecipeItem{}, &models.User{}, &models.RecipeInstruction{}, &models.UserPreference{})
+		DB.Migrator().DropTable(&models.Recipe{}, &models.Item{}, &models.ItemNutrient{}, &models.RecipeNutrient{}, &models.UserItem{}, &models.RecipeItem{}, &models.User{}, &models.RecipeInstruction{}, &models.UserPreference{}, &models.ShoppingList{}, &models.ShoppingListItem{}, &models.Household{}, &models.HouseholdMember{}, &models.Scan{}, &models.ScanLine{}, &models.ItemAlias{}, &models.ItemBarcode{}, &models.Purchase{}, &models.PurchaseLine{}, &models.Budget{}, &models.PantryEvent{})
 	}
 
+	if err := Migrate(DB); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("Connected to PostgreSQL successfully")
+}
+
+// Migrate creates the enum types and extensions the schema needs, migrates every table and
+// backfills new columns. It is safe to run on every start.
+func Migrate(db *gorm.DB) error {
+	var err error
+
 	// Create ENUM types if they don't exist
 	enums := []string{
 		"role AS ENUM ('user', 'admin')",
+		"storage_location AS ENUM ('pantry', 'fridge', 'freezer')",
+		"household_role AS ENUM ('owner', 'member')",
+		"scan_status AS ENUM ('pending', 'committed', 'discarded')",
+		"pantry_event_type AS ENUM ('added', 'consumed', 'adjusted', 'discarded', 'expired')",
+		"pantry_event_source AS ENUM ('manual', 'detect', 'predict', 'cook', 'receipt', 'barcode', 'shopping_list')",
+		"waste_reason AS ENUM ('expired', 'spoiled', 'leftover')",
+		"enrichment_status AS ENUM ('pending', 'enriched', 'failed', 'not_found')",
+		"enrichment_source AS ENUM ('spoonacular', 'manual')",
 	}
 
 	for _, enum := range enums {
 		query := "DO $$ BEGIN IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = '" + enum[:strings.Index(enum, " ")] + "') THEN CREATE TYPE " + enum + "; END IF; END $$;"
-		err = DB.Exec(query).Error
+		err = db.Exec(query).Error
 		if err != nil {
-			log.Fatalf("Failed to create ENUM type %s: %v", enum, err)
+			return fmt.Errorf("failed to create ENUM type %s: %w", enum, err)
 		}
 	}
 
+	// Pantry rows used to be keyed by (user_id, item_id); they are lots with their own id now
+	if db.Migrator().HasTable(&models.UserItem{}) && !db.Migrator().HasColumn(&models.UserItem{}, "ID") {
+		err = db.Exec("ALTER TABLE user_items DROP CONSTRAINT IF EXISTS user_items_pkey, ADD COLUMN id BIGSERIAL PRIMARY KEY").Error
+		if err != nil {
+			return fmt.Errorf("failed to migrate user_items to lots: %w", err)
+		}
+	}
+
+	// Trigram similarity is used to match detected names to items
+	err = db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error
+	if err != nil {
+		return fmt.Errorf("failed to create pg_trgm extension: %w", err)
+	}
+
 	// Run migrations in order
-	err = DB.AutoMigrate(&models.User{}, &models.Item{}, &models.ItemNutrient{}, &models.RecipeNutrient{}, &models.UserItem{}, &models.Recipe{}, &models.RecipeItem{}, &models.RecipeInstruction{}, &models.UserPreference{})
+	err = db.AutoMigrate(&models.User{}, &models.Item{}, &models.ItemNutrient{}, &models.RecipeNutrient{}, &models.UserItem{}, &models.Recipe{}, &models.RecipeItem{}, &models.RecipeInstruction{}, &models.UserPreference{}, &models.ShoppingList{}, &models.ShoppingListItem{}, &models.Household{}, &models.HouseholdMember{}, &models.Scan{}, &models.ScanLine{}, &models.ItemAlias{}, &models.ItemBarcode{}, &models.Purchase{}, &models.PurchaseLine{}, &models.Budget{}, &models.PantryEvent{})
 	if err != nil {
-		log.Fatalf("Failed to migrate table: %v", err)
+		return fmt.Errorf("failed to migrate table: %w", err)
 	}
 
-	fmt.Println("Connected to PostgreSQL successfully")
+	err = db.Exec("CREATE INDEX IF NOT EXISTS idx_items_name_trgm ON items USING gin (LOWER(name) gin_trgm_ops)").Error
+	if err != nil {
+		return fmt.Errorf("failed to create item name index: %w", err)
+	}
+
+	// Items filled in before enrichment was tracked count as enriched now, so that they are not
+	// all refreshed at once
+	err = db.Exec(`UPDATE items SET enrichment_status = 'enriched', enriched_at = NOW(),
+		enrichment_source = CASE WHEN spoonacular_id <> 0 THEN 'spoonacular'::enrichment_source ELSE 'manual'::enrichment_source END
+		WHERE enrichment_status = 'pending' AND enriched_at IS NULL
+		AND EXISTS (SELECT 1 FROM item_nutrients WHERE item_nutrients.item_id = items.id)`).Error
+	if err != nil {
+		return fmt.Errorf("failed to backfill item enrichment: %w", err)
+	}
+
+	// Normalized names are matched against; items from before they were stored get theirs now
+	var items []models.Item
+	err = db.Select("id", "name").Where("normalized_name = ''").FindInBatches(&items, 500, func(tx *gorm.DB, batch int) error {
+		for _, item := range items {
+			if err := db.Model(&models.Item{}).Where("id = ?", item.ID).UpdateColumn("normalized_name", matching.Normalize(item.Name)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to backfill normalized item names: %w", err)
+	}
+
+	return nil
 }
@@ -25,7 +25,7 @@ const docTemplate = `{
     "paths": {
         "/auth/login": {
             "post": {
-                "description": "Logs in a user and returns a JWT token",
+                "description": "Logs in a user and returns a short-lived access token and a refresh token",
                 "consumes": [
                     "application/json"
                 ],
@@ -63,9 +63,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/register": {
+        "/auth/logout": {
             "post": {
-                "description": "Creates a new user account",
+                "description": "Revokes the refresh token family and, if sent in the Authorization header, the current access token",
                 "consumes": [
                     "application/json"
                 ],
@@ -75,23 +75,23 @@ const docTemplate = `{
                 "tags": [
                     "auth"
                 ],
-                "summary": "Register a new user",
+                "summary": "Logs out a user",
                 "parameters": [
                     {
-                        "description": "User Registration Request",
+                        "description": "User Logout Request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.RegisterRequest"
+                            "$ref": "#/definitions/dtos.LogoutRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.RegisterResponse"
+                            "$ref": "#/definitions/dtos.LogoutResponse"
                         }
                     },
                     "default": {
@@ -103,30 +103,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/image": {
-            "get": {
-                "description": "Proxy an image from a given URL",
+        "/auth/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and a rotated refresh token. Reusing an already exchanged refresh token revokes the whole session.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
-                    "image/*"
+                    "application/json"
                 ],
                 "tags": [
-                    "image"
+                    "auth"
                 ],
-                "summary": "Proxy an image",
+                "summary": "Refresh an access token",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Image URL",
-                        "name": "url",
-                        "in": "query",
-                        "required": true
+                        "description": "Refresh Token Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RefreshRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "$ref": "#/definitions/dtos.LoginResponse"
                         }
                     },
                     "default": {
@@ -138,9 +143,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/item": {
+        "/auth/register": {
             "post": {
-                "description": "Add a new item to the database",
+                "description": "Creates a new user account",
                 "consumes": [
                     "application/json"
                 ],
@@ -148,17 +153,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "item"
+                    "auth"
                 ],
-                "summary": "Create an item",
+                "summary": "Register a new user",
                 "parameters": [
                     {
-                        "description": "New Item",
-                        "name": "item",
+                        "description": "User Registration Request",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.ItemRequest"
+                            "$ref": "#/definitions/dtos.RegisterRequest"
                         }
                     }
                 ],
@@ -166,7 +171,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/dtos.ItemResponse"
+                            "$ref": "#/definitions/dtos.RegisterResponse"
                         }
                     },
                     "default": {
@@ -178,33 +183,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/item/search": {
+        "/budget": {
             "get": {
-                "description": "Searches for items that match the provided keyword in their name or description",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get the authenticated user's monthly grocery budgets with this month's spend, and alerts for those exceeded",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "item"
+                    "budget"
                 ],
-                "summary": "Search items",
+                "summary": "Get budgets",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Search keyword",
-                        "name": "name",
-                        "in": "query",
-                        "required": true
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.ItemsResponse"
+                            "$ref": "#/definitions/dtos.BudgetsResponse"
                         }
                     },
                     "default": {
@@ -214,11 +215,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/item/{id}": {
-            "get": {
-                "description": "Get an item by its ID",
+            },
+            "put": {
+                "description": "Set the authenticated user's monthly grocery budget for an item category, or the overall budget when no category is given",
                 "consumes": [
                     "application/json"
                 ],
@@ -226,23 +225,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "item"
+                    "budget"
                 ],
-                "summary": "Get an item",
+                "summary": "Set a budget",
                 "parameters": [
+                    {
+                        "description": "Budget",
+                        "name": "budget",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.BudgetRequest"
+                        }
+                    },
                     {
                         "type": "integer",
-                        "description": "Item ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.ItemResponse"
+                            "$ref": "#/definitions/dtos.BudgetResponse"
                         }
                     },
                     "default": {
@@ -252,43 +259,36 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update an existing item by ID",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/budget/{id}": {
+            "delete": {
+                "description": "Delete one of the authenticated user's budgets",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "item"
+                    "budget"
                 ],
-                "summary": "Update an item",
+                "summary": "Delete a budget",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Item ID",
+                        "description": "Budget ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Updated Item Data",
-                        "name": "item",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dtos.ItemRequest"
-                        }
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/dtos.ItemResponse"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "default": {
                         "description": "Standard Error Responses",
@@ -297,31 +297,24 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Remove an item by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/household": {
+            "get": {
+                "description": "Get every household the authenticated user belongs to",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "item"
-                ],
-                "summary": "Delete an item",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Item ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "household"
                 ],
+                "summary": "Get all user's households",
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.HouseholdsResponse"
+                        }
                     },
                     "default": {
                         "description": "Standard Error Responses",
@@ -330,11 +323,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/recipe": {
+            },
             "post": {
-                "description": "Creates a new recipe",
+                "description": "Create a household with the authenticated user as its owner",
                 "consumes": [
                     "application/json"
                 ],
@@ -342,17 +333,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "recipe"
+                    "household"
                 ],
-                "summary": "Create a recipe",
+                "summary": "Create a household",
                 "parameters": [
                     {
-                        "description": "Recipe Data",
-                        "name": "recipe",
+                        "description": "Create Household",
+                        "name": "household",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.RecipeRequest"
+                            "$ref": "#/definitions/dtos.HouseholdRequest"
                         }
                     }
                 ],
@@ -360,7 +351,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/dtos.RecipeResponse"
+                            "$ref": "#/definitions/dtos.HouseholdResponse"
                         }
                     },
                     "default": {
@@ -372,9 +363,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/recipe/search": {
-            "get": {
-                "description": "Searches for recipes by title, ingredients, or diet type",
+        "/household/join": {
+            "post": {
+                "description": "Join a household as a member using its invite code",
                 "consumes": [
                     "application/json"
                 ],
@@ -382,34 +373,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "recipe"
+                    "household"
                 ],
-                "summary": "Search recipes",
+                "summary": "Join a household",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Title of recipe",
-                        "name": "title",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Comma-separated ingredient IDs",
-                        "name": "ingredients",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Diet type",
-                        "name": "diet",
-                        "in": "query"
+                        "description": "Invite Code",
+                        "name": "join",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.HouseholdJoinRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.RecipesResponse"
+                            "$ref": "#/definitions/dtos.HouseholdResponse"
                         }
                     },
                     "default": {
@@ -421,24 +403,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/recipe/{id}": {
+        "/household/{household_id}": {
             "get": {
-                "description": "Retrieves a recipe by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get a household the authenticated user belongs to, with its members",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "recipe"
+                    "household"
                 ],
-                "summary": "Get a recipe",
+                "summary": "Get a household",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Recipe ID",
-                        "name": "id",
+                        "description": "Household ID",
+                        "name": "household_id",
                         "in": "path",
                         "required": true
                     }
@@ -447,7 +426,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.RecipeResponse"
+                            "$ref": "#/definitions/dtos.HouseholdResponse"
                         }
                     },
                     "default": {
@@ -459,7 +438,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "Updates an existing recipe by ID",
+                "description": "Rename a household; only owners can do this",
                 "consumes": [
                     "application/json"
                 ],
@@ -467,24 +446,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "recipe"
+                    "household"
                 ],
-                "summary": "Update a recipe",
+                "summary": "Rename a household",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Recipe ID",
-                        "name": "id",
+                        "description": "Household ID",
+                        "name": "household_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Updated Recipe Data",
-                        "name": "recipe",
+                        "description": "Update Household",
+                        "name": "household",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.RecipeRequest"
+                            "$ref": "#/definitions/dtos.HouseholdRequest"
                         }
                     }
                 ],
@@ -492,7 +471,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.RecipeResponse"
+                            "$ref": "#/definitions/dtos.HouseholdResponse"
                         }
                     },
                     "default": {
@@ -504,22 +483,16 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Deletes a recipe by ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Delete a household with its shared pantry and shopping lists; only owners can do this",
                 "tags": [
-                    "recipe"
+                    "household"
                 ],
-                "summary": "Delete a recipe",
+                "summary": "Delete a household",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Recipe ID",
-                        "name": "id",
+                        "description": "Household ID",
+                        "name": "household_id",
                         "in": "path",
                         "required": true
                     }
@@ -537,21 +510,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/user_item": {
-            "get": {
-                "description": "Get all items for the authenticated user",
+        "/household/{household_id}/invite": {
+            "post": {
+                "description": "Replace the invite code so the old one stops working; only owners can do this",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user_item"
+                    "household"
+                ],
+                "summary": "Regenerate a household's invite code",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Household ID",
+                        "name": "household_id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get all user's items",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.UserItemsResponse"
+                            "$ref": "#/definitions/dtos.HouseholdResponse"
                         }
                     },
                     "default": {
@@ -561,36 +543,27 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/household/{household_id}/leave": {
             "post": {
-                "description": "Create a new item for the authenticated user",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Leave a household; the last owner has to hand over ownership first unless nobody else is left",
                 "tags": [
-                    "user_item"
+                    "household"
                 ],
-                "summary": "Create a new user_item for the authenticated user",
+                "summary": "Leave a household",
                 "parameters": [
                     {
-                        "description": "Create User Item",
-                        "name": "userItem",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dtos.UserItemRequest"
-                        }
+                        "type": "integer",
+                        "description": "Household ID",
+                        "name": "household_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/dtos.UserItemResponse"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "default": {
                         "description": "Standard Error Responses",
@@ -601,36 +574,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/user_item/detect": {
-            "post": {
-                "description": "Detect items from an uploaded image for the authenticated user using OpenAI's vision model",
+        "/household/{household_id}/member/{user_id}": {
+            "put": {
+                "description": "Promote a member to owner or demote an owner to member; only owners can do this",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user_item"
+                    "household"
                 ],
-                "summary": "Detect items from an uploaded image",
+                "summary": "Change a member's role",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "Image file",
-                        "name": "image",
-                        "in": "formData",
+                        "type": "integer",
+                        "description": "Household ID",
+                        "name": "household_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Member User ID",
+                        "name": "user_id",
+                        "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Member Role",
+                        "name": "member",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.HouseholdMemberRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/dtos.UserItemsResponse"
-                            }
+                            "$ref": "#/definitions/dtos.HouseholdResponse"
                         }
                     },
                     "default": {
@@ -640,35 +626,143 @@ const docTemplate = `{
                         }
                     }
                 }
+            },
+            "delete": {
+                "description": "Remove a member from a household; only owners can do this",
+                "tags": [
+                    "household"
+                ],
+                "summary": "Remove a member",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Household ID",
+                        "name": "household_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Member User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
             }
         },
-        "/user_item/predict": {
+        "/image": {
+            "get": {
+                "description": "Proxy an image from a given URL",
+                "produces": [
+                    "image/*"
+                ],
+                "tags": [
+                    "image"
+                ],
+                "summary": "Proxy an image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Image URL",
+                        "name": "url",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/item": {
             "post": {
-                "description": "Predict items from an uploaded image for the authenticated user",
+                "description": "Add a new item to the database",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user_item"
+                    "item"
                 ],
-                "summary": "Predict items from an uploaded image",
+                "summary": "Create an item",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "Image file",
-                        "name": "image",
-                        "in": "formData",
-                        "required": true
+                        "description": "New Item",
+                        "name": "item",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ItemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ItemResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/item/duplicates": {
+            "get": {
+                "description": "List groups of catalog items that are probably the same product, by normalized name and trigram similarity",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "item"
+                ],
+                "summary": "Find duplicate items",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Lowest name similarity (0-1) to report, defaults to 0.4",
+                        "name": "threshold",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.UserItemsResponse"
+                            "$ref": "#/definitions/dtos.ItemDuplicatesResponse"
                         }
                     },
                     "default": {
@@ -680,9 +774,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/user_item/search": {
+        "/item/merge": {
+            "post": {
+                "description": "Merge duplicate items into the one to keep. Pantry lots, recipe and shopping list entries and nutrients are moved over in one transaction, the duplicates are deleted and their names kept as aliases. Amounts on the same recipe or list are added up, and the merge fails with 409 when their units cannot be converted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "item"
+                ],
+                "summary": "Merge items",
+                "parameters": [
+                    {
+                        "description": "Item to keep and its duplicates",
+                        "name": "merge",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ItemMergeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ItemResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/item/search": {
             "get": {
-                "description": "Searches for user items by name",
+                "description": "Searches for items that match the provided keyword in their name or description",
                 "consumes": [
                     "application/json"
                 ],
@@ -690,22 +824,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user_item"
+                    "item"
                 ],
-                "summary": "Search user items",
+                "summary": "Search items",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Name of user item",
+                        "description": "Search keyword",
                         "name": "name",
-                        "in": "query"
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.UserItemsResponse"
+                            "$ref": "#/definitions/dtos.ItemsResponse"
                         }
                     },
                     "default": {
@@ -717,21 +852,24 @@ const docTemplate = `{
                 }
             }
         },
-        "/user_item/{item_id}": {
+        "/item/{id}": {
             "get": {
-                "description": "Get a specific item for the authenticated user by item ID",
+                "description": "Get an item by its ID",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user_item"
+                    "item"
                 ],
-                "summary": "Get a user's item by ItemID",
+                "summary": "Get an item",
                 "parameters": [
                     {
                         "type": "integer",
                         "description": "Item ID",
-                        "name": "item_id",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -740,7 +878,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.UserItemResponse"
+                            "$ref": "#/definitions/dtos.ItemResponse"
                         }
                     },
                     "default": {
@@ -752,7 +890,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "Update a user_item for the authenticated user",
+                "description": "Update an existing item by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -760,24 +898,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user_item"
+                    "item"
                 ],
-                "summary": "Update a user_item for the authenticated user",
+                "summary": "Update an item",
                 "parameters": [
                     {
                         "type": "integer",
                         "description": "Item ID",
-                        "name": "item_id",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Update User Item",
-                        "name": "userItem",
+                        "description": "Updated Item Data",
+                        "name": "item",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.UserItemRequest"
+                            "$ref": "#/definitions/dtos.ItemRequest"
                         }
                     }
                 ],
@@ -785,7 +923,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dtos.UserItemResponse"
+                            "$ref": "#/definitions/dtos.ItemResponse"
                         }
                     },
                     "default": {
@@ -797,16 +935,22 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Delete a user_item for the authenticated user",
+                "description": "Remove an item by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "user_item"
+                    "item"
                 ],
-                "summary": "Delete a user_item for the authenticated user",
+                "summary": "Delete an item",
                 "parameters": [
                     {
                         "type": "integer",
                         "description": "Item ID",
-                        "name": "item_id",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -823,519 +967,4219 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dtos.BadRequestResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string",
-                    "example": "Invalid request data"
-                }
-            }
-        },
-        "dtos.ConflictResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string",
-                    "example": "User already exists"
-                }
-            }
         },
-        "dtos.DietCount": {
-            "type": "object",
-            "properties": {
-                "count": {
-                    "type": "integer"
-                },
-                "vegan": {
-                    "type": "boolean"
-                },
-                "vegetarian": {
-                    "type": "boolean"
-                }
+        "/item/{id}/alias": {
+            "get": {
+                "description": "Get the other names an item is matched by when detected",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "item"
+                ],
+                "summary": "List item aliases",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ItemAliasesResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Make detections of another name resolve to this item. An alias already used by another item is moved to this one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "item"
+                ],
+                "summary": "Add an item alias",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Alias",
+                        "name": "alias",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ItemAliasRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ItemAliasResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/item/{id}/alias/{alias_id}": {
+            "delete": {
+                "description": "Remove one of an item's aliases",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "item"
+                ],
+                "summary": "Delete an item alias",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Alias ID",
+                        "name": "alias_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/purchase": {
+            "get": {
+                "description": "Get the authenticated user's purchases, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchase"
+                ],
+                "summary": "Get purchases",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day, such as 2025-03-01",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, such as 2025-03-31",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Store name",
+                        "name": "store",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.PurchasesResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Record a shopping trip of the authenticated user with the price paid for each item",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchase"
+                ],
+                "summary": "Record a purchase",
+                "parameters": [
+                    {
+                        "description": "Purchase",
+                        "name": "purchase",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.PurchaseRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.PurchaseResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/purchase/price/{item_id}": {
+            "get": {
+                "description": "Get what the authenticated user paid for an item, newest first, with the average unit price overall and per store",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchase"
+                ],
+                "summary": "Get the price history of an item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit to give prices per, defaults to the unit of the latest purchase",
+                        "name": "unit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ItemPriceHistoryResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/purchase/{id}": {
+            "get": {
+                "description": "Get a purchase of the authenticated user by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchase"
+                ],
+                "summary": "Get a purchase",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Purchase ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.PurchaseResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a purchase of the authenticated user. Pantry items added with it are kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchase"
+                ],
+                "summary": "Delete a purchase",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Purchase ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/queue": {
+            "get": {
+                "description": "Count the item enrichment jobs that are queued, being worked on, waiting for a retry and dead-lettered",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "queue"
+                ],
+                "summary": "Get enrichment queue stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.QueueStatsResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/queue/dead": {
+            "get": {
+                "description": "List the item enrichment jobs that failed too often, or that Spoonacular had no match for, most recent first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "queue"
+                ],
+                "summary": "List dead-lettered enrichment jobs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.DeadQueueItemsResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/queue/dead/requeue": {
+            "post": {
+                "description": "Put every dead-lettered job back in the queue with its attempts reset",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "queue"
+                ],
+                "summary": "Requeue every dead-lettered enrichment job",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RequeueResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/queue/dead/{item_id}/requeue": {
+            "post": {
+                "description": "Put the dead-lettered job of an item back in the queue with its attempts reset",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "queue"
+                ],
+                "summary": "Requeue a dead-lettered enrichment job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RequeueResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/queue/quota": {
+            "get": {
+                "description": "Get how many Spoonacular points have been used and are left today, as last reported by Spoonacular",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "queue"
+                ],
+                "summary": "Get the Spoonacular quota",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.SpoonacularQuotaResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipe": {
+            "post": {
+                "description": "Creates a new recipe",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recipe"
+                ],
+                "summary": "Create a recipe",
+                "parameters": [
+                    {
+                        "description": "Recipe Data",
+                        "name": "recipe",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RecipeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RecipeResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipe/search": {
+            "get": {
+                "description": "Searches for recipes by title, ingredients, or diet type",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recipe"
+                ],
+                "summary": "Search recipes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Title of recipe",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated ingredient IDs",
+                        "name": "ingredients",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Diet type",
+                        "name": "diet",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RecipesResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipe/suggest": {
+            "get": {
+                "description": "Ranks recipes by how much of their ingredients the authenticated user's items cover, then by the number of missing ingredients. The user's diet preference applies unless diet is given.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recipe"
+                ],
+                "summary": "Suggest recipes for the user's pantry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Diet type, overrides the user's preference",
+                        "name": "diet",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Page size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RecipeSuggestionsResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipe/{id}": {
+            "get": {
+                "description": "Retrieves a recipe by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recipe"
+                ],
+                "summary": "Get a recipe",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RecipeResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates an existing recipe by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recipe"
+                ],
+                "summary": "Update a recipe",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated Recipe Data",
+                        "name": "recipe",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RecipeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.RecipeResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a recipe by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recipe"
+                ],
+                "summary": "Delete a recipe",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipe/{id}/cook": {
+            "post": {
+                "description": "Deducts the recipe's ingredients, scaled to the requested servings, from the authenticated user's items in one transaction. Items that reach zero are removed. Returns the pantry changes and any shortfalls.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recipe"
+                ],
+                "summary": "Cook a recipe",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Servings to cook",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CookRecipeRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.CookRecipeResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recipe/{id}/missing": {
+            "post": {
+                "description": "Compares the recipe's ingredients, scaled to the requested servings, against the authenticated user's items and returns what is missing. If a shopping list ID is given, the missing items are appended to it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "recipe"
+                ],
+                "summary": "Get the items missing to cook a recipe",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Recipe ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Servings and optional shopping list",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.MissingItemsRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.MissingItemsResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/report/spend": {
+            "get": {
+                "description": "Total what the authenticated user spent on groceries in a period, grouped by item category, store or week, with alerts for budgets exceeded this month",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "budget"
+                ],
+                "summary": "Get a spend report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day, defaults to the first of this month",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, defaults to today",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "category",
+                            "store",
+                            "week"
+                        ],
+                        "type": "string",
+                        "default": "category",
+                        "description": "Grouping",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.SpendReportResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/report/waste": {
+            "get": {
+                "description": "Sum the food the authenticated user threw away in a period, month by month: how often, why, the estimated cost and the items wasted most. Costs are estimated from the prices paid for each item. Deleted items only count when they had expired.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Get a waste report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day, defaults to the first of the month five months ago",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, defaults to today",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Most-wasted items listed per month",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.WasteReportResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shopping_list": {
+            "get": {
+                "description": "Get all shopping lists of the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shopping_list"
+                ],
+                "summary": "Get all user's shopping lists",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ShoppingListsResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new shopping list for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shopping_list"
+                ],
+                "summary": "Create a shopping list",
+                "parameters": [
+                    {
+                        "description": "Create Shopping List",
+                        "name": "shoppingList",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ShoppingListRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ShoppingListResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shopping_list/{id}": {
+            "get": {
+                "description": "Get a shopping list of the authenticated user by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shopping_list"
+                ],
+                "summary": "Get a shopping list",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shopping List ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ShoppingListResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Rename a shopping list of the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shopping_list"
+                ],
+                "summary": "Update a shopping list",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shopping List ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Shopping List",
+                        "name": "shoppingList",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ShoppingListRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ShoppingListResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a shopping list of the authenticated user",
+                "tags": [
+                    "shopping_list"
+                ],
+                "summary": "Delete a shopping list",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shopping List ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shopping_list/{id}/item": {
+            "post": {
+                "description": "Add an item to a shopping list; the amount is increased if the item is already on the list, and the request fails with 409 if its unit cannot be converted to the listed one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shopping_list"
+                ],
+                "summary": "Add an item to a shopping list",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shopping List ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Add Shopping List Item",
+                        "name": "shoppingListItem",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ShoppingListItemRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ShoppingListResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shopping_list/{id}/item/{item_id}": {
+            "put": {
+                "description": "Update the amount and unit of an item on a shopping list",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shopping_list"
+                ],
+                "summary": "Update an item of a shopping list",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shopping List ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Shopping List Item",
+                        "name": "shoppingListItem",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ShoppingListItemRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ShoppingListResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove an item from a shopping list of the authenticated user",
+                "tags": [
+                    "shopping_list"
+                ],
+                "summary": "Remove an item from a shopping list",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shopping List ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shopping_list/{id}/item/{item_id}/check": {
+            "put": {
+                "description": "Mark an item on a shopping list as purchased or not purchased",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shopping_list"
+                ],
+                "summary": "Check off an item of a shopping list",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shopping List ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Check Shopping List Item",
+                        "name": "check",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ShoppingListItemCheckRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ShoppingListResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shopping_list/{id}/purchase": {
+            "post": {
+                "description": "Adds every checked item of a shopping list to the authenticated user's items and removes it from the list",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shopping_list"
+                ],
+                "summary": "Move purchased items into the pantry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shopping List ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemsResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_item": {
+            "get": {
+                "description": "Get all items for the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Get all user's items",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemsResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new item for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Create a new user_item for the authenticated user",
+                "parameters": [
+                    {
+                        "description": "Create User Item",
+                        "name": "userItem",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_item/barcode": {
+            "post": {
+                "description": "Resolve an EAN/UPC barcode to an item and add a package of it to the authenticated user's items. Codes not in the catalog are looked up in the product database; item_id links a code the database does not know.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Add a scanned package",
+                "parameters": [
+                    {
+                        "description": "Scanned barcode",
+                        "name": "barcode",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.BarcodeRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_item/detect": {
+            "post": {
+                "description": "Detect items and their amounts in an uploaded image with the configured detector and add them to the authenticated user's items. With draft=true nothing is added; the results are returned as a pending scan to review and commit.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Detect items from an uploaded image",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Image file",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Store the results as a pending scan instead of adding them",
+                        "name": "draft",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemsResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Pending scan, when draft is set",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ScanResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_item/expiring": {
+            "get": {
+                "description": "Get the authenticated user's items that expire within the given period, including already expired ones, soonest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Get user's items about to expire",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "3d",
+                        "description": "Period such as 3d, 1w or 12h",
+                        "name": "within",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemsResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_item/lot/{lot_id}": {
+            "put": {
+                "description": "Replace the amount and unit of one lot; an amount of zero removes the lot",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Update a single lot of a user_item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Lot ID",
+                        "name": "lot_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Lot",
+                        "name": "lot",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemLotRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete one lot, leaving the other lots of the item in the pantry",
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Delete a single lot of a user_item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Lot ID",
+                        "name": "lot_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_item/predict": {
+            "post": {
+                "description": "Same as /user_item/detect, kept for older clients",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Predict items from an uploaded image",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Image file",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Store the results as a pending scan instead of adding them",
+                        "name": "draft",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemsResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Pending scan, when draft is set",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ScanResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_item/receipt": {
+            "post": {
+                "description": "Read a photo of a grocery receipt and record it as a purchase with its store, date, lines and prices. Lines matching a known item are added to the authenticated user's items, bought on the receipt's date; the others are returned as a pending scan to review and commit.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Import a grocery receipt",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Receipt image",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ReceiptResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_item/scan/{scan_id}": {
+            "get": {
+                "description": "Get a scan created by a draft detection, with the detected lines and their confidences",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Get a scan",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Scan ID",
+                        "name": "scan_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ScanResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Discard a pending scan without adding anything to the pantry",
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Discard a scan",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Scan ID",
+                        "name": "scan_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_item/scan/{scan_id}/commit": {
+            "post": {
+                "description": "Accept, edit or reject each line of a pending scan. Accepted and edited lines are added to the pantry; lines without a decision are rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Commit a reviewed scan",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Scan ID",
+                        "name": "scan_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Line decisions",
+                        "name": "review",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ScanCommitRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemsResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_item/search": {
+            "get": {
+                "description": "Searches for user items by name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Search user items",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of user item",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemsResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_item/{item_id}": {
+            "get": {
+                "description": "Get a specific item for the authenticated user by item ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Get a user's item by ItemID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Set the total amount of a user_item for the authenticated user. A price is recorded as a purchase of the amount added and is refused when the amount does not go up.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Update a user_item for the authenticated user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update User Item",
+                        "name": "userItem",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.UserItemResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a user_item for the authenticated user",
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Delete a user_item for the authenticated user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_item/{item_id}/discard": {
+            "post": {
+                "description": "Throw away an amount of an item, or all of it when no amount is given, for a reason: expired, spoiled or leftover. The lots that expire first go first. Unlike a delete, this counts as food waste in the waste report.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Discard a user_item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "What to throw away and why",
+                        "name": "discard",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.DiscardRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.DiscardResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user_item/{item_id}/history": {
+            "get": {
+                "description": "Lists every change to the pantry's lots of an item, newest first: what was added, consumed, adjusted, discarded or thrown out expired, by whom and through which feature. Lots that have since been used up or deleted are included.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_item"
+                ],
+                "summary": "Get the history of a user item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "item_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Household whose shared pantry to use instead of the user's own",
+                        "name": "X-Household-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.PantryEventsResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "Standard Error Responses",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dtos.BadRequestResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Invalid request data"
+                }
+            }
+        },
+        "dtos.BarcodeRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 400
+                },
+                "barcode": {
+                    "type": "string",
+                    "example": "3017620422003"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-03-08T10:00:00Z"
+                },
+                "item_id": {
+                    "type": "integer",
+                    "example": 456
+                },
+                "location": {
+                    "type": "string",
+                    "example": "pantry"
+                },
+                "purchased_at": {
+                    "type": "string",
+                    "example": "2025-03-01T10:00:00Z"
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "g"
+                }
+            }
+        },
+        "dtos.BoundingBoxResponse": {
+            "type": "object",
+            "properties": {
+                "height": {
+                    "type": "number",
+                    "example": 0.2
+                },
+                "width": {
+                    "type": "number",
+                    "example": 0.15
+                },
+                "x": {
+                    "type": "number",
+                    "example": 0.1
+                },
+                "y": {
+                    "type": "number",
+                    "example": 0.2
+                }
+            }
+        },
+        "dtos.BudgetAlert": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 60
+                },
+                "category": {
+                    "type": "string",
+                    "example": "dairy"
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2025-03"
+                },
+                "spent": {
+                    "type": "number",
+                    "example": 72.5
+                }
+            }
+        },
+        "dtos.BudgetRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 60
+                },
+                "category": {
+                    "type": "string",
+                    "example": "dairy"
+                }
+            }
+        },
+        "dtos.BudgetResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 60
+                },
+                "category": {
+                    "type": "string",
+                    "example": "dairy"
+                },
+                "exceeded": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "remaining": {
+                    "type": "number",
+                    "example": -12.5
+                },
+                "spent": {
+                    "type": "number",
+                    "example": 72.5
+                }
+            }
+        },
+        "dtos.BudgetsResponse": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.BudgetAlert"
+                    }
+                },
+                "budgets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.BudgetResponse"
+                    }
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2025-03"
+                }
+            }
+        },
+        "dtos.ConflictResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "User already exists"
+                }
+            }
+        },
+        "dtos.CookRecipeRequest": {
+            "type": "object",
+            "properties": {
+                "servings": {
+                    "type": "number",
+                    "example": 4
+                }
+            }
+        },
+        "dtos.CookRecipeResponse": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.PantryChangeResponse"
+                    }
+                },
+                "recipe_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "servings": {
+                    "type": "number",
+                    "example": 4
+                },
+                "shortfalls": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.MissingItemResponse"
+                    }
+                }
+            }
+        },
+        "dtos.DeadQueueItemsResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.QueueItemResponse"
+                    }
+                }
+            }
+        },
+        "dtos.DietCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "vegan": {
+                    "type": "boolean"
+                },
+                "vegetarian": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dtos.DiscardRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 0.5
+                },
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "expired",
+                        "spoiled",
+                        "leftover"
+                    ],
+                    "example": "spoiled"
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "kg"
+                }
+            }
+        },
+        "dtos.DiscardResponse": {
+            "type": "object",
+            "properties": {
+                "discarded": {
+                    "type": "number",
+                    "example": 0.5
+                },
+                "estimated_cost": {
+                    "type": "number",
+                    "example": 2.1
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "kg"
+                },
+                "user_item": {
+                    "$ref": "#/definitions/dtos.UserItemResponse"
+                }
+            }
+        },
+        "dtos.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "An error occurred"
+                }
+            }
+        },
+        "dtos.ForbiddenResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Access denied"
+                }
+            }
+        },
+        "dtos.HouseholdJoinRequest": {
+            "type": "object",
+            "properties": {
+                "invite_code": {
+                    "type": "string",
+                    "example": "K7Q2M9XA"
+                }
+            }
+        },
+        "dtos.HouseholdMemberRequest": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "member"
+                    ],
+                    "example": "member"
+                }
+            }
+        },
+        "dtos.HouseholdMemberResponse": {
+            "type": "object",
+            "properties": {
+                "joined_at": {
+                    "type": "string",
+                    "example": "2025-03-01T10:00:00Z"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "owner"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "username": {
+                    "type": "string",
+                    "example": "john"
+                }
+            }
+        },
+        "dtos.HouseholdRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Smith family"
+                }
+            }
+        },
+        "dtos.HouseholdResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-03-01T10:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "invite_code": {
+                    "type": "string",
+                    "example": "K7Q2M9XA"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.HouseholdMemberResponse"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Smith family"
+                }
+            }
+        },
+        "dtos.HouseholdsResponse": {
+            "type": "object",
+            "properties": {
+                "households": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.HouseholdResponse"
+                    }
+                }
+            }
+        },
+        "dtos.InternalServerErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Internal server error"
+                }
+            }
+        },
+        "dtos.ItemAliasRequest": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string",
+                    "example": "courgette"
+                }
+            }
+        },
+        "dtos.ItemAliasResponse": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string",
+                    "example": "courgette"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "item_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dtos.ItemAliasesResponse": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ItemAliasResponse"
+                    }
+                }
+            }
+        },
+        "dtos.ItemDuplicateGroup": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ItemResponse"
+                    }
+                },
+                "score": {
+                    "type": "number",
+                    "example": 0.82
+                },
+                "suggested_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dtos.ItemDuplicatesResponse": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ItemDuplicateGroup"
+                    }
+                }
+            }
+        },
+        "dtos.ItemMergeRequest": {
+            "type": "object",
+            "properties": {
+                "duplicate_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        2,
+                        3
+                    ]
+                },
+                "item_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dtos.ItemNutrientRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 200
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Calories"
+                },
+                "percentOfDailyNeeds": {
+                    "type": "number",
+                    "example": 100
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "kcal"
+                }
+            }
+        },
+        "dtos.ItemNutrientResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 200
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Calories"
+                },
+                "percentOfDailyNeeds": {
+                    "type": "number",
+                    "example": 100
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "kcal"
+                }
+            }
+        },
+        "dtos.ItemPriceHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "average_unit_price": {
+                    "type": "number",
+                    "example": 2.89
+                },
+                "item": {
+                    "$ref": "#/definitions/dtos.ItemResponse"
+                },
+                "prices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ItemPriceResponse"
+                    }
+                },
+                "stores": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.StorePriceResponse"
+                    }
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "l"
+                }
+            }
+        },
+        "dtos.ItemPriceResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 1
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "price": {
+                    "type": "number",
+                    "example": 2.99
+                },
+                "purchase_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "purchased_at": {
+                    "type": "string",
+                    "example": "2025-03-01T00:00:00Z"
+                },
+                "store": {
+                    "type": "string",
+                    "example": "Corner Market"
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "l"
+                },
+                "unit_price": {
+                    "type": "number",
+                    "example": 2.99
+                }
+            }
+        },
+        "dtos.ItemRequest": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string",
+                    "example": "dairy"
+                },
+                "density": {
+                    "type": "number",
+                    "example": 1.03
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "image": {
+                    "type": "string",
+                    "example": "milk.jpg"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Milk"
+                },
+                "nutrients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ItemNutrientRequest"
+                    }
+                },
+                "shelf_life_days": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "spoonacular_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dtos.ItemResponse": {
+            "type": "object",
+            "properties": {
+                "barcodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "3017620422003"
+                    ]
+                },
+                "category": {
+                    "type": "string",
+                    "example": "dairy"
+                },
+                "density": {
+                    "type": "number",
+                    "example": 1.03
+                },
+                "enriched_at": {
+                    "type": "string",
+                    "example": "2025-03-01T10:00:00Z"
+                },
+                "enrichment_status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "enriched",
+                        "failed",
+                        "not_found"
+                    ],
+                    "example": "enriched"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "image": {
+                    "type": "string",
+                    "example": "milk.jpg"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Milk"
+                },
+                "nutrients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ItemNutrientResponse"
+                    }
+                },
+                "shelf_life_days": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "spoonacular_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dtos.ItemsResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ItemResponse"
+                    }
+                }
+            }
+        },
+        "dtos.LoginRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "securepassword123"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "john_doe"
+                }
+            }
+        },
+        "dtos.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "type": "integer",
+                    "example": 900
+                },
+                "refresh_token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR..."
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR..."
+                }
+            }
+        },
+        "dtos.LogoutRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR..."
+                }
+            }
+        },
+        "dtos.LogoutResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Logged out successfully"
+                }
+            }
+        },
+        "dtos.MissingItemResponse": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "number",
+                    "example": 200
+                },
+                "item": {
+                    "$ref": "#/definitions/dtos.ItemResponse"
+                },
+                "missing": {
+                    "type": "number",
+                    "example": 300
+                },
+                "required": {
+                    "type": "number",
+                    "example": 500
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "g"
+                }
+            }
+        },
+        "dtos.MissingItemsRequest": {
+            "type": "object",
+            "properties": {
+                "servings": {
+                    "type": "number",
+                    "example": 4
+                },
+                "shopping_list_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dtos.MissingItemsResponse": {
+            "type": "object",
+            "properties": {
+                "missing_items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.MissingItemResponse"
+                    }
+                },
+                "recipe_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "servings": {
+                    "type": "number",
+                    "example": 4
+                },
+                "shopping_list": {
+                    "$ref": "#/definitions/dtos.ShoppingListResponse"
+                }
+            }
+        },
+        "dtos.NotFoundResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Resource not found"
+                }
+            }
+        },
+        "dtos.PantryChangeResponse": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "number",
+                    "example": 500
+                },
+                "before": {
+                    "type": "number",
+                    "example": 1000
+                },
+                "item": {
+                    "$ref": "#/definitions/dtos.ItemResponse"
+                },
+                "removed": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "g"
+                }
+            }
+        },
+        "dtos.PantryEventResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": -0.5
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-03-01T10:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "lot_id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "expired",
+                        "spoiled",
+                        "leftover"
+                    ],
+                    "example": "spoiled"
+                },
+                "source": {
+                    "type": "string",
+                    "enum": [
+                        "manual",
+                        "detect",
+                        "predict",
+                        "cook",
+                        "receipt",
+                        "barcode",
+                        "shopping_list"
+                    ],
+                    "example": "cook"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "added",
+                        "consumed",
+                        "adjusted",
+                        "discarded",
+                        "expired"
+                    ],
+                    "example": "consumed"
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "kg"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "dtos.PantryEventsResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.PantryEventResponse"
+                    }
+                },
+                "item": {
+                    "$ref": "#/definitions/dtos.ItemResponse"
+                }
+            }
+        },
+        "dtos.PurchaseLineRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 1
+                },
+                "item_id": {
+                    "type": "integer",
+                    "example": 456
+                },
+                "price": {
+                    "type": "number",
+                    "example": 2.99
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "l"
+                }
+            }
+        },
+        "dtos.PurchaseLineResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "item": {
+                    "$ref": "#/definitions/dtos.ItemResponse"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Milk"
+                },
+                "price": {
+                    "type": "number",
+                    "example": 2.99
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "l"
+                }
+            }
+        },
+        "dtos.PurchaseRequest": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.PurchaseLineRequest"
+                    }
+                },
+                "purchased_at": {
+                    "type": "string",
+                    "example": "2025-03-01T10:00:00Z"
+                },
+                "store": {
+                    "type": "string",
+                    "example": "Corner Market"
+                },
+                "total": {
+                    "type": "number",
+                    "example": 5.48
+                }
+            }
+        },
+        "dtos.PurchaseResponse": {
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "type": "string",
+                    "example": "USD"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.PurchaseLineResponse"
+                    }
+                },
+                "purchased_at": {
+                    "type": "string",
+                    "example": "2025-03-01T00:00:00Z"
+                },
+                "store": {
+                    "type": "string",
+                    "example": "Corner Market"
+                },
+                "total": {
+                    "type": "number",
+                    "example": 5.48
+                }
+            }
+        },
+        "dtos.PurchasesResponse": {
+            "type": "object",
+            "properties": {
+                "purchases": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.PurchaseResponse"
+                    }
+                }
+            }
+        },
+        "dtos.QueueItemResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-03-01T10:00:00Z"
+                },
+                "failed_at": {
+                    "type": "string",
+                    "example": "2025-03-01T16:12:00Z"
+                },
+                "item_id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "last_error": {
+                    "type": "string",
+                    "example": "unexpected status code: 500"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "oat milk"
+                },
+                "priority": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "dtos.QueueStatsResponse": {
+            "type": "object",
+            "properties": {
+                "dead": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "in_flight": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "queued": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "retrying": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "dtos.ReceiptResponse": {
+            "type": "object",
+            "properties": {
+                "purchase": {
+                    "$ref": "#/definitions/dtos.PurchaseResponse"
+                },
+                "scan": {
+                    "$ref": "#/definitions/dtos.ScanResponse"
+                },
+                "user_items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.UserItemResponse"
+                    }
+                }
+            }
+        },
+        "dtos.RecipeInstructionRequest": {
+            "type": "object",
+            "properties": {
+                "number": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "step": {
+                    "type": "string",
+                    "example": "Bring a large pot of salted water to boil"
+                }
+            }
+        },
+        "dtos.RecipeInstructionResponse": {
+            "type": "object",
+            "properties": {
+                "number": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "step": {
+                    "type": "string",
+                    "example": "Bring a large pot of salted water to boil"
+                }
+            }
+        },
+        "dtos.RecipeItemRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 2.5
+                },
+                "item_id": {
+                    "type": "integer",
+                    "example": 456
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "cups"
+                }
+            }
+        },
+        "dtos.RecipeItemResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 2.5
+                },
+                "item": {
+                    "$ref": "#/definitions/dtos.ItemResponse"
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "cups"
+                }
+            }
+        },
+        "dtos.RecipeNutrientRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 200
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Calories"
+                },
+                "percentOfDailyNeeds": {
+                    "type": "number",
+                    "example": 100
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "kcal"
+                }
+            }
+        },
+        "dtos.RecipeNutrientResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 200
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Calories"
+                },
+                "percentOfDailyNeeds": {
+                    "type": "number",
+                    "example": 100
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "kcal"
+                }
+            }
+        },
+        "dtos.RecipeRequest": {
+            "type": "object",
+            "properties": {
+                "cooking_time": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "image": {
+                    "type": "string",
+                    "example": "https://example.com/spaghetti.jpg"
+                },
+                "ingredients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.RecipeItemRequest"
+                    }
+                },
+                "instructions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.RecipeInstructionRequest"
+                    }
+                },
+                "kcal": {
+                    "type": "number",
+                    "example": 450.5
+                },
+                "nutrients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.RecipeNutrientRequest"
+                    }
+                },
+                "prep_time": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "ready_time": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "servings": {
+                    "type": "number",
+                    "example": 4
+                },
+                "spoonacular_id": {
+                    "type": "integer",
+                    "example": 12345
+                },
+                "summary": {
+                    "type": "string",
+                    "example": "A classic Italian pasta dish with eggs, cheese, pancetta, and black pepper."
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Spaghetti Carbonara"
+                },
+                "vegan": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "vegetarian": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "dtos.RecipeResponse": {
+            "type": "object",
+            "properties": {
+                "cooking_time": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "image": {
+                    "type": "string",
+                    "example": "https://example.com/spaghetti.jpg"
+                },
+                "ingredients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.RecipeItemResponse"
+                    }
+                },
+                "instructions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.RecipeInstructionResponse"
+                    }
+                },
+                "kcal": {
+                    "type": "number",
+                    "example": 450.5
+                },
+                "nutrients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.RecipeNutrientResponse"
+                    }
+                },
+                "prep_time": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "ready_time": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "servings": {
+                    "type": "number",
+                    "example": 4
+                },
+                "spoonacular_id": {
+                    "type": "integer",
+                    "example": 12345
+                },
+                "summary": {
+                    "type": "string",
+                    "example": "A classic Italian pasta dish with eggs, cheese, pancetta, and black pepper."
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Spaghetti Carbonara"
+                },
+                "vegan": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "vegetarian": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "dtos.RecipeSuggestionResponse": {
+            "type": "object",
+            "properties": {
+                "coverage": {
+                    "type": "number",
+                    "example": 75
+                },
+                "matched_count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "missing_count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "missing_items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.MissingItemResponse"
+                    }
+                },
+                "recipe": {
+                    "$ref": "#/definitions/dtos.RecipeResponse"
+                }
             }
         },
-        "dtos.ErrorResponse": {
+        "dtos.RecipeSuggestionsResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "count": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "page_size": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "suggestions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.RecipeSuggestionResponse"
+                    }
+                }
+            }
+        },
+        "dtos.RecipesResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "diet_counts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.DietCount"
+                    }
+                },
+                "recipes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.RecipeResponse"
+                    }
+                }
+            }
+        },
+        "dtos.RefreshRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
                     "type": "string",
-                    "example": "An error occurred"
+                    "example": "eyJhbGciOiJIUzI1NiIsInR..."
                 }
             }
         },
-        "dtos.ForbiddenResponse": {
+        "dtos.RegisterRequest": {
             "type": "object",
             "properties": {
-                "error": {
+                "password": {
                     "type": "string",
-                    "example": "Access denied"
+                    "example": "securepassword123"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "john_doe"
                 }
             }
         },
-        "dtos.InternalServerErrorResponse": {
+        "dtos.RegisterResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "message": {
                     "type": "string",
-                    "example": "Internal server error"
+                    "example": "User registered successfully"
                 }
             }
         },
-        "dtos.ItemNutrientRequest": {
+        "dtos.RequeueResponse": {
+            "type": "object",
+            "properties": {
+                "requeued": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dtos.ScanCommitRequest": {
+            "type": "object",
+            "properties": {
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ScanLineDecision"
+                    }
+                }
+            }
+        },
+        "dtos.ScanLineDecision": {
             "type": "object",
             "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "accept",
+                        "edit",
+                        "reject"
+                    ],
+                    "example": "edit"
+                },
                 "amount": {
                     "type": "number",
-                    "example": 200
+                    "example": 4
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "item_id": {
+                    "type": "integer",
+                    "example": 12
                 },
                 "name": {
                     "type": "string",
-                    "example": "Calories"
-                },
-                "percentOfDailyNeeds": {
-                    "type": "number",
-                    "example": 100
+                    "example": "Green apple"
                 },
                 "unit": {
                     "type": "string",
-                    "example": "kcal"
+                    "example": "unit"
                 }
             }
         },
-        "dtos.ItemNutrientResponse": {
+        "dtos.ScanLineResponse": {
             "type": "object",
             "properties": {
                 "amount": {
                     "type": "number",
-                    "example": 200
+                    "example": 3
+                },
+                "box": {
+                    "$ref": "#/definitions/dtos.BoundingBoxResponse"
+                },
+                "confidence": {
+                    "type": "number",
+                    "example": 0.93
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "item": {
+                    "$ref": "#/definitions/dtos.ItemResponse"
+                },
+                "match_score": {
+                    "type": "number",
+                    "example": 1
                 },
                 "name": {
                     "type": "string",
-                    "example": "Calories"
+                    "example": "Apple"
                 },
-                "percentOfDailyNeeds": {
-                    "type": "number",
-                    "example": 100
+                "suggestion": {
+                    "$ref": "#/definitions/dtos.ItemResponse"
                 },
                 "unit": {
                     "type": "string",
-                    "example": "kcal"
+                    "example": "unit"
                 }
             }
         },
-        "dtos.ItemRequest": {
+        "dtos.ScanResponse": {
             "type": "object",
             "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-03-01T10:00:00Z"
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
                 },
-                "image": {
-                    "type": "string",
-                    "example": "milk.jpg"
-                },
-                "name": {
-                    "type": "string",
-                    "example": "Milk"
-                },
-                "nutrients": {
+                "lines": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dtos.ItemNutrientRequest"
+                        "$ref": "#/definitions/dtos.ScanLineResponse"
                     }
                 },
-                "spoonacular_id": {
-                    "type": "integer",
-                    "example": 1
+                "source": {
+                    "type": "string",
+                    "enum": [
+                        "detect",
+                        "predict",
+                        "receipt"
+                    ],
+                    "example": "detect"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "committed",
+                        "discarded"
+                    ],
+                    "example": "pending"
                 }
             }
         },
-        "dtos.ItemResponse": {
+        "dtos.ShoppingListItemCheckRequest": {
             "type": "object",
             "properties": {
-                "id": {
+                "checked": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "dtos.ShoppingListItemRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 2
+                },
+                "item_id": {
                     "type": "integer",
-                    "example": 1
+                    "example": 456
                 },
-                "image": {
+                "unit": {
                     "type": "string",
-                    "example": "milk.jpg"
+                    "example": "kg"
+                }
+            }
+        },
+        "dtos.ShoppingListItemResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 2
                 },
-                "name": {
-                    "type": "string",
-                    "example": "Milk"
+                "checked": {
+                    "type": "boolean",
+                    "example": false
                 },
-                "nutrients": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.ItemNutrientResponse"
-                    }
+                "estimated_cost": {
+                    "type": "number",
+                    "example": 5.98
                 },
-                "spoonacular_id": {
-                    "type": "integer",
-                    "example": 1
+                "item": {
+                    "$ref": "#/definitions/dtos.ItemResponse"
+                },
+                "unit": {
+                    "type": "string",
+                    "example": "kg"
                 }
             }
         },
-        "dtos.ItemsResponse": {
+        "dtos.ShoppingListRequest": {
             "type": "object",
             "properties": {
-                "items": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.ItemResponse"
-                    }
+                "name": {
+                    "type": "string",
+                    "example": "Weekly groceries"
                 }
             }
         },
-        "dtos.LoginRequest": {
+        "dtos.ShoppingListResponse": {
             "type": "object",
             "properties": {
-                "password": {
+                "created_at": {
                     "type": "string",
-                    "example": "securepassword123"
+                    "example": "2025-03-01T10:00:00Z"
                 },
-                "username": {
+                "estimated_cost": {
+                    "type": "number",
+                    "example": 23.45
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ShoppingListItemResponse"
+                    }
+                },
+                "name": {
                     "type": "string",
-                    "example": "john_doe"
+                    "example": "Weekly groceries"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-03-01T10:00:00Z"
                 }
             }
         },
-        "dtos.LoginResponse": {
+        "dtos.ShoppingListsResponse": {
             "type": "object",
             "properties": {
-                "token": {
-                    "type": "string",
-                    "example": "eyJhbGciOiJIUzI1NiIsInR..."
+                "shopping_lists": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ShoppingListResponse"
+                    }
                 }
             }
         },
-        "dtos.NotFoundResponse": {
+        "dtos.SpendGroupResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "key": {
                     "type": "string",
-                    "example": "Resource not found"
+                    "example": "dairy"
+                },
+                "lines": {
+                    "type": "integer",
+                    "example": 14
+                },
+                "total": {
+                    "type": "number",
+                    "example": 72.5
                 }
             }
         },
-        "dtos.RecipeInstructionRequest": {
+        "dtos.SpendReportResponse": {
             "type": "object",
             "properties": {
-                "number": {
-                    "type": "integer",
-                    "example": 1
+                "alerts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.BudgetAlert"
+                    }
                 },
-                "step": {
+                "from": {
                     "type": "string",
-                    "example": "Bring a large pot of salted water to boil"
+                    "example": "2025-03-01T00:00:00Z"
+                },
+                "group_by": {
+                    "type": "string",
+                    "enum": [
+                        "category",
+                        "store",
+                        "week"
+                    ],
+                    "example": "category"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.SpendGroupResponse"
+                    }
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-03-31T00:00:00Z"
+                },
+                "total": {
+                    "type": "number",
+                    "example": 312.4
                 }
             }
         },
-        "dtos.RecipeInstructionResponse": {
+        "dtos.SpoonacularQuotaResponse": {
             "type": "object",
             "properties": {
-                "number": {
-                    "type": "integer",
-                    "example": 1
+                "day": {
+                    "type": "string",
+                    "example": "2025-03-01"
+                },
+                "left": {
+                    "type": "number",
+                    "example": 101.5
                 },
-                "step": {
+                "limit": {
+                    "type": "number",
+                    "example": 150
+                },
+                "resets_at": {
                     "type": "string",
-                    "example": "Bring a large pot of salted water to boil"
+                    "example": "2025-03-02T00:00:00Z"
+                },
+                "used": {
+                    "type": "number",
+                    "example": 48.5
                 }
             }
         },
-        "dtos.RecipeItemRequest": {
+        "dtos.StorePriceResponse": {
             "type": "object",
             "properties": {
-                "amount": {
+                "average_unit_price": {
                     "type": "number",
-                    "example": 2.5
+                    "example": 2.89
                 },
-                "item_id": {
+                "purchases": {
                     "type": "integer",
-                    "example": 456
+                    "example": 4
                 },
-                "unit": {
+                "store": {
                     "type": "string",
-                    "example": "cups"
+                    "example": "Corner Market"
                 }
             }
         },
-        "dtos.RecipeItemResponse": {
+        "dtos.UnauthorizedResponse": {
             "type": "object",
             "properties": {
-                "amount": {
-                    "type": "number",
-                    "example": 2.5
-                },
-                "item": {
-                    "$ref": "#/definitions/dtos.ItemResponse"
-                },
-                "unit": {
+                "error": {
                     "type": "string",
-                    "example": "cups"
+                    "example": "Invalid credentials"
                 }
             }
         },
-        "dtos.RecipeNutrientRequest": {
+        "dtos.UserItemLotRequest": {
             "type": "object",
             "properties": {
                 "amount": {
                     "type": "number",
-                    "example": 200
+                    "example": 1
                 },
-                "name": {
+                "expires_at": {
                     "type": "string",
-                    "example": "Calories"
+                    "example": "2025-03-08T10:00:00Z"
                 },
-                "percentOfDailyNeeds": {
-                    "type": "number",
-                    "example": 100
+                "location": {
+                    "type": "string",
+                    "example": "fridge"
+                },
+                "purchased_at": {
+                    "type": "string",
+                    "example": "2025-03-01T10:00:00Z"
                 },
                 "unit": {
                     "type": "string",
-                    "example": "kcal"
+                    "example": "l"
                 }
             }
         },
-        "dtos.RecipeNutrientResponse": {
+        "dtos.UserItemLotResponse": {
             "type": "object",
             "properties": {
                 "amount": {
                     "type": "number",
-                    "example": 200
+                    "example": 1
                 },
-                "name": {
+                "expires_at": {
                     "type": "string",
-                    "example": "Calories"
+                    "example": "2025-03-08T10:00:00Z"
                 },
-                "percentOfDailyNeeds": {
-                    "type": "number",
-                    "example": 100
+                "id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "location": {
+                    "type": "string",
+                    "example": "fridge"
+                },
+                "purchased_at": {
+                    "type": "string",
+                    "example": "2025-03-01T10:00:00Z"
                 },
                 "unit": {
                     "type": "string",
-                    "example": "kcal"
+                    "example": "l"
                 }
             }
         },
-        "dtos.RecipeRequest": {
+        "dtos.UserItemRequest": {
             "type": "object",
             "properties": {
-                "cooking_time": {
-                    "type": "integer",
-                    "example": 20
+                "amount": {
+                    "type": "number",
+                    "example": 2
                 },
-                "image": {
+                "currency": {
                     "type": "string",
-                    "example": "https://example.com/spaghetti.jpg"
+                    "example": "USD"
                 },
-                "ingredients": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.RecipeItemRequest"
-                    }
-                },
-                "instructions": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.RecipeInstructionRequest"
-                    }
-                },
-                "kcal": {
-                    "type": "number",
-                    "example": 450.5
-                },
-                "nutrients": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.RecipeNutrientRequest"
-                    }
+                "expires_at": {
+                    "type": "string",
+                    "example": "2025-03-08T10:00:00Z"
                 },
-                "prep_time": {
+                "item_id": {
                     "type": "integer",
-                    "example": 10
+                    "example": 456
                 },
-                "ready_time": {
-                    "type": "integer",
-                    "example": 30
+                "location": {
+                    "type": "string",
+                    "example": "fridge"
                 },
-                "servings": {
+                "price": {
                     "type": "number",
-                    "example": 4
+                    "example": 3.49
                 },
-                "spoonacular_id": {
-                    "type": "integer",
-                    "example": 12345
-                },
-                "summary": {
+                "purchased_at": {
                     "type": "string",
-                    "example": "A classic Italian pasta dish with eggs, cheese, pancetta, and black pepper."
+                    "example": "2025-03-01T10:00:00Z"
                 },
-                "title": {
+                "store": {
                     "type": "string",
-                    "example": "Spaghetti Carbonara"
+                    "example": "Corner Market"
                 },
-                "vegan": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "vegetarian": {
-                    "type": "boolean",
-                    "example": false
+                "unit": {
+                    "type": "string",
+                    "example": "kg"
                 }
             }
         },
-        "dtos.RecipeResponse": {
+        "dtos.UserItemResponse": {
             "type": "object",
             "properties": {
-                "cooking_time": {
-                    "type": "integer",
-                    "example": 20
-                },
-                "id": {
-                    "type": "integer",
-                    "example": 1
+                "amount": {
+                    "type": "number",
+                    "example": 2
                 },
-                "image": {
+                "expires_at": {
                     "type": "string",
-                    "example": "https://example.com/spaghetti.jpg"
+                    "example": "2025-03-08T10:00:00Z"
                 },
-                "ingredients": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.RecipeItemResponse"
-                    }
-                },
-                "instructions": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.RecipeInstructionResponse"
-                    }
+                "item": {
+                    "$ref": "#/definitions/dtos.ItemResponse"
                 },
-                "kcal": {
-                    "type": "number",
-                    "example": 450.5
+                "location": {
+                    "type": "string",
+                    "example": "fridge"
                 },
-                "nutrients": {
+                "lots": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dtos.RecipeNutrientResponse"
+                        "$ref": "#/definitions/dtos.UserItemLotResponse"
                     }
                 },
-                "prep_time": {
-                    "type": "integer",
-                    "example": 10
-                },
-                "ready_time": {
-                    "type": "integer",
-                    "example": 30
-                },
-                "servings": {
-                    "type": "number",
-                    "example": 4
-                },
-                "spoonacular_id": {
-                    "type": "integer",
-                    "example": 12345
-                },
-                "summary": {
+                "purchased_at": {
                     "type": "string",
-                    "example": "A classic Italian pasta dish with eggs, cheese, pancetta, and black pepper."
+                    "example": "2025-03-01T10:00:00Z"
                 },
-                "title": {
+                "unit": {
                     "type": "string",
-                    "example": "Spaghetti Carbonara"
-                },
-                "vegan": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "vegetarian": {
-                    "type": "boolean",
-                    "example": false
+                    "example": "kg"
                 }
             }
         },
-        "dtos.RecipesResponse": {
+        "dtos.UserItemsResponse": {
             "type": "object",
             "properties": {
-                "count": {
-                    "type": "integer"
-                },
-                "diet_counts": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.DietCount"
-                    }
+                "scan": {
+                    "$ref": "#/definitions/dtos.ScanResponse"
                 },
-                "recipes": {
+                "user_items": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dtos.RecipeResponse"
+                        "$ref": "#/definitions/dtos.UserItemResponse"
                     }
                 }
             }
         },
-        "dtos.RegisterRequest": {
+        "dtos.WasteMonthResponse": {
             "type": "object",
             "properties": {
-                "password": {
-                    "type": "string",
-                    "example": "securepassword123"
+                "discards": {
+                    "type": "integer",
+                    "example": 7
                 },
-                "username": {
+                "estimated_cost": {
+                    "type": "number",
+                    "example": 14.5
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.WastedItemResponse"
+                    }
+                },
+                "month": {
                     "type": "string",
-                    "example": "john_doe"
+                    "example": "2025-03"
+                },
+                "reasons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.WasteReasonResponse"
+                    }
                 }
             }
         },
-        "dtos.RegisterResponse": {
+        "dtos.WasteReasonResponse": {
             "type": "object",
             "properties": {
-                "message": {
+                "discards": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "estimated_cost": {
+                    "type": "number",
+                    "example": 8.2
+                },
+                "reason": {
                     "type": "string",
-                    "example": "User registered successfully"
+                    "enum": [
+                        "expired",
+                        "spoiled",
+                        "leftover"
+                    ],
+                    "example": "expired"
                 }
             }
         },
-        "dtos.UnauthorizedResponse": {
+        "dtos.WasteReportResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "discards": {
+                    "type": "integer",
+                    "example": 19
+                },
+                "estimated_cost": {
+                    "type": "number",
+                    "example": 41.8
+                },
+                "from": {
                     "type": "string",
-                    "example": "Invalid credentials"
+                    "example": "2025-01-01T00:00:00Z"
+                },
+                "months": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.WasteMonthResponse"
+                    }
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2025-03-31T00:00:00Z"
                 }
             }
         },
-        "dtos.UserItemRequest": {
+        "dtos.WastedItemResponse": {
             "type": "object",
             "properties": {
                 "amount": {
                     "type": "number",
-                    "example": 2
+                    "example": 1.5
                 },
-                "item_id": {
+                "discards": {
                     "type": "integer",
-                    "example": 456
+                    "example": 3
                 },
-                "unit": {
-                    "type": "string",
-                    "example": "kg"
-                }
-            }
-        },
-        "dtos.UserItemResponse": {
-            "type": "object",
-            "properties": {
-                "amount": {
+                "estimated_cost": {
                     "type": "number",
-                    "example": 2
+                    "example": 6.3
                 },
                 "item": {
                     "$ref": "#/definitions/dtos.ItemResponse"
@@ -1345,17 +5189,6 @@ const docTemplate = `{
                     "example": "kg"
                 }
             }
-        },
-        "dtos.UserItemsResponse": {
-            "type": "object",
-            "properties": {
-                "user_items": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dtos.UserItemResponse"
-                    }
-                }
-            }
         }
     }
 }`
@@ -3,14 +3,23 @@ package clients
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
 )
 
+// ErrNoResults means Spoonacular knows no ingredient by the name searched for
+var ErrNoResults = errors.New("no results found")
+
 type SpoonacularClient struct {
 	baseURL string
 	apiKey  string
 	client  *http.Client
+	quota   *quotaStore
 }
 
 type SpoonacularIngredient struct {
@@ -30,86 +39,142 @@ type SpoonacularIngredientInfo struct {
 	ID        int    `json:"id"`
 	Name      string `json:"name"`
 	Image     string `json:"image"`
+	Aisle     string `json:"aisle"`
 	Nutrition struct {
 		Nutrients []SpoonacularNutrient `json:"nutrients"`
 	} `json:"nutrition"`
 }
 
-func NewSpoonacularClient(baseURL, apiKey string) *SpoonacularClient {
+// SpoonacularRecipe is a result of a complex recipe search with nutrition and instructions
+type SpoonacularRecipe struct {
+	ID                 int     `json:"id"`
+	Title              string  `json:"title"`
+	Image              string  `json:"image"`
+	ReadyInMinutes     int     `json:"readyInMinutes"`
+	PreparationMinutes int     `json:"preparationMinutes"`
+	CookingMinutes     int     `json:"cookingMinutes"`
+	Servings           float32 `json:"servings"`
+	Summary            string  `json:"summary"`
+	Vegan              bool    `json:"vegan"`
+	Vegetarian         bool    `json:"vegetarian"`
+	Nutrition          struct {
+		Nutrients   []SpoonacularNutrient `json:"nutrients"`
+		Ingredients []struct {
+			ID     int     `json:"id"`
+			Name   string  `json:"name"`
+			Amount float64 `json:"amount"`
+			Unit   string  `json:"unit"`
+		} `json:"ingredients"`
+	} `json:"nutrition"`
+	AnalyzedInstructions []struct {
+		Steps []struct {
+			Number int    `json:"number"`
+			Step   string `json:"step"`
+		} `json:"steps"`
+	} `json:"analyzedInstructions"`
+}
+
+// NewSpoonacularClient keeps track of the API key's daily quota in Redis, assuming dailyQuota
+// points until Spoonacular reports its own figures
+func NewSpoonacularClient(baseURL, apiKey string, redis *redis.Client, dailyQuota float64) *SpoonacularClient {
 	return &SpoonacularClient{
 		baseURL: baseURL,
 		apiKey:  apiKey,
 		client:  &http.Client{},
+		quota:   &quotaStore{redis: redis, limit: dailyQuota},
 	}
 }
 
-func (c *SpoonacularClient) SearchIngredient(ctx context.Context, query string) (*SpoonacularIngredientInfo, error) {
-	// First search for the ingredient
-	searchURL := fmt.Sprintf("%s/food/ingredients/search?query=%s&number=1&apiKey=%s", c.baseURL, query, c.apiKey)
+// Quota returns the points used and left today
+func (c *SpoonacularClient) Quota(ctx context.Context) (Quota, error) {
+	return c.quota.get(ctx)
+}
+
+// get calls path with params and decodes the JSON response into out. Every call goes through it
+// so that each is counted against the quota, by the usage Spoonacular reports or else by the
+// points it was estimated to cost. Calls are not made once the quota is used up.
+func (c *SpoonacularClient) get(ctx context.Context, path string, params url.Values, points float64, out interface{}) error {
+	quota, err := c.quota.get(ctx)
+	if err != nil {
+		return err
+	}
+	if quota.Left < points {
+		return ErrQuotaExhausted
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	params.Set("apiKey", c.apiKey)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path+"?"+params.Encode(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if err := c.quota.record(ctx, resp, points); err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusPaymentRequired {
+		return ErrQuotaExhausted
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// SearchIngredient finds the ingredient best matching query and fetches its details and
+// nutrients. It takes two calls of a point each.
+func (c *SpoonacularClient) SearchIngredient(ctx context.Context, query string) (*SpoonacularIngredientInfo, error) {
+	// First search for the ingredient
 	var searchResult struct {
 		Results []SpoonacularIngredient `json:"results"`
 	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&searchResult); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	params := url.Values{"query": {query}, "number": {"1"}}
+	if err := c.get(ctx, "/food/ingredients/search", params, 1, &searchResult); err != nil {
+		return nil, err
 	}
 
 	if len(searchResult.Results) == 0 {
-		return nil, fmt.Errorf("no results found")
+		return nil, ErrNoResults
 	}
 
 	ingredient := searchResult.Results[0]
 
 	// Then get detailed information including nutrients
-	infoURL := fmt.Sprintf("%s/food/ingredients/%d/information?apiKey=%s&amount=1", c.baseURL, ingredient.ID, c.apiKey)
-
-	req, err = http.NewRequestWithContext(ctx, "GET", infoURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	resp, err = c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
 	var info SpoonacularIngredientInfo
-	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	path := fmt.Sprintf("/food/ingredients/%d/information", ingredient.ID)
+	if err := c.get(ctx, path, url.Values{"amount": {"1"}}, 1, &info); err != nil {
+		return nil, err
 	}
 
 	return &info, nil
 }
 
-func (c *SpoonacularClient) GetBaseURL() string {
-	return c.baseURL
-}
+// SearchRecipes runs a complex recipe search with nutrition and instructions. It costs a point,
+// plus a little for every result and the information added to it.
+func (c *SpoonacularClient) SearchRecipes(ctx context.Context, params url.Values) ([]SpoonacularRecipe, error) {
+	params.Set("addRecipeInstructions", "true")
+	params.Set("addRecipeNutrition", "true")
+	number, err := strconv.Atoi(params.Get("number"))
+	if err != nil {
+		number = 10
+	}
 
-func (c *SpoonacularClient) GetAPIKey() string {
-	return c.apiKey
-}
+	var searchResult struct {
+		Results []SpoonacularRecipe `json:"results"`
+	}
+	if err := c.get(ctx, "/recipes/complexSearch", params, 1+0.035*float64(number), &searchResult); err != nil {
+		return nil, err
+	}
 
-func (c *SpoonacularClient) GetClient() *http.Client {
-	return c.client
+	return searchResult.Results, nil
 }
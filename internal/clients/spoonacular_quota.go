@@ -0,0 +1,118 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	quotaKeyPrefix = "spoonacular_quota:"
+
+	// DefaultDailyQuota is the free plan's daily points, assumed until Spoonacular reports the real figure
+	DefaultDailyQuota = 150
+)
+
+// ErrQuotaExhausted means the day's Spoonacular points are used up; they come back at midnight UTC
+var ErrQuotaExhausted = errors.New("spoonacular quota exhausted")
+
+// Quota is how many Spoonacular points have been used and are left on the current UTC day
+type Quota struct {
+	Day      string    `json:"day"`
+	Used     float64   `json:"used"`
+	Left     float64   `json:"left"`
+	Limit    float64   `json:"limit"`
+	ResetsAt time.Time `json:"resets_at"`
+}
+
+// quotaStore keeps the day's quota in Redis, where every process using the API key can see it.
+// Spoonacular resets quotas at midnight UTC, so each day has its own key that expires after it.
+type quotaStore struct {
+	redis *redis.Client
+	limit float64
+}
+
+func quotaDay(now time.Time) (string, time.Time) {
+	now = now.UTC()
+	return now.Format("2006-01-02"), time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+func (s *quotaStore) get(ctx context.Context) (Quota, error) {
+	day, resetsAt := quotaDay(time.Now())
+	quota := Quota{Day: day, Limit: s.limit, Left: s.limit, ResetsAt: resetsAt}
+
+	values, err := s.redis.HMGet(ctx, quotaKeyPrefix+day, "used", "left").Result()
+	if err != nil {
+		return Quota{}, fmt.Errorf("failed to get Spoonacular quota: %w", err)
+	}
+	if used, ok := values[0].(string); ok {
+		quota.Used, _ = strconv.ParseFloat(used, 64)
+		quota.Left = s.limit - quota.Used
+	}
+	if left, ok := values[1].(string); ok {
+		quota.Left, _ = strconv.ParseFloat(left, 64)
+		quota.Limit = quota.Used + quota.Left
+	}
+	return quota, nil
+}
+
+// set records the usage Spoonacular reported
+func (s *quotaStore) set(ctx context.Context, used, left float64) error {
+	day, resetsAt := quotaDay(time.Now())
+	key := quotaKeyPrefix + day
+
+	pipe := s.redis.TxPipeline()
+	pipe.HSet(ctx, key, "used", used, "left", left)
+	pipe.ExpireAt(ctx, key, resetsAt.Add(time.Hour))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to set Spoonacular quota: %w", err)
+	}
+	return nil
+}
+
+// addQuotaScript counts ARGV[1] points against the day's quota in KEYS[1], which expires at ARGV[2]
+var addQuotaScript = redis.NewScript(`
+redis.call('HINCRBYFLOAT', KEYS[1], 'used', ARGV[1])
+if redis.call('HEXISTS', KEYS[1], 'left') == 1 then
+	redis.call('HINCRBYFLOAT', KEYS[1], 'left', -tonumber(ARGV[1]))
+end
+redis.call('EXPIREAT', KEYS[1], ARGV[2])
+return 1
+`)
+
+// add counts points spent on a call Spoonacular did not report usage for
+func (s *quotaStore) add(ctx context.Context, points float64) error {
+	day, resetsAt := quotaDay(time.Now())
+	if err := addQuotaScript.Run(ctx, s.redis, []string{quotaKeyPrefix + day}, points, resetsAt.Add(time.Hour).Unix()).Err(); err != nil {
+		return fmt.Errorf("failed to count Spoonacular quota: %w", err)
+	}
+	return nil
+}
+
+// record updates the quota after a call: from the X-API-Quota-Used and X-API-Quota-Left headers
+// when Spoonacular sent them, otherwise by the points the call was estimated to cost. A 402
+// means the quota is used up whatever was counted so far.
+func (s *quotaStore) record(ctx context.Context, resp *http.Response, points float64) error {
+	if resp.StatusCode == http.StatusPaymentRequired {
+		quota, err := s.get(ctx)
+		if err != nil {
+			return err
+		}
+		return s.set(ctx, max(quota.Used, quota.Limit), 0)
+	}
+
+	used, err := strconv.ParseFloat(resp.Header.Get("X-API-Quota-Used"), 64)
+	if err != nil {
+		return s.add(ctx, points)
+	}
+	left, err := strconv.ParseFloat(resp.Header.Get("X-API-Quota-Left"), 64)
+	if err != nil {
+		left = s.limit - used
+	}
+	return s.set(ctx, used, left)
+}
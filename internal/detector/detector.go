@@ -0,0 +1,80 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// BoundingBox locates an item in the image. Coordinates are fractions of the image size, with
+// the origin at the top left corner.
+type BoundingBox struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// DetectedItem is one grocery item found in an image. Amount is 0 when the detector only
+// recognizes items without estimating how much of them there is; Box is nil when it does not
+// locate them.
+type DetectedItem struct {
+	Name       string
+	Amount     float64
+	Unit       string
+	Confidence float64
+	Box        *BoundingBox
+}
+
+// Detector finds grocery items in an image
+type Detector interface {
+	Detect(ctx context.Context, image []byte) ([]DetectedItem, error)
+}
+
+const (
+	OpenAI      = "openai"
+	HuggingFace = "huggingface"
+	Fake        = "fake"
+)
+
+var ErrNotConfigured = errors.New("no item detector is configured")
+
+// Config selects and configures a detector. An empty Name picks OpenAI when an API key is set,
+// then Hugging Face when a URL is set.
+type Config struct {
+	Name           string
+	OpenAIAPIKey   string
+	OpenAIModel    string
+	HuggingFaceURL string
+}
+
+func New(cfg Config) (Detector, error) {
+	name := cfg.Name
+	if name == "" {
+		switch {
+		case cfg.OpenAIAPIKey != "":
+			name = OpenAI
+		case cfg.HuggingFaceURL != "":
+			name = HuggingFace
+		default:
+			return nil, ErrNotConfigured
+		}
+	}
+
+	switch name {
+	case OpenAI:
+		if cfg.OpenAIAPIKey == "" {
+			return nil, fmt.Errorf("%w: OPENAI_API_KEY is not set", ErrNotConfigured)
+		}
+		return NewOpenAIDetector(cfg.OpenAIAPIKey, cfg.OpenAIModel), nil
+	case HuggingFace:
+		if cfg.HuggingFaceURL == "" {
+			return nil, fmt.Errorf("%w: HUGGINGFACE_URL is not set", ErrNotConfigured)
+		}
+		return NewHuggingFaceDetector(cfg.HuggingFaceURL), nil
+	case Fake:
+		return NewFakeDetector(SampleItems...), nil
+	default:
+		return nil, fmt.Errorf("unknown detector %q", name)
+	}
+}
@@ -0,0 +1,48 @@
+package detector
+
+import "context"
+
+// SampleItems is what the fake detector returns when it is picked by configuration
+var SampleItems = []DetectedItem{
+	{Name: "Apple", Amount: 3, Unit: "unit", Confidence: 0.95, Box: &BoundingBox{X: 0.1, Y: 0.2, Width: 0.2, Height: 0.2}},
+	{Name: "Milk", Amount: 1, Unit: "l", Confidence: 0.8, Box: &BoundingBox{X: 0.5, Y: 0.1, Width: 0.2, Height: 0.5}},
+}
+
+// SampleReceipt is what the fake detector reads from any receipt
+var SampleReceipt = Receipt{
+	Store:    "Corner Market",
+	Currency: "USD",
+	Total:    5.48,
+	Lines: []ReceiptLine{
+		{Text: "APPLES GALA 3 @ 0.83", Name: "Apple", Amount: 3, Unit: "unit", Price: 2.49},
+		{Text: "WHOLE MILK 1L", Name: "Milk", Amount: 1, Unit: "l", Price: 2.99},
+	},
+}
+
+// FakeDetector returns fixed items and a fixed receipt without calling any service, for tests
+// and local development without API keys
+type FakeDetector struct {
+	Items   []DetectedItem
+	Receipt Receipt
+	Err     error
+}
+
+func NewFakeDetector(items ...DetectedItem) *FakeDetector {
+	return &FakeDetector{Items: items, Receipt: SampleReceipt}
+}
+
+func (d *FakeDetector) Detect(ctx context.Context, image []byte) ([]DetectedItem, error) {
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	return append([]DetectedItem(nil), d.Items...), nil
+}
+
+func (d *FakeDetector) ReadReceipt(ctx context.Context, image []byte) (Receipt, error) {
+	if d.Err != nil {
+		return Receipt{}, d.Err
+	}
+	receipt := d.Receipt
+	receipt.Lines = append([]ReceiptLine(nil), d.Receipt.Lines...)
+	return receipt, nil
+}
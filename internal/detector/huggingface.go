@@ -0,0 +1,80 @@
+package detector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// HuggingFaceDetector posts the image to the GroceryTrak detection Space. The Space only
+// returns the classes it recognized, so items come back without an amount or a box.
+type HuggingFaceDetector struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewHuggingFaceDetector(baseURL string) *HuggingFaceDetector {
+	return &HuggingFaceDetector{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{},
+	}
+}
+
+func (d *HuggingFaceDetector) Detect(ctx context.Context, image []byte) ([]DetectedItem, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	filePart, err := writer.CreateFormFile("image", "upload.png")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := filePart.Write(image); err != nil {
+		return nil, fmt.Errorf("failed to write file data: %w", err)
+	}
+	writer.Close()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/predict", d.baseURL), &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prediction: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("prediction failed with status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Items          []string `json:"items"`
+		AnnotatedImage string   `json:"annotated_image"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to parse prediction response: %w", err)
+	}
+
+	// The Space lists a class once per detection; one entry per item is enough
+	seen := make(map[string]bool)
+	var items []DetectedItem
+	for _, class := range response.Items {
+		class = strings.TrimSpace(class)
+		if class == "" || seen[strings.ToLower(class)] {
+			continue
+		}
+		seen[strings.ToLower(class)] = true
+		items = append(items, DetectedItem{
+			Name:       class,
+			Confidence: 1,
+		})
+	}
+
+	return items, nil
+}
@@ -0,0 +1,121 @@
+package detector
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const DefaultOpenAIModel = "gpt-4.1-mini"
+
+const openAIPrompt = `You are a grocery item detector. Analyze the image and identify all grocery items. For each item, provide:
+1. The name of the item, uppercase first letter
+2. The amount (as a whole number or decimal number with two decimal places)
+3. The unit of measurement (e.g., unit, kg, g, lb, oz, etc.)
+4. Your confidence that the item is really there, from 0 to 1
+5. The bounding box of the item as fractions of the image size: x and y of the top left corner, width and height
+
+Return ONLY a JSON array of objects with these exact keys: name, amount, unit, confidence, box.
+Example response:
+[{"name":"Apple","amount":1,"unit":"unit","confidence":0.93,"box":{"x":0.1,"y":0.2,"width":0.15,"height":0.2}},{"name":"Milk","amount":1.5,"unit":"L","confidence":0.81,"box":{"x":0.5,"y":0.1,"width":0.2,"height":0.5}}]
+
+Do not include any other text, explanations, or formatting. Return only the JSON array.`
+
+// OpenAIDetector asks a vision model to list the items in an image with their amounts
+type OpenAIDetector struct {
+	client *openai.Client
+	model  string
+}
+
+func NewOpenAIDetector(apiKey, model string) *OpenAIDetector {
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+	return &OpenAIDetector{
+		client: openai.NewClient(apiKey),
+		model:  model,
+	}
+}
+
+func (d *OpenAIDetector) Detect(ctx context.Context, image []byte) ([]DetectedItem, error) {
+	content, err := d.complete(ctx, openAIPrompt, image)
+	if err != nil {
+		return nil, err
+	}
+
+	var detectedItems []struct {
+		Name       string       `json:"name"`
+		Amount     float64      `json:"amount"`
+		Unit       string       `json:"unit"`
+		Confidence *float64     `json:"confidence"`
+		Box        *BoundingBox `json:"box"`
+	}
+
+	if err := json.Unmarshal([]byte(content), &detectedItems); err != nil {
+		return nil, fmt.Errorf("failed to parse detected items: %w", err)
+	}
+
+	items := make([]DetectedItem, 0, len(detectedItems))
+	for _, detectedItem := range detectedItems {
+		if detectedItem.Name == "" {
+			continue
+		}
+
+		confidence := 1.0
+		if detectedItem.Confidence != nil {
+			confidence = *detectedItem.Confidence
+		}
+		items = append(items, DetectedItem{
+			Name:       detectedItem.Name,
+			Amount:     detectedItem.Amount,
+			Unit:       detectedItem.Unit,
+			Confidence: confidence,
+			Box:        detectedItem.Box,
+		})
+	}
+
+	return items, nil
+}
+
+// complete sends the prompt with the image and returns the JSON the model answered with
+func (d *OpenAIDetector) complete(ctx context.Context, prompt string, image []byte) (string, error) {
+	imageBase64 := base64.StdEncoding.EncodeToString(image)
+
+	resp, err := d.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: d.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role: "user",
+				MultiContent: []openai.ChatMessagePart{
+					{
+						Type: "text",
+						Text: prompt,
+					},
+					{
+						Type: "image_url",
+						ImageURL: &openai.ChatMessageImageURL{
+							URL: fmt.Sprintf("data:image/jpeg;base64,%s", imageBase64),
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("empty response from OpenAI")
+	}
+
+	// Models sometimes wrap the JSON in a markdown code block despite the prompt
+	content := strings.TrimSpace(resp.Choices[0].Message.Content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.Trim(content, "`\n ")
+	return content, nil
+}
@@ -0,0 +1,107 @@
+package detector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ReceiptLine is one product on a receipt. Name is the generic grocery name, with the store's
+// abbreviations expanded; Price is what was paid for the whole line.
+type ReceiptLine struct {
+	Text   string
+	Name   string
+	Amount float64
+	Unit   string
+	Price  float64
+}
+
+// Receipt is what could be read from a photo of a grocery receipt. Date is nil when none is
+// printed or legible.
+type Receipt struct {
+	Store    string
+	Date     *time.Time
+	Currency string
+	Total    float64
+	Lines    []ReceiptLine
+}
+
+// ReceiptReader reads grocery receipts. Detectors implement it when their model can read text
+// as well as recognize items.
+type ReceiptReader interface {
+	ReadReceipt(ctx context.Context, image []byte) (Receipt, error)
+}
+
+var ErrReceiptsNotSupported = errors.New("the configured item detector cannot read receipts")
+
+const openAIReceiptPrompt = `You read grocery receipts. From the receipt in the image, extract:
+- store: the name of the store
+- date: the date of the purchase as YYYY-MM-DD, or null when it is not printed
+- currency: the ISO 4217 code of the currency, guessed from the symbols and the store when not printed
+- total: the total amount paid
+- lines: one object per product bought, with
+  - text: the line as printed
+  - name: the generic grocery name of the product with abbreviations expanded, without brand or package size, uppercase first letter ("GV 2% MLK GAL" is "Milk")
+  - amount: how much was bought, the weight for products sold by weight, else the count
+  - unit: the unit of the amount (unit, kg, g, lb, oz, l, ml, etc.)
+  - price: the price paid for the whole line, after discounts on that line
+
+Leave out taxes, bag fees, deposits, subtotals, payments and change.
+Return ONLY a JSON object with these exact keys: store, date, currency, total, lines.
+Example response:
+{"store":"Trader Joe's","date":"2025-03-01","currency":"USD","total":7.47,"lines":[{"text":"BANANAS 1.2 LB","name":"Banana","amount":1.2,"unit":"lb","price":0.83},{"text":"ORG WHOLE MILK","name":"Milk","amount":1,"unit":"unit","price":6.64}]}
+
+Do not include any other text, explanations, or formatting. Return only the JSON object.`
+
+func (d *OpenAIDetector) ReadReceipt(ctx context.Context, image []byte) (Receipt, error) {
+	content, err := d.complete(ctx, openAIReceiptPrompt, image)
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	var response struct {
+		Store    string  `json:"store"`
+		Date     *string `json:"date"`
+		Currency string  `json:"currency"`
+		Total    float64 `json:"total"`
+		Lines    []struct {
+			Text   string  `json:"text"`
+			Name   string  `json:"name"`
+			Amount float64 `json:"amount"`
+			Unit   string  `json:"unit"`
+			Price  float64 `json:"price"`
+		} `json:"lines"`
+	}
+	if err := json.Unmarshal([]byte(content), &response); err != nil {
+		return Receipt{}, fmt.Errorf("failed to parse receipt: %w", err)
+	}
+
+	receipt := Receipt{
+		Store:    strings.TrimSpace(response.Store),
+		Currency: strings.ToUpper(strings.TrimSpace(response.Currency)),
+		Total:    response.Total,
+		Lines:    make([]ReceiptLine, 0, len(response.Lines)),
+	}
+	if response.Date != nil {
+		if date, err := time.Parse("2006-01-02", *response.Date); err == nil {
+			receipt.Date = &date
+		}
+	}
+	for _, line := range response.Lines {
+		if line.Name == "" {
+			continue
+		}
+		receipt.Lines = append(receipt.Lines, ReceiptLine{
+			Text:   line.Text,
+			Name:   line.Name,
+			Amount: line.Amount,
+			Unit:   line.Unit,
+			Price:  line.Price,
+		})
+	}
+
+	return receipt, nil
+}
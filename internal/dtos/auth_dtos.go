@@ -15,5 +15,19 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR..."`
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR..."`
+	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR..."`
+	ExpiresIn    int64  `json:"expires_in" example:"900"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR..."`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR..."`
+}
+
+type LogoutResponse struct {
+	Message string `json:"message" example:"Logged out successfully"`
 }
@@ -0,0 +1,65 @@
+package dtos
+
+import "time"
+
+// BudgetRequest sets the monthly budget for a category, or the overall one when Category is empty
+type BudgetRequest struct {
+	Category string  `json:"category,omitempty" example:"dairy"`
+	Amount   float64 `json:"amount" example:"60"`
+}
+
+// BudgetResponse is a budget with what has been spent against it this month
+type BudgetResponse struct {
+	ID        uint    `json:"id" example:"1"`
+	Category  string  `json:"category,omitempty" example:"dairy"`
+	Amount    float64 `json:"amount" example:"60"`
+	Spent     float64 `json:"spent" example:"72.5"`
+	Remaining float64 `json:"remaining" example:"-12.5"`
+	Exceeded  bool    `json:"exceeded" example:"true"`
+}
+
+// BudgetAlert reports a budget spent past its amount in Month
+type BudgetAlert struct {
+	Category string  `json:"category,omitempty" example:"dairy"`
+	Month    string  `json:"month" example:"2025-03"`
+	Amount   float64 `json:"amount" example:"60"`
+	Spent    float64 `json:"spent" example:"72.5"`
+}
+
+type BudgetsResponse struct {
+	Month   string           `json:"month" example:"2025-03"`
+	Budgets []BudgetResponse `json:"budgets"`
+	Alerts  []BudgetAlert    `json:"alerts"`
+}
+
+const (
+	SpendByCategory = "category"
+	SpendByStore    = "store"
+	SpendByWeek     = "week"
+)
+
+// SpendReportQuery covers From to To, both days included
+type SpendReportQuery struct {
+	From    time.Time
+	To      time.Time
+	GroupBy string
+}
+
+// SpendGroupResponse is the spend of one category, store or week. Weeks are keyed by the
+// date of their Monday.
+type SpendGroupResponse struct {
+	Key   string  `json:"key" example:"dairy"`
+	Total float64 `json:"total" example:"72.5"`
+	Lines int     `json:"lines" example:"14"`
+}
+
+// SpendReportResponse totals the prices of the purchase lines in a period. Alerts lists the
+// budgets exceeded this month.
+type SpendReportResponse struct {
+	From    time.Time            `json:"from" example:"2025-03-01T00:00:00Z"`
+	To      time.Time            `json:"to" example:"2025-03-31T00:00:00Z"`
+	GroupBy string               `json:"group_by" example:"category" enums:"category,store,week"`
+	Total   float64              `json:"total" example:"312.4"`
+	Groups  []SpendGroupResponse `json:"groups"`
+	Alerts  []BudgetAlert        `json:"alerts"`
+}
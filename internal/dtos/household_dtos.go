@@ -0,0 +1,34 @@
+package dtos
+
+import "time"
+
+type HouseholdRequest struct {
+	Name string `json:"name" example:"Smith family"`
+}
+
+type HouseholdJoinRequest struct {
+	InviteCode string `json:"invite_code" example:"K7Q2M9XA"`
+}
+
+type HouseholdMemberRequest struct {
+	Role string `json:"role" example:"member" enums:"owner,member"`
+}
+
+type HouseholdMemberResponse struct {
+	UserID   uint      `json:"user_id" example:"1"`
+	Username string    `json:"username" example:"john"`
+	Role     string    `json:"role" example:"owner"`
+	JoinedAt time.Time `json:"joined_at" example:"2025-03-01T10:00:00Z"`
+}
+
+type HouseholdResponse struct {
+	ID         uint                      `json:"id" example:"1"`
+	Name       string                    `json:"name" example:"Smith family"`
+	InviteCode string                    `json:"invite_code,omitempty" example:"K7Q2M9XA"`
+	CreatedAt  time.Time                 `json:"created_at" example:"2025-03-01T10:00:00Z"`
+	Members    []HouseholdMemberResponse `json:"members"`
+}
+
+type HouseholdsResponse struct {
+	Households []HouseholdResponse `json:"households"`
+}
@@ -1,19 +1,30 @@
 package dtos
 
+import "time"
+
 type ItemRequest struct {
 	ID            uint                  `json:"id" example:"1"`
 	Name          string                `json:"name" example:"Milk"`
 	Image         string                `json:"image" example:"milk.jpg"`
 	SpoonacularID uint                  `json:"spoonacular_id" example:"1"`
+	Density       *float64              `json:"density,omitempty" example:"1.03"`
+	Category      string                `json:"category" example:"dairy"`
+	ShelfLifeDays *int                  `json:"shelf_life_days,omitempty" example:"7"`
 	Nutrients     []ItemNutrientRequest `json:"nutrients"`
 }
 
 type ItemResponse struct {
-	ID            uint                   `json:"id" example:"1"`
-	Name          string                 `json:"name" example:"Milk"`
-	Image         string                 `json:"image" example:"milk.jpg"`
-	SpoonacularID uint                   `json:"spoonacular_id" example:"1"`
-	Nutrients     []ItemNutrientResponse `json:"nutrients"`
+	ID               uint                   `json:"id" example:"1"`
+	Name             string                 `json:"name" example:"Milk"`
+	Image            string                 `json:"image" example:"milk.jpg"`
+	SpoonacularID    uint                   `json:"spoonacular_id" example:"1"`
+	Density          *float64               `json:"density,omitempty" example:"1.03"`
+	Category         string                 `json:"category,omitempty" example:"dairy"`
+	ShelfLifeDays    *int                   `json:"shelf_life_days,omitempty" example:"7"`
+	Barcodes         []string               `json:"barcodes,omitempty" example:"3017620422003"`
+	EnrichmentStatus string                 `json:"enrichment_status" example:"enriched" enums:"pending,enriched,failed,not_found"`
+	EnrichedAt       *time.Time             `json:"enriched_at,omitempty" example:"2025-03-01T10:00:00Z"`
+	Nutrients        []ItemNutrientResponse `json:"nutrients"`
 }
 
 type ItemsResponse struct {
@@ -23,3 +34,35 @@ type ItemsResponse struct {
 type ItemQuery struct {
 	Name string `json:"name" example:"pasta"`
 }
+
+type ItemAliasRequest struct {
+	Alias string `json:"alias" example:"courgette"`
+}
+
+type ItemAliasResponse struct {
+	ID     uint   `json:"id" example:"1"`
+	ItemID uint   `json:"item_id" example:"1"`
+	Alias  string `json:"alias" example:"courgette"`
+}
+
+type ItemAliasesResponse struct {
+	Aliases []ItemAliasResponse `json:"aliases"`
+}
+
+// ItemDuplicateGroup is a set of items that look like the same product. SuggestedID is the item
+// to keep: the first one with a Spoonacular ID, else the oldest.
+type ItemDuplicateGroup struct {
+	SuggestedID uint           `json:"suggested_id" example:"1"`
+	Score       float64        `json:"score" example:"0.82"`
+	Items       []ItemResponse `json:"items"`
+}
+
+type ItemDuplicatesResponse struct {
+	Groups []ItemDuplicateGroup `json:"groups"`
+}
+
+// ItemMergeRequest merges the duplicates into ItemID, which is kept
+type ItemMergeRequest struct {
+	ItemID       uint   `json:"item_id" example:"1"`
+	DuplicateIDs []uint `json:"duplicate_ids" example:"2,3"`
+}
@@ -0,0 +1,84 @@
+package dtos
+
+import "time"
+
+// PantryEventResponse is one change to a lot. Amount is positive when something was added and
+// negative when it was taken out. Reason is set when food was wasted.
+type PantryEventResponse struct {
+	ID        uint      `json:"id" example:"1"`
+	LotID     uint      `json:"lot_id" example:"12"`
+	UserID    uint      `json:"user_id" example:"3"`
+	Type      string    `json:"type" example:"consumed" enums:"added,consumed,adjusted,discarded,expired"`
+	Source    string    `json:"source" example:"cook" enums:"manual,detect,predict,cook,receipt,barcode,shopping_list"`
+	Amount    float32   `json:"amount" example:"-0.5"`
+	Unit      string    `json:"unit" example:"kg"`
+	Reason    string    `json:"reason,omitempty" example:"spoiled" enums:"expired,spoiled,leftover"`
+	CreatedAt time.Time `json:"created_at" example:"2025-03-01T10:00:00Z"`
+}
+
+type PantryEventsResponse struct {
+	Item   ItemResponse          `json:"item"`
+	Events []PantryEventResponse `json:"events"`
+}
+
+// DiscardRequest throws away Amount (in Unit, or the unit of the first lot) of an item, or all
+// of it when no amount is given
+type DiscardRequest struct {
+	Reason string   `json:"reason" example:"spoiled" enums:"expired,spoiled,leftover"`
+	Amount *float32 `json:"amount,omitempty" example:"0.5"`
+	Unit   string   `json:"unit,omitempty" example:"kg"`
+}
+
+// DiscardResponse is what is left of the item after throwing Discarded of it away.
+// EstimatedCost is missing when the item was never bought with a price.
+type DiscardResponse struct {
+	UserItem      UserItemResponse `json:"user_item"`
+	Discarded     float32          `json:"discarded" example:"0.5"`
+	Unit          string           `json:"unit" example:"kg"`
+	EstimatedCost *float64         `json:"estimated_cost,omitempty" example:"2.1"`
+}
+
+// WasteReportQuery covers From to To, both days included. Limit is the number of most-wasted
+// items listed per month.
+type WasteReportQuery struct {
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// WastedItemResponse is how much of an item was thrown away. Amount is in Unit; amounts in units
+// that do not convert to it are only counted in Discards. EstimatedCost is missing when the item
+// was never bought with a price.
+type WastedItemResponse struct {
+	Item          ItemResponse `json:"item"`
+	Amount        float32      `json:"amount" example:"1.5"`
+	Unit          string       `json:"unit" example:"kg"`
+	Discards      int          `json:"discards" example:"3"`
+	EstimatedCost *float64     `json:"estimated_cost,omitempty" example:"6.3"`
+}
+
+type WasteReasonResponse struct {
+	Reason        string  `json:"reason" example:"expired" enums:"expired,spoiled,leftover"`
+	Discards      int     `json:"discards" example:"4"`
+	EstimatedCost float64 `json:"estimated_cost" example:"8.2"`
+}
+
+// WasteMonthResponse is the waste of one month. Items lists the most-wasted items, costliest
+// first.
+type WasteMonthResponse struct {
+	Month         string                `json:"month" example:"2025-03"`
+	Discards      int                   `json:"discards" example:"7"`
+	EstimatedCost float64               `json:"estimated_cost" example:"14.5"`
+	Reasons       []WasteReasonResponse `json:"reasons"`
+	Items         []WastedItemResponse  `json:"items"`
+}
+
+// WasteReportResponse sums the food thrown away in a period, month by month. Costs are estimated
+// from the average price paid for each item and only cover items bought with a price.
+type WasteReportResponse struct {
+	From          time.Time            `json:"from" example:"2025-01-01T00:00:00Z"`
+	To            time.Time            `json:"to" example:"2025-03-31T00:00:00Z"`
+	Discards      int                  `json:"discards" example:"19"`
+	EstimatedCost float64              `json:"estimated_cost" example:"41.8"`
+	Months        []WasteMonthResponse `json:"months"`
+}
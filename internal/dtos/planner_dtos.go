@@ -0,0 +1,42 @@
+package dtos
+
+type MissingItemsRequest struct {
+	Servings       float32 `json:"servings" example:"4"`
+	ShoppingListID *uint   `json:"shopping_list_id,omitempty" example:"1"`
+}
+
+type MissingItemResponse struct {
+	Item      ItemResponse `json:"item"`
+	Required  float32      `json:"required" example:"500"`
+	Available float32      `json:"available" example:"200"`
+	Missing   float32      `json:"missing" example:"300"`
+	Unit      string       `json:"unit" example:"g"`
+}
+
+type MissingItemsResponse struct {
+	RecipeID     uint                  `json:"recipe_id" example:"1"`
+	Servings     float32               `json:"servings" example:"4"`
+	MissingItems []MissingItemResponse `json:"missing_items"`
+	ShoppingList *ShoppingListResponse `json:"shopping_list,omitempty"`
+}
+
+type CookRecipeRequest struct {
+	Servings float32 `json:"servings" example:"4"`
+}
+
+type PantryChangeResponse struct {
+	Item    ItemResponse `json:"item"`
+	Before  float32      `json:"before" example:"1000"`
+	After   float32      `json:"after" example:"500"`
+	Unit    string       `json:"unit" example:"g"`
+	Removed bool         `json:"removed" example:"false"`
+}
+
+// CookRecipeResponse lists the pantry changes in the unit of the pantry's lots and the shortfalls
+// in the recipe's units
+type CookRecipeResponse struct {
+	RecipeID   uint                   `json:"recipe_id" example:"1"`
+	Servings   float32                `json:"servings" example:"4"`
+	Changes    []PantryChangeResponse `json:"changes"`
+	Shortfalls []MissingItemResponse  `json:"shortfalls"`
+}
@@ -0,0 +1,87 @@
+package dtos
+
+import "time"
+
+type PurchaseLineResponse struct {
+	ID     uint          `json:"id" example:"1"`
+	Name   string        `json:"name" example:"Milk"`
+	Item   *ItemResponse `json:"item,omitempty"`
+	Amount float32       `json:"amount" example:"1"`
+	Unit   string        `json:"unit" example:"l"`
+	Price  float64       `json:"price" example:"2.99"`
+}
+
+type PurchaseResponse struct {
+	ID          uint                   `json:"id" example:"1"`
+	Store       string                 `json:"store" example:"Corner Market"`
+	PurchasedAt time.Time              `json:"purchased_at" example:"2025-03-01T00:00:00Z"`
+	Currency    string                 `json:"currency" example:"USD"`
+	Total       float64                `json:"total" example:"5.48"`
+	Lines       []PurchaseLineResponse `json:"lines"`
+}
+
+// ReceiptResponse is the result of a receipt import: the purchase it recorded and the pantry
+// items it added. Lines that matched no known item confidently are in Scan, for review.
+type ReceiptResponse struct {
+	Purchase  PurchaseResponse   `json:"purchase"`
+	UserItems []UserItemResponse `json:"user_items"`
+	Scan      *ScanResponse      `json:"scan,omitempty"`
+}
+
+// PurchaseLineRequest is one product bought; Price is what was paid for the whole line
+type PurchaseLineRequest struct {
+	ItemID uint    `json:"item_id" example:"456"`
+	Amount float32 `json:"amount" example:"1"`
+	Unit   string  `json:"unit" example:"l"`
+	Price  float64 `json:"price" example:"2.99"`
+}
+
+// PurchaseRequest records a shopping trip. PurchasedAt defaults to now and Total to the sum of
+// the line prices.
+type PurchaseRequest struct {
+	Store       string                `json:"store" example:"Corner Market"`
+	PurchasedAt *time.Time            `json:"purchased_at,omitempty" example:"2025-03-01T10:00:00Z"`
+	Currency    string                `json:"currency" example:"USD"`
+	Total       float64               `json:"total,omitempty" example:"5.48"`
+	Lines       []PurchaseLineRequest `json:"lines"`
+}
+
+type PurchasesResponse struct {
+	Purchases []PurchaseResponse `json:"purchases"`
+}
+
+// PurchaseQuery filters purchases by date, both ends inclusive, and by store
+type PurchaseQuery struct {
+	From  *time.Time
+	To    *time.Time
+	Store string
+}
+
+// ItemPriceResponse is one purchase of an item. UnitPrice is the price per unit of the history,
+// omitted when the amount cannot be converted to it.
+type ItemPriceResponse struct {
+	PurchaseID  uint      `json:"purchase_id" example:"1"`
+	Store       string    `json:"store" example:"Corner Market"`
+	PurchasedAt time.Time `json:"purchased_at" example:"2025-03-01T00:00:00Z"`
+	Currency    string    `json:"currency" example:"USD"`
+	Amount      float32   `json:"amount" example:"1"`
+	Unit        string    `json:"unit" example:"l"`
+	Price       float64   `json:"price" example:"2.99"`
+	UnitPrice   *float64  `json:"unit_price,omitempty" example:"2.99"`
+}
+
+type StorePriceResponse struct {
+	Store            string  `json:"store" example:"Corner Market"`
+	AverageUnitPrice float64 `json:"average_unit_price" example:"2.89"`
+	Purchases        int     `json:"purchases" example:"4"`
+}
+
+// ItemPriceHistoryResponse lists what an item cost, newest first. Average prices are per Unit,
+// weighted by the amount bought.
+type ItemPriceHistoryResponse struct {
+	Item             ItemResponse         `json:"item"`
+	Unit             string               `json:"unit" example:"l"`
+	AverageUnitPrice *float64             `json:"average_unit_price,omitempty" example:"2.89"`
+	Stores           []StorePriceResponse `json:"stores"`
+	Prices           []ItemPriceResponse  `json:"prices"`
+}
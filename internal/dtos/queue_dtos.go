@@ -0,0 +1,41 @@
+package dtos
+
+import "time"
+
+type QueueItemResponse struct {
+	ItemID    uint      `json:"item_id" example:"42"`
+	Name      string    `json:"name" example:"oat milk"`
+	Priority  int       `json:"priority" example:"0"`
+	Attempts  int       `json:"attempts" example:"5"`
+	LastError string    `json:"last_error,omitempty" example:"unexpected status code: 500"`
+	CreatedAt time.Time `json:"created_at" example:"2025-03-01T10:00:00Z"`
+	FailedAt  time.Time `json:"failed_at" example:"2025-03-01T16:12:00Z"`
+}
+
+// DeadQueueItemsResponse lists the enrichment jobs that were given up on, most recent first
+type DeadQueueItemsResponse struct {
+	Items []QueueItemResponse `json:"items"`
+}
+
+// QueueStatsResponse counts the enrichment jobs waiting to run, being worked on, waiting to be
+// retried after a failure and given up on
+type QueueStatsResponse struct {
+	Queued   int64 `json:"queued" example:"12"`
+	InFlight int64 `json:"in_flight" example:"3"`
+	Retrying int64 `json:"retrying" example:"2"`
+	Dead     int64 `json:"dead" example:"1"`
+}
+
+// SpoonacularQuotaResponse is how many Spoonacular points have been used and are left today.
+// Quotas reset at midnight UTC.
+type SpoonacularQuotaResponse struct {
+	Day      string    `json:"day" example:"2025-03-01"`
+	Used     float64   `json:"used" example:"48.5"`
+	Left     float64   `json:"left" example:"101.5"`
+	Limit    float64   `json:"limit" example:"150"`
+	ResetsAt time.Time `json:"resets_at" example:"2025-03-02T00:00:00Z"`
+}
+
+type RequeueResponse struct {
+	Requeued int `json:"requeued" example:"1"`
+}
@@ -52,3 +52,24 @@ type RecipeQuery struct {
 	Ingredients []string `json:"ingredients" example:"1,2,3"`
 	Diet        string   `json:"diet" example:"vegan"`
 }
+
+type RecipeSuggestQuery struct {
+	Diet     string `json:"diet" example:"vegetarian"`
+	Page     int    `json:"page" example:"1"`
+	PageSize int    `json:"page_size" example:"10"`
+}
+
+type RecipeSuggestionResponse struct {
+	Recipe       RecipeResponse        `json:"recipe"`
+	Coverage     float64               `json:"coverage" example:"75"`
+	MatchedCount int                   `json:"matched_count" example:"3"`
+	MissingCount int                   `json:"missing_count" example:"1"`
+	MissingItems []MissingItemResponse `json:"missing_items"`
+}
+
+type RecipeSuggestionsResponse struct {
+	Suggestions []RecipeSuggestionResponse `json:"suggestions"`
+	Page        int                        `json:"page" example:"1"`
+	PageSize    int                        `json:"page_size" example:"10"`
+	Count       int                        `json:"count" example:"42"`
+}
@@ -0,0 +1,48 @@
+package dtos
+
+import "time"
+
+type BoundingBoxResponse struct {
+	X      float64 `json:"x" example:"0.1"`
+	Y      float64 `json:"y" example:"0.2"`
+	Width  float64 `json:"width" example:"0.15"`
+	Height float64 `json:"height" example:"0.2"`
+}
+
+// ScanLineResponse is one detection. Item is the known item it matched; when there is none,
+// Suggestion is the closest item and committing the line unchanged proposes a new one.
+type ScanLineResponse struct {
+	ID         uint                 `json:"id" example:"1"`
+	Name       string               `json:"name" example:"Apple"`
+	Item       *ItemResponse        `json:"item,omitempty"`
+	Suggestion *ItemResponse        `json:"suggestion,omitempty"`
+	MatchScore float32              `json:"match_score" example:"1"`
+	Amount     float32              `json:"amount" example:"3"`
+	Unit       string               `json:"unit" example:"unit"`
+	Confidence float32              `json:"confidence" example:"0.93"`
+	Box        *BoundingBoxResponse `json:"box,omitempty"`
+}
+
+type ScanResponse struct {
+	ID        uint               `json:"id" example:"1"`
+	Status    string             `json:"status" example:"pending" enums:"pending,committed,discarded"`
+	Source    string             `json:"source" example:"detect" enums:"detect,predict,receipt"`
+	CreatedAt time.Time          `json:"created_at" example:"2025-03-01T10:00:00Z"`
+	Lines     []ScanLineResponse `json:"lines"`
+}
+
+// ScanLineDecision is the review of one scan line. Accept keeps the line as detected; edit
+// replaces whichever of name, item_id, amount and unit are set; reject drops it.
+type ScanLineDecision struct {
+	ID     uint     `json:"id" example:"1"`
+	Action string   `json:"action" example:"edit" enums:"accept,edit,reject"`
+	Name   string   `json:"name,omitempty" example:"Green apple"`
+	ItemID *uint    `json:"item_id,omitempty" example:"12"`
+	Amount *float32 `json:"amount,omitempty" example:"4"`
+	Unit   string   `json:"unit,omitempty" example:"unit"`
+}
+
+// ScanCommitRequest lists the decisions for a scan; lines without a decision are rejected
+type ScanCommitRequest struct {
+	Lines []ScanLineDecision `json:"lines"`
+}
@@ -0,0 +1,22 @@
+package dtos
+
+import "time"
+
+type ShoppingListRequest struct {
+	Name string `json:"name" example:"Weekly groceries"`
+}
+
+// ShoppingListResponse is a list with its items. EstimatedCost adds up the estimates of the
+// items that have one.
+type ShoppingListResponse struct {
+	ID            uint                       `json:"id" example:"1"`
+	Name          string                     `json:"name" example:"Weekly groceries"`
+	CreatedAt     time.Time                  `json:"created_at" example:"2025-03-01T10:00:00Z"`
+	UpdatedAt     time.Time                  `json:"updated_at" example:"2025-03-01T10:00:00Z"`
+	EstimatedCost float64                    `json:"estimated_cost" example:"23.45"`
+	Items         []ShoppingListItemResponse `json:"items"`
+}
+
+type ShoppingListsResponse struct {
+	ShoppingLists []ShoppingListResponse `json:"shopping_lists"`
+}
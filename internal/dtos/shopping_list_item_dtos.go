@@ -0,0 +1,21 @@
+package dtos
+
+type ShoppingListItemRequest struct {
+	ItemID uint    `json:"item_id" example:"456"`
+	Amount float32 `json:"amount" example:"2.0"`
+	Unit   string  `json:"unit" example:"kg"`
+}
+
+type ShoppingListItemCheckRequest struct {
+	Checked bool `json:"checked" example:"true"`
+}
+
+// ShoppingListItemResponse is an item on a list. EstimatedCost is based on what was paid for
+// the item before and omitted when it has no price history.
+type ShoppingListItemResponse struct {
+	Item          ItemResponse `json:"item"`
+	Amount        float32      `json:"amount" example:"2.0"`
+	Unit          string       `json:"unit" example:"kg"`
+	Checked       bool         `json:"checked" example:"false"`
+	EstimatedCost *float64     `json:"estimated_cost,omitempty" example:"5.98"`
+}
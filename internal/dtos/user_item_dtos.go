@@ -1,19 +1,69 @@
 package dtos
 
+import "time"
+
+// UserItemRequest adds or updates a pantry item. Price, when given, is what was paid for the
+// amount added and is recorded as a purchase at Store; on update it applies to the increase and
+// is refused when the amount does not go up.
 type UserItemRequest struct {
-	ItemID uint    `json:"item_id" example:"456"`
-	Amount float32 `json:"amount" example:"2.0"`
-	Unit   string  `json:"unit" example:"kg"`
+	ItemID      uint       `json:"item_id" example:"456"`
+	Amount      float32    `json:"amount" example:"2.0"`
+	Unit        string     `json:"unit" example:"kg"`
+	PurchasedAt *time.Time `json:"purchased_at,omitempty" example:"2025-03-01T10:00:00Z"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" example:"2025-03-08T10:00:00Z"`
+	Location    string     `json:"location,omitempty" example:"fridge"`
+	Price       *float64   `json:"price,omitempty" example:"3.49"`
+	Store       string     `json:"store,omitempty" example:"Corner Market"`
+	Currency    string     `json:"currency,omitempty" example:"USD"`
+}
+
+// BarcodeRequest adds a scanned package to the pantry. ItemID links a code no product database
+// knows to an item; it is ignored once the code is known. Without an amount, the package size
+// is used when known, else one unit.
+type BarcodeRequest struct {
+	Barcode     string     `json:"barcode" example:"3017620422003"`
+	ItemID      uint       `json:"item_id,omitempty" example:"456"`
+	Amount      float32    `json:"amount,omitempty" example:"400"`
+	Unit        string     `json:"unit,omitempty" example:"g"`
+	PurchasedAt *time.Time `json:"purchased_at,omitempty" example:"2025-03-01T10:00:00Z"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" example:"2025-03-08T10:00:00Z"`
+	Location    string     `json:"location,omitempty" example:"pantry"`
+}
+
+type UserItemLotRequest struct {
+	Amount      float32    `json:"amount" example:"1.0"`
+	Unit        string     `json:"unit" example:"l"`
+	PurchasedAt *time.Time `json:"purchased_at,omitempty" example:"2025-03-01T10:00:00Z"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" example:"2025-03-08T10:00:00Z"`
+	Location    string     `json:"location,omitempty" example:"fridge"`
+}
+
+type UserItemLotResponse struct {
+	ID          uint       `json:"id" example:"12"`
+	Amount      float32    `json:"amount" example:"1.0"`
+	Unit        string     `json:"unit" example:"l"`
+	PurchasedAt *time.Time `json:"purchased_at,omitempty" example:"2025-03-01T10:00:00Z"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" example:"2025-03-08T10:00:00Z"`
+	Location    string     `json:"location" example:"fridge"`
 }
 
+// UserItemResponse aggregates every lot of an item. Amount is the total of the lots in Unit,
+// ExpiresAt and Location are those of the lot that expires first.
 type UserItemResponse struct {
-	Item   ItemResponse `json:"item"`
-	Amount float32      `json:"amount" example:"2.0"`
-	Unit   string       `json:"unit" example:"kg"`
+	Item        ItemResponse          `json:"item"`
+	Amount      float32               `json:"amount" example:"2.0"`
+	Unit        string                `json:"unit" example:"kg"`
+	PurchasedAt *time.Time            `json:"purchased_at,omitempty" example:"2025-03-01T10:00:00Z"`
+	ExpiresAt   *time.Time            `json:"expires_at,omitempty" example:"2025-03-08T10:00:00Z"`
+	Location    string                `json:"location,omitempty" example:"fridge"`
+	Lots        []UserItemLotResponse `json:"lots"`
 }
 
+// UserItemsResponse lists pantry items. After a detection, Scan holds the detections that
+// matched no known item confidently enough to be added without review.
 type UserItemsResponse struct {
 	UserItems []UserItemResponse `json:"user_items"`
+	Scan      *ScanResponse      `json:"scan,omitempty"`
 }
 
 type UserItemQuery struct {
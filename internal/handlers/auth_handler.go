@@ -3,8 +3,10 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
 	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
 )
 
@@ -33,7 +35,7 @@ func (h *AuthHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.Repo.RegisterUser(req, "user") // Default role to "user"
+	resp, err := h.Repo.RegisterUser(req, models.UserRole)
 	if err != nil {
 		w.WriteHeader(http.StatusConflict)
 		json.NewEncoder(w).Encode(dtos.ConflictResponse{Error: err.Error()})
@@ -45,7 +47,7 @@ func (h *AuthHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Logs in a user
-// @Description Logs in a user and returns a JWT token
+// @Description Logs in a user and returns a short-lived access token and a refresh token
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -70,3 +72,62 @@ func (h *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 
 	json.NewEncoder(w).Encode(resp)
 }
+
+// @Summary Refresh an access token
+// @Description Exchanges a refresh token for a new access token and a rotated refresh token. Reusing an already exchanged refresh token revokes the whole session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dtos.RefreshRequest true "Refresh Token Request"
+// @Success 200 {object} dtos.LoginResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /auth/refresh [post]
+func (h *AuthHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	var req dtos.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request format"})
+		return
+	}
+
+	resp, err := h.Repo.RefreshToken(req)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(dtos.UnauthorizedResponse{Error: "Invalid or expired refresh token"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Logs out a user
+// @Description Revokes the refresh token family and, if sent in the Authorization header, the current access token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dtos.LogoutRequest true "User Logout Request"
+// @Success 200 {object} dtos.LogoutResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /auth/logout [post]
+func (h *AuthHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	var req dtos.LogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request format"})
+		return
+	}
+
+	var accessToken string
+	if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		accessToken = strings.TrimPrefix(authHeader, "Bearer ")
+	}
+
+	resp, err := h.Repo.LogoutUser(req, accessToken)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(dtos.UnauthorizedResponse{Error: "Invalid or expired token"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
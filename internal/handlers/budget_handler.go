@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
+	"github.com/GroceryTrak/GroceryTrakService/internal/shelflife"
+	"github.com/GroceryTrak/GroceryTrakService/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type BudgetHandler struct {
+	Repo          repository.BudgetRepository
+	HouseholdRepo repository.HouseholdRepository
+}
+
+func NewBudgetHandler(repo repository.BudgetRepository, householdRepo repository.HouseholdRepository) *BudgetHandler {
+	return &BudgetHandler{Repo: repo, HouseholdRepo: householdRepo}
+}
+
+// @Summary Get budgets
+// @Description Get the authenticated user's monthly grocery budgets with this month's spend, and alerts for those exceeded
+// @Tags budget
+// @Produce json
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.BudgetsResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /budget [get]
+func (h *BudgetHandler) GetBudgetsHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	budgets, err := h.Repo.GetBudgets(owner)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get budgets"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(budgets)
+}
+
+// @Summary Set a budget
+// @Description Set the authenticated user's monthly grocery budget for an item category, or the overall budget when no category is given
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param budget body dtos.BudgetRequest true "Budget"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.BudgetResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /budget [put]
+func (h *BudgetHandler) SetBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	var req dtos.BudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Amount <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
+
+	if req.Category != "" && !shelflife.IsValidCategory(models.ItemCategory(req.Category)) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid category"})
+		return
+	}
+
+	budget, err := h.Repo.SetBudget(req, owner)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to set budget"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(budget)
+}
+
+// @Summary Delete a budget
+// @Description Delete one of the authenticated user's budgets
+// @Tags budget
+// @Produce json
+// @Param id path int true "Budget ID"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 204 "No Content"
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /budget/{id} [delete]
+func (h *BudgetHandler) DeleteBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	budgetID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid budget ID"})
+		return
+	}
+
+	if err := h.Repo.DeleteBudget(uint(budgetID), owner); errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Budget not found"})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to delete budget"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get a spend report
+// @Description Total what the authenticated user spent on groceries in a period, grouped by item category, store or week, with alerts for budgets exceeded this month
+// @Tags budget
+// @Produce json
+// @Param from query string false "First day, defaults to the first of this month"
+// @Param to query string false "Last day, defaults to today"
+// @Param group_by query string false "Grouping" Enums(category, store, week) default(category)
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.SpendReportResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /report/spend [get]
+func (h *BudgetHandler) GetSpendReportHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	query := dtos.SpendReportQuery{
+		From:    today.AddDate(0, 0, 1-today.Day()),
+		To:      today,
+		GroupBy: dtos.SpendByCategory,
+	}
+	for param, date := range map[string]*time.Time{"from": &query.From, "to": &query.To} {
+		if value := r.URL.Query().Get(param); value != "" {
+			t, err := utils.ParseDate(value)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid " + param + " date"})
+				return
+			}
+			*date = t
+		}
+	}
+	if query.To.Before(query.From) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "from must not be after to"})
+		return
+	}
+
+	if groupBy := r.URL.Query().Get("group_by"); groupBy != "" {
+		switch groupBy {
+		case dtos.SpendByCategory, dtos.SpendByStore, dtos.SpendByWeek:
+			query.GroupBy = groupBy
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "group_by must be category, store or week"})
+			return
+		}
+	}
+
+	report, err := h.Repo.GetSpendReport(query, owner)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get spend report"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
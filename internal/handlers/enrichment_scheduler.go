@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
+)
+
+// EnrichmentScheduler queues items whose Spoonacular data is older than maxAge to be refreshed,
+// and items still waiting to be enriched whose job went missing. Jobs are keyed by item, so any
+// number of instances can run it at once.
+type EnrichmentScheduler struct {
+	queue    repository.ItemQueueRepository
+	itemRepo repository.ItemRepository
+	maxAge   time.Duration
+	interval time.Duration
+}
+
+func NewEnrichmentScheduler(queue repository.ItemQueueRepository, itemRepo repository.ItemRepository, maxAge time.Duration) *EnrichmentScheduler {
+	return &EnrichmentScheduler{
+		queue:    queue,
+		itemRepo: itemRepo,
+		maxAge:   maxAge,
+		interval: time.Hour,
+	}
+}
+
+// Start checks for stale items every interval until ctx is done
+func (s *EnrichmentScheduler) Start(ctx context.Context) error {
+	log.Printf("Starting enrichment scheduler, refreshing items enriched more than %s ago...", s.maxAge)
+
+	for {
+		if err := s.schedule(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error scheduling enrichment: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.interval):
+		}
+	}
+}
+
+// schedule queues the stale items. Refreshes go in at low priority so that items nobody has
+// seen nutrients for yet come first.
+func (s *EnrichmentScheduler) schedule(ctx context.Context) error {
+	items, err := s.itemRepo.FindStaleItems(time.Now().Add(-s.maxAge))
+	if err != nil {
+		return fmt.Errorf("failed to find stale items: %w", err)
+	}
+
+	for _, item := range items {
+		priority := models.LowPriority
+		if item.EnrichmentStatus == models.EnrichmentPending {
+			priority = models.DefaultPriority
+		}
+
+		queueItem := models.QueueItem{
+			ItemID:    item.ID,
+			Name:      item.Name,
+			CreatedAt: time.Now(),
+			Priority:  priority,
+		}
+		if err := s.queue.AddItem(ctx, queueItem); err != nil {
+			return fmt.Errorf("failed to queue item %d: %w", item.ID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/middlewares"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+// HouseholdHeader selects a household's shared pantry and shopping lists instead of the
+// user's own. The same can be done with a /household/{household_id} path prefix.
+const HouseholdHeader = "X-Household-ID"
+
+type HouseholdHandler struct {
+	Repo repository.HouseholdRepository
+}
+
+func NewHouseholdHandler(repo repository.HouseholdRepository) *HouseholdHandler {
+	return &HouseholdHandler{Repo: repo}
+}
+
+// resolveOwner works out whose pantry a request targets: the household from the path or the
+// X-Household-ID header when the user is a member of it, or else the user's own. It writes the
+// error response and returns false when the household cannot be used.
+func resolveOwner(w http.ResponseWriter, r *http.Request, households repository.HouseholdRepository) (repository.Owner, bool) {
+	owner := repository.Owner{UserID: r.Context().Value(middlewares.IDKey).(uint)}
+
+	param := chi.URLParam(r, "household_id")
+	if param == "" {
+		param = r.Header.Get(HouseholdHeader)
+	}
+	if param == "" {
+		return owner, true
+	}
+
+	householdID, err := strconv.ParseUint(param, 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid household ID"})
+		return repository.Owner{}, false
+	}
+
+	if _, err := households.GetMemberRole(uint(householdID), owner.UserID); err != nil {
+		writeHouseholdError(w, err, "Failed to check household membership")
+		return repository.Owner{}, false
+	}
+
+	id := uint(householdID)
+	owner.HouseholdID = &id
+	return owner, true
+}
+
+// writeHouseholdError maps a repository error to a 403, 404, 409 or 500 response
+func writeHouseholdError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrNotHouseholdMember), errors.Is(err, repository.ErrNotHouseholdOwner):
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(dtos.ForbiddenResponse{Error: err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Household not found"})
+	case errors.Is(err, repository.ErrLastHouseholdOwner), errors.Is(err, repository.ErrAlreadyMember):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(dtos.ConflictResponse{Error: err.Error()})
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: message})
+	}
+}
+
+func parseHouseholdID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	householdID, err := strconv.ParseUint(chi.URLParam(r, "household_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid household ID"})
+		return 0, false
+	}
+	return uint(householdID), true
+}
+
+// @Summary Get all user's households
+// @Description Get every household the authenticated user belongs to
+// @Tags household
+// @Produce json
+// @Success 200 {object} dtos.HouseholdsResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /household [get]
+func (h *HouseholdHandler) GetAllHouseholdsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middlewares.IDKey).(uint)
+
+	households, err := h.Repo.GetAllHouseholds(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get households"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(households)
+}
+
+// @Summary Get a household
+// @Description Get a household the authenticated user belongs to, with its members
+// @Tags household
+// @Produce json
+// @Param household_id path int true "Household ID"
+// @Success 200 {object} dtos.HouseholdResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /household/{household_id} [get]
+func (h *HouseholdHandler) GetHouseholdHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middlewares.IDKey).(uint)
+
+	householdID, ok := parseHouseholdID(w, r)
+	if !ok {
+		return
+	}
+
+	household, err := h.Repo.GetHousehold(householdID, userID)
+	if err != nil {
+		writeHouseholdError(w, err, "Failed to get household")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(household)
+}
+
+// @Summary Create a household
+// @Description Create a household with the authenticated user as its owner
+// @Tags household
+// @Accept json
+// @Produce json
+// @Param household body dtos.HouseholdRequest true "Create Household"
+// @Success 201 {object} dtos.HouseholdResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /household [post]
+func (h *HouseholdHandler) CreateHouseholdHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middlewares.IDKey).(uint)
+
+	var req dtos.HouseholdRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
+
+	household, err := h.Repo.CreateHousehold(req, userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to create household"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(household)
+}
+
+// @Summary Rename a household
+// @Description Rename a household; only owners can do this
+// @Tags household
+// @Accept json
+// @Produce json
+// @Param household_id path int true "Household ID"
+// @Param household body dtos.HouseholdRequest true "Update Household"
+// @Success 200 {object} dtos.HouseholdResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /household/{household_id} [put]
+func (h *HouseholdHandler) UpdateHouseholdHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middlewares.IDKey).(uint)
+
+	householdID, ok := parseHouseholdID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.HouseholdRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
+
+	household, err := h.Repo.UpdateHousehold(req, householdID, userID)
+	if err != nil {
+		writeHouseholdError(w, err, "Failed to update household")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(household)
+}
+
+// @Summary Delete a household
+// @Description Delete a household with its shared pantry and shopping lists; only owners can do this
+// @Tags household
+// @Param household_id path int true "Household ID"
+// @Success 204
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /household/{household_id} [delete]
+func (h *HouseholdHandler) DeleteHouseholdHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middlewares.IDKey).(uint)
+
+	householdID, ok := parseHouseholdID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Repo.DeleteHousehold(householdID, userID); err != nil {
+		writeHouseholdError(w, err, "Failed to delete household")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Regenerate a household's invite code
+// @Description Replace the invite code so the old one stops working; only owners can do this
+// @Tags household
+// @Produce json
+// @Param household_id path int true "Household ID"
+// @Success 200 {object} dtos.HouseholdResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /household/{household_id}/invite [post]
+func (h *HouseholdHandler) RegenerateInviteCodeHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middlewares.IDKey).(uint)
+
+	householdID, ok := parseHouseholdID(w, r)
+	if !ok {
+		return
+	}
+
+	household, err := h.Repo.RegenerateInviteCode(householdID, userID)
+	if err != nil {
+		writeHouseholdError(w, err, "Failed to regenerate invite code")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(household)
+}
+
+// @Summary Join a household
+// @Description Join a household as a member using its invite code
+// @Tags household
+// @Accept json
+// @Produce json
+// @Param join body dtos.HouseholdJoinRequest true "Invite Code"
+// @Success 200 {object} dtos.HouseholdResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /household/join [post]
+func (h *HouseholdHandler) JoinHouseholdHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middlewares.IDKey).(uint)
+
+	var req dtos.HouseholdJoinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.InviteCode == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
+
+	household, err := h.Repo.JoinHousehold(req, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Invalid invite code"})
+		return
+	} else if err != nil {
+		writeHouseholdError(w, err, "Failed to join household")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(household)
+}
+
+// @Summary Leave a household
+// @Description Leave a household; the last owner has to hand over ownership first unless nobody else is left
+// @Tags household
+// @Param household_id path int true "Household ID"
+// @Success 204
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /household/{household_id}/leave [post]
+func (h *HouseholdHandler) LeaveHouseholdHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middlewares.IDKey).(uint)
+
+	householdID, ok := parseHouseholdID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Repo.LeaveHousehold(householdID, userID); err != nil {
+		writeHouseholdError(w, err, "Failed to leave household")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Change a member's role
+// @Description Promote a member to owner or demote an owner to member; only owners can do this
+// @Tags household
+// @Accept json
+// @Produce json
+// @Param household_id path int true "Household ID"
+// @Param user_id path int true "Member User ID"
+// @Param member body dtos.HouseholdMemberRequest true "Member Role"
+// @Success 200 {object} dtos.HouseholdResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /household/{household_id}/member/{user_id} [put]
+func (h *HouseholdHandler) UpdateHouseholdMemberHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middlewares.IDKey).(uint)
+
+	householdID, ok := parseHouseholdID(w, r)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseUint(chi.URLParam(r, "user_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	var req dtos.HouseholdMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
+
+	if role := models.HouseholdRole(req.Role); role != models.HouseholdOwnerRole && role != models.HouseholdMemberRole {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid household role"})
+		return
+	}
+
+	household, err := h.Repo.UpdateHouseholdMember(req, householdID, uint(memberID), userID)
+	if err != nil {
+		writeHouseholdError(w, err, "Failed to update household member")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(household)
+}
+
+// @Summary Remove a member
+// @Description Remove a member from a household; only owners can do this
+// @Tags household
+// @Param household_id path int true "Household ID"
+// @Param user_id path int true "Member User ID"
+// @Success 204
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /household/{household_id}/member/{user_id} [delete]
+func (h *HouseholdHandler) RemoveHouseholdMemberHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middlewares.IDKey).(uint)
+
+	householdID, ok := parseHouseholdID(w, r)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseUint(chi.URLParam(r, "user_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	if err := h.Repo.RemoveHouseholdMember(householdID, uint(memberID), userID); err != nil {
+		writeHouseholdError(w, err, "Failed to remove household member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
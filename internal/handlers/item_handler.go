@@ -2,20 +2,26 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
 	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
+	"github.com/GroceryTrak/GroceryTrakService/internal/shelflife"
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 )
 
 type ItemHandler struct {
-	Repo repository.ItemRepository
+	Repo      repository.ItemRepository
+	MatchRepo repository.ItemMatchRepository
 }
 
-func NewItemHandler(repo repository.ItemRepository) *ItemHandler {
-	return &ItemHandler{Repo: repo}
+func NewItemHandler(repo repository.ItemRepository, matchRepo repository.ItemMatchRepository) *ItemHandler {
+	return &ItemHandler{Repo: repo, MatchRepo: matchRepo}
 }
 
 // @Summary Get an item
@@ -62,6 +68,12 @@ func (h *ItemHandler) CreateItemHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if newItem.Category != "" && !shelflife.IsValidCategory(models.ItemCategory(newItem.Category)) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid category"})
+		return
+	}
+
 	createdItem, err := h.Repo.CreateItem(newItem)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -99,6 +111,12 @@ func (h *ItemHandler) UpdateItemHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if updatedItem.Category != "" && !shelflife.IsValidCategory(models.ItemCategory(updatedItem.Category)) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid category"})
+		return
+	}
+
 	item, err := h.Repo.UpdateItem(uint(id), updatedItem)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -163,3 +181,190 @@ func (h *ItemHandler) SearchItemsHandler(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(items)
 }
+
+// @Summary List item aliases
+// @Description Get the other names an item is matched by when detected
+// @Tags item
+// @Accept json
+// @Produce json
+// @Param id path int true "Item ID"
+// @Success 200 {object} dtos.ItemAliasesResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /item/{id}/alias [get]
+func (h *ItemHandler) GetItemAliasesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid item ID"})
+		return
+	}
+
+	aliases, err := h.MatchRepo.GetItemAliases(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Item not found"})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Database error"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(aliases)
+}
+
+// @Summary Add an item alias
+// @Description Make detections of another name resolve to this item. An alias already used by another item is moved to this one.
+// @Tags item
+// @Accept json
+// @Produce json
+// @Param id path int true "Item ID"
+// @Param alias body dtos.ItemAliasRequest true "Alias"
+// @Success 201 {object} dtos.ItemAliasResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /item/{id}/alias [post]
+func (h *ItemHandler) AddItemAliasHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid item ID"})
+		return
+	}
+
+	var req dtos.ItemAliasRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Alias) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request data"})
+		return
+	}
+
+	alias, err := h.MatchRepo.AddItemAlias(req, uint(id))
+	if errors.Is(err, repository.ErrInvalidAlias) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Alias must contain letters or digits"})
+		return
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Item not found"})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to add alias"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(alias)
+}
+
+// @Summary Delete an item alias
+// @Description Remove one of an item's aliases
+// @Tags item
+// @Accept json
+// @Produce json
+// @Param id path int true "Item ID"
+// @Param alias_id path int true "Alias ID"
+// @Success 204 "No Content"
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /item/{id}/alias/{alias_id} [delete]
+func (h *ItemHandler) DeleteItemAliasHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid item ID"})
+		return
+	}
+
+	aliasID, err := strconv.ParseUint(chi.URLParam(r, "alias_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid alias ID"})
+		return
+	}
+
+	if err := h.MatchRepo.DeleteItemAlias(uint(id), uint(aliasID)); errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Alias not found"})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to delete alias"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Find duplicate items
+// @Description List groups of catalog items that are probably the same product, by normalized name and trigram similarity
+// @Tags item
+// @Accept json
+// @Produce json
+// @Param threshold query number false "Lowest name similarity (0-1) to report, defaults to 0.4"
+// @Success 200 {object} dtos.ItemDuplicatesResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /item/duplicates [get]
+func (h *ItemHandler) FindDuplicateItemsHandler(w http.ResponseWriter, r *http.Request) {
+	var threshold float64
+	if raw := r.URL.Query().Get("threshold"); raw != "" {
+		var err error
+		threshold, err = strconv.ParseFloat(raw, 64)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Threshold must be between 0 and 1"})
+			return
+		}
+	}
+
+	duplicates, err := h.Repo.FindDuplicateItems(threshold)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Database error"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(duplicates)
+}
+
+// @Summary Merge items
+// @Description Merge duplicate items into the one to keep. Pantry lots, recipe and shopping list entries and nutrients are moved over in one transaction, the duplicates are deleted and their names kept as aliases. Amounts on the same recipe or list are added up, and the merge fails with 409 when their units cannot be converted.
+// @Tags item
+// @Accept json
+// @Produce json
+// @Param merge body dtos.ItemMergeRequest true "Item to keep and its duplicates"
+// @Success 200 {object} dtos.ItemResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /item/merge [post]
+func (h *ItemHandler) MergeItemsHandler(w http.ResponseWriter, r *http.Request) {
+	var req dtos.ItemMergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request data"})
+		return
+	}
+
+	item, err := h.Repo.MergeItems(req)
+	if errors.Is(err, repository.ErrInvalidItemMerge) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: err.Error()})
+		return
+	} else if errors.Is(err, repository.ErrItemMergeConflict) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(dtos.ConflictResponse{Error: err.Error()})
+		return
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Item not found"})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to merge items"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestItemHandlersRejectInvalidCategory(t *testing.T) {
+	// The category is checked before the repository is used
+	h := NewItemHandler(nil, nil)
+	body := `{"name":"Milk","category":"snacks"}`
+
+	w := httptest.NewRecorder()
+	h.CreateItemHandler(w, httptest.NewRequest(http.MethodPost, "/item", strings.NewReader(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("CreateItemHandler() status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", "1")
+	r := httptest.NewRequest(http.MethodPut, "/item/1", strings.NewReader(body))
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+	w = httptest.NewRecorder()
+	h.UpdateItemHandler(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("UpdateItemHandler() status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
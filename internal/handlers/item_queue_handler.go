@@ -2,129 +2,189 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"net/url"
+	"sync"
 	"time"
 
 	"github.com/GroceryTrak/GroceryTrakService/internal/clients"
 	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
 	"github.com/GroceryTrak/GroceryTrakService/internal/models"
 	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
+	"github.com/GroceryTrak/GroceryTrakService/internal/shelflife"
 )
 
 type ItemQueueHandler struct {
 	queue       repository.ItemQueueRepository
 	spoonacular *clients.SpoonacularClient
 	itemRepo    repository.ItemRepository
-	batchSize   int
+	limiter     repository.RateLimitRepository
+	concurrency int
 	interval    time.Duration
+	lease       time.Duration
 }
 
+// NewItemQueueHandler sets up concurrency workers that share limiter with every other instance
 func NewItemQueueHandler(
 	queue repository.ItemQueueRepository,
 	spoonacular *clients.SpoonacularClient,
 	itemRepo repository.ItemRepository,
+	limiter repository.RateLimitRepository,
+	concurrency int,
 ) *ItemQueueHandler {
 	return &ItemQueueHandler{
 		queue:       queue,
 		spoonacular: spoonacular,
 		itemRepo:    itemRepo,
-		batchSize:   10,
-		interval:    1 * time.Minute,
+		limiter:     limiter,
+		concurrency: max(concurrency, 1),
+		interval:    30 * time.Second,
+		lease:       5 * time.Minute,
 	}
 }
 
+// Start runs the workers until ctx is done. Each claims one job at a time from Redis, so any
+// number of instances can work the same queue.
 func (h *ItemQueueHandler) Start(ctx context.Context) error {
-	ticker := time.NewTicker(h.interval)
-	defer ticker.Stop()
+	log.Printf("Starting item queue handler with %d workers...", h.concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < h.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.work(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
 
-	log.Println("Starting item queue handler...")
+// work processes jobs one after another, resting for interval when there are none or something
+// went wrong
+func (h *ItemQueueHandler) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		processed, err := h.processNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Error processing item: %v", err)
+		}
+		if processed && err == nil {
+			continue
+		}
 
-	for {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
-		case <-ticker.C:
-			if err := h.processBatch(ctx); err != nil {
-				log.Printf("Error processing batch: %v", err)
-			}
+		case <-time.After(h.interval):
 		}
 	}
 }
 
-func (h *ItemQueueHandler) processBatch(ctx context.Context) error {
-	credits, err := h.queue.CheckAPICredits(ctx)
+// processNext claims the next job and enriches its item, reporting whether there was one
+func (h *ItemQueueHandler) processNext(ctx context.Context) (bool, error) {
+	// Enriching an item takes two points, see SearchIngredient
+	quota, err := h.spoonacular.Quota(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to check API credits: %w", err)
+		return false, fmt.Errorf("failed to check Spoonacular quota: %w", err)
 	}
-	if credits <= 0 {
-		return fmt.Errorf("no API credits remaining")
+	if quota.Left < 2 {
+		return false, fmt.Errorf("%w until %s", clients.ErrQuotaExhausted, quota.ResetsAt.Format(time.RFC3339))
 	}
 
-	items, err := h.queue.GetNextBatch(ctx, h.batchSize)
+	items, err := h.queue.ClaimBatch(ctx, 1, h.lease)
 	if err != nil {
-		return fmt.Errorf("failed to get batch: %w", err)
+		return false, fmt.Errorf("failed to claim item: %w", err)
 	}
-
 	if len(items) == 0 {
-		return nil
+		return false, nil
 	}
+	item := items[0]
 
-	for _, item := range items {
-		encodedName := url.QueryEscape(item.Name)
-		spoonacularItem, err := h.spoonacular.SearchIngredient(ctx, encodedName)
-		if err != nil {
-			log.Printf("Failed to search for item %s: %v", item.Name, err)
-			if err := h.queue.RemoveItem(ctx, item); err != nil {
-				log.Printf("Failed to remove item from queue: %v", err)
-			}
-			continue
+	// Searching takes two calls, one to find the ingredient and one for its details
+	if err := h.limiter.Wait(ctx, 2); err != nil {
+		// Shutting down: hand the job back rather than let its lease run out
+		if err := h.queue.ReleaseItem(context.Background(), item); err != nil {
+			log.Printf("Failed to release item: %v", err)
 		}
+		return true, err
+	}
 
-		nutrients := make([]models.ItemNutrient, len(spoonacularItem.Nutrition.Nutrients))
-		for i, n := range spoonacularItem.Nutrition.Nutrients {
-			nutrients[i] = models.ItemNutrient{
-				Name:                n.Name,
-				Amount:              n.Amount,
-				Unit:                n.Unit,
-				PercentOfDailyNeeds: n.PercentOfDailyNeeds,
-			}
-		}
+	h.enrich(ctx, item)
+	return true, nil
+}
 
-		updateReq := dtos.ItemRequest{
-			Name:          item.Name,
-			Image:         spoonacularItem.Image,
-			SpoonacularID: uint(spoonacularItem.ID),
-			Nutrients:     make([]dtos.ItemNutrientRequest, len(nutrients)),
+// enrich fills an item in from Spoonacular and settles its job: done, to be retried, or given up
+func (h *ItemQueueHandler) enrich(ctx context.Context, item models.QueueItem) {
+	spoonacularItem, err := h.spoonacular.SearchIngredient(ctx, item.Name)
+	if errors.Is(err, clients.ErrNoResults) {
+		// Asking again will not find it either
+		log.Printf("No Spoonacular match for item %s", item.Name)
+		if err := h.queue.DeadLetterItem(ctx, item, err); err != nil {
+			log.Printf("Failed to dead-letter item: %v", err)
 		}
-
-		for i, n := range nutrients {
-			updateReq.Nutrients[i] = dtos.ItemNutrientRequest{
-				Name:                n.Name,
-				Amount:              n.Amount,
-				Unit:                n.Unit,
-				PercentOfDailyNeeds: n.PercentOfDailyNeeds,
-			}
+		h.setStatus(item, models.EnrichmentNotFound)
+		return
+	} else if errors.Is(err, clients.ErrQuotaExhausted) || err != nil && ctx.Err() != nil {
+		// Not the item's fault: put it back for when there is quota, or for another worker
+		if err := h.queue.ReleaseItem(context.Background(), item); err != nil {
+			log.Printf("Failed to release item: %v", err)
 		}
+		return
+	} else if err != nil {
+		log.Printf("Failed to search for item %s: %v", item.Name, err)
+		if err := h.queue.RetryItem(ctx, item, err); err != nil {
+			log.Printf("Failed to schedule item for retry: %v", err)
+		}
+		h.setStatus(item, models.EnrichmentFailed)
+		return
+	}
 
-		_, err = h.itemRepo.UpdateItem(item.ItemID, updateReq)
-		if err != nil {
-			log.Printf("Failed to update item %d: %v", item.ItemID, err)
-			continue
+	nutrients := make([]models.ItemNutrient, len(spoonacularItem.Nutrition.Nutrients))
+	for i, n := range spoonacularItem.Nutrition.Nutrients {
+		nutrients[i] = models.ItemNutrient{
+			Name:                n.Name,
+			Amount:              n.Amount,
+			Unit:                n.Unit,
+			PercentOfDailyNeeds: n.PercentOfDailyNeeds,
 		}
+	}
 
-		if err := h.queue.RemoveItem(ctx, item); err != nil {
-			log.Printf("Failed to remove item from queue: %v", err)
-			continue
+	updateReq := dtos.ItemRequest{
+		Name:          item.Name,
+		Image:         spoonacularItem.Image,
+		SpoonacularID: uint(spoonacularItem.ID),
+		Category:      string(shelflife.CategoryFromAisle(spoonacularItem.Aisle)),
+		Nutrients:     make([]dtos.ItemNutrientRequest, len(nutrients)),
+	}
+
+	for i, n := range nutrients {
+		updateReq.Nutrients[i] = dtos.ItemNutrientRequest{
+			Name:                n.Name,
+			Amount:              n.Amount,
+			Unit:                n.Unit,
+			PercentOfDailyNeeds: n.PercentOfDailyNeeds,
 		}
+	}
 
-		if err := h.queue.DecrementAPICredits(ctx); err != nil {
-			log.Printf("Failed to decrement API credits: %v", err)
+	_, err = h.itemRepo.EnrichItem(item.ItemID, updateReq)
+	if err != nil {
+		log.Printf("Failed to update item %d: %v", item.ItemID, err)
+		if err := h.queue.RetryItem(ctx, item, err); err != nil {
+			log.Printf("Failed to schedule item for retry: %v", err)
 		}
+		h.setStatus(item, models.EnrichmentFailed)
+		return
+	}
 
-		time.Sleep(100 * time.Millisecond)
+	if err := h.queue.CompleteItem(ctx, item); err != nil {
+		log.Printf("Failed to remove item from queue: %v", err)
 	}
+}
 
-	return nil
+// setStatus records on the item why its job did not complete
+func (h *ItemQueueHandler) setStatus(item models.QueueItem, status models.EnrichmentStatus) {
+	if err := h.itemRepo.SetEnrichmentStatus(item.ItemID, status); err != nil {
+		log.Printf("Failed to set enrichment status of item %d: %v", item.ItemID, err)
+	}
 }
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
+	"github.com/GroceryTrak/GroceryTrakService/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type PurchaseHandler struct {
+	Repo          repository.PurchaseRepository
+	HouseholdRepo repository.HouseholdRepository
+}
+
+func NewPurchaseHandler(repo repository.PurchaseRepository, householdRepo repository.HouseholdRepository) *PurchaseHandler {
+	return &PurchaseHandler{Repo: repo, HouseholdRepo: householdRepo}
+}
+
+// writePurchaseError maps a repository error to a 404 or a 500 response
+func writePurchaseError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Purchase or item not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: message})
+}
+
+// @Summary Get purchases
+// @Description Get the authenticated user's purchases, newest first
+// @Tags purchase
+// @Produce json
+// @Param from query string false "First day, such as 2025-03-01"
+// @Param to query string false "Last day, such as 2025-03-31"
+// @Param store query string false "Store name"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.PurchasesResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /purchase [get]
+func (h *PurchaseHandler) GetPurchasesHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	query := dtos.PurchaseQuery{Store: r.URL.Query().Get("store")}
+	for param, date := range map[string]**time.Time{"from": &query.From, "to": &query.To} {
+		if value := r.URL.Query().Get(param); value != "" {
+			t, err := utils.ParseDate(value)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid " + param + " date"})
+				return
+			}
+			*date = &t
+		}
+	}
+
+	purchases, err := h.Repo.GetPurchases(query, owner)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get purchases"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(purchases)
+}
+
+// @Summary Get a purchase
+// @Description Get a purchase of the authenticated user by ID
+// @Tags purchase
+// @Produce json
+// @Param id path int true "Purchase ID"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.PurchaseResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /purchase/{id} [get]
+func (h *PurchaseHandler) GetPurchaseHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	purchaseID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid purchase ID"})
+		return
+	}
+
+	purchase, err := h.Repo.GetPurchase(uint(purchaseID), owner)
+	if err != nil {
+		writePurchaseError(w, err, "Failed to get purchase")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(purchase)
+}
+
+// @Summary Record a purchase
+// @Description Record a shopping trip of the authenticated user with the price paid for each item
+// @Tags purchase
+// @Accept json
+// @Produce json
+// @Param purchase body dtos.PurchaseRequest true "Purchase"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 201 {object} dtos.PurchaseResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /purchase [post]
+func (h *PurchaseHandler) CreatePurchaseHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	var req dtos.PurchaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Lines) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
+	for _, line := range req.Lines {
+		if line.Amount < 0 || line.Price < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Amounts and prices cannot be negative"})
+			return
+		}
+	}
+
+	purchase, err := h.Repo.CreatePurchase(req, owner)
+	if err != nil {
+		writePurchaseError(w, err, "Failed to record purchase")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(purchase)
+}
+
+// @Summary Delete a purchase
+// @Description Delete a purchase of the authenticated user. Pantry items added with it are kept.
+// @Tags purchase
+// @Produce json
+// @Param id path int true "Purchase ID"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 204 "No Content"
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /purchase/{id} [delete]
+func (h *PurchaseHandler) DeletePurchaseHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	purchaseID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid purchase ID"})
+		return
+	}
+
+	if err := h.Repo.DeletePurchase(uint(purchaseID), owner); err != nil {
+		writePurchaseError(w, err, "Failed to delete purchase")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get the price history of an item
+// @Description Get what the authenticated user paid for an item, newest first, with the average unit price overall and per store
+// @Tags purchase
+// @Produce json
+// @Param item_id path int true "Item ID"
+// @Param unit query string false "Unit to give prices per, defaults to the unit of the latest purchase"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.ItemPriceHistoryResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /purchase/price/{item_id} [get]
+func (h *PurchaseHandler) GetItemPriceHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	itemID, err := strconv.ParseUint(chi.URLParam(r, "item_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid item ID"})
+		return
+	}
+
+	history, err := h.Repo.GetItemPriceHistory(uint(itemID), r.URL.Query().Get("unit"), owner)
+	if err != nil {
+		writePurchaseError(w, err, "Failed to get price history")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/clients"
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
+	"github.com/go-chi/chi/v5"
+)
+
+// QueueHandler lets admins look into the item enrichment queue and the Spoonacular quota it spends
+type QueueHandler struct {
+	Repo        repository.ItemQueueRepository
+	Spoonacular *clients.SpoonacularClient
+}
+
+func NewQueueHandler(repo repository.ItemQueueRepository, spoonacular *clients.SpoonacularClient) *QueueHandler {
+	return &QueueHandler{Repo: repo, Spoonacular: spoonacular}
+}
+
+// @Summary Get enrichment queue stats
+// @Description Count the item enrichment jobs that are queued, being worked on, waiting for a retry and dead-lettered
+// @Tags queue
+// @Produce json
+// @Success 200 {object} dtos.QueueStatsResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /queue [get]
+func (h *QueueHandler) GetQueueStatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.Repo.GetQueueStats(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get queue stats"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// @Summary List dead-lettered enrichment jobs
+// @Description List the item enrichment jobs that failed too often, or that Spoonacular had no match for, most recent first
+// @Tags queue
+// @Produce json
+// @Success 200 {object} dtos.DeadQueueItemsResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /queue/dead [get]
+func (h *QueueHandler) GetDeadItemsHandler(w http.ResponseWriter, r *http.Request) {
+	items, err := h.Repo.GetDeadItems(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get dead-lettered jobs"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// @Summary Requeue every dead-lettered enrichment job
+// @Description Put every dead-lettered job back in the queue with its attempts reset
+// @Tags queue
+// @Produce json
+// @Success 200 {object} dtos.RequeueResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /queue/dead/requeue [post]
+func (h *QueueHandler) RequeueDeadItemsHandler(w http.ResponseWriter, r *http.Request) {
+	requeued, err := h.Repo.RequeueDeadItems(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to requeue dead-lettered jobs"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dtos.RequeueResponse{Requeued: requeued})
+}
+
+// @Summary Requeue a dead-lettered enrichment job
+// @Description Put the dead-lettered job of an item back in the queue with its attempts reset
+// @Tags queue
+// @Produce json
+// @Param item_id path int true "Item ID"
+// @Success 200 {object} dtos.RequeueResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /queue/dead/{item_id}/requeue [post]
+func (h *QueueHandler) RequeueDeadItemHandler(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseUint(chi.URLParam(r, "item_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid item ID"})
+		return
+	}
+
+	err = h.Repo.RequeueDeadItem(r.Context(), uint(itemID))
+	if errors.Is(err, repository.ErrQueueItemNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "No dead-lettered job for this item"})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to requeue dead-lettered job"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dtos.RequeueResponse{Requeued: 1})
+}
+
+// @Summary Get the Spoonacular quota
+// @Description Get how many Spoonacular points have been used and are left today, as last reported by Spoonacular
+// @Tags queue
+// @Produce json
+// @Success 200 {object} dtos.SpoonacularQuotaResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /queue/quota [get]
+func (h *QueueHandler) GetQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	quota, err := h.Spoonacular.Quota(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get Spoonacular quota"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dtos.SpoonacularQuotaResponse{
+		Day:      quota.Day,
+		Used:     quota.Used,
+		Left:     quota.Left,
+		Limit:    quota.Limit,
+		ResetsAt: quota.ResetsAt,
+	})
+}
@@ -2,21 +2,37 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/planner"
 	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 )
 
 type RecipeHandler struct {
-	Repo repository.RecipeRepository
+	Repo             repository.RecipeRepository
+	UserItemRepo     repository.UserItemRepository
+	ShoppingListRepo repository.ShoppingListRepository
+	HouseholdRepo    repository.HouseholdRepository
 }
 
-func NewRecipeHandler(repo repository.RecipeRepository) *RecipeHandler {
-	return &RecipeHandler{Repo: repo}
+func NewRecipeHandler(
+	repo repository.RecipeRepository,
+	userItemRepo repository.UserItemRepository,
+	shoppingListRepo repository.ShoppingListRepository,
+	householdRepo repository.HouseholdRepository,
+) *RecipeHandler {
+	return &RecipeHandler{
+		Repo:             repo,
+		UserItemRepo:     userItemRepo,
+		ShoppingListRepo: shoppingListRepo,
+		HouseholdRepo:    householdRepo,
+	}
 }
 
 // @Summary Get a recipe
@@ -165,3 +181,182 @@ func (h *RecipeHandler) SearchRecipesHandler(w http.ResponseWriter, r *http.Requ
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(recipes)
 }
+
+// @Summary Get the items missing to cook a recipe
+// @Description Compares the recipe's ingredients, scaled to the requested servings, against the authenticated user's items and returns what is missing. If a shopping list ID is given, the missing items are appended to it.
+// @Tags recipe
+// @Accept json
+// @Produce json
+// @Param id path int true "Recipe ID"
+// @Param request body dtos.MissingItemsRequest true "Servings and optional shopping list"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.MissingItemsResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /recipe/{id}/missing [post]
+func (h *RecipeHandler) MissingItemsHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid recipe ID"})
+		return
+	}
+
+	var req dtos.MissingItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Servings < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request data"})
+		return
+	}
+
+	recipe, err := h.Repo.GetRecipe(uint(id))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Recipe not found"})
+		return
+	}
+
+	userItems, err := h.UserItemRepo.GetAllUserItems(owner)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get all user items"})
+		return
+	}
+
+	servings := req.Servings
+	if servings == 0 {
+		servings = recipe.Servings
+	}
+
+	resp := dtos.MissingItemsResponse{
+		RecipeID:     recipe.ID,
+		Servings:     servings,
+		MissingItems: planner.MissingItems(recipe, userItems, servings),
+	}
+
+	if req.ShoppingListID != nil {
+		listItems := make([]dtos.ShoppingListItemRequest, len(resp.MissingItems))
+		for i, missing := range resp.MissingItems {
+			listItems[i] = dtos.ShoppingListItemRequest{
+				ItemID: missing.Item.ID,
+				Amount: missing.Missing,
+				Unit:   missing.Unit,
+			}
+		}
+
+		list, err := h.ShoppingListRepo.AddShoppingListItems(listItems, *req.ShoppingListID, owner)
+		if err != nil {
+			writeShoppingListError(w, err, "Failed to add missing items to shopping list")
+			return
+		}
+		resp.ShoppingList = &list
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Cook a recipe
+// @Description Deducts the recipe's ingredients, scaled to the requested servings, from the authenticated user's items in one transaction. Items that reach zero are removed. Returns the pantry changes and any shortfalls.
+// @Tags recipe
+// @Accept json
+// @Produce json
+// @Param id path int true "Recipe ID"
+// @Param request body dtos.CookRecipeRequest true "Servings to cook"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.CookRecipeResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /recipe/{id}/cook [post]
+func (h *RecipeHandler) CookRecipeHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid recipe ID"})
+		return
+	}
+
+	var req dtos.CookRecipeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Servings < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request data"})
+		return
+	}
+
+	resp, err := h.UserItemRepo.CookRecipe(uint(id), owner, req.Servings)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Recipe not found"})
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to cook recipe"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Suggest recipes for the user's pantry
+// @Description Ranks recipes by how much of their ingredients the authenticated user's items cover, then by the number of missing ingredients. The user's diet preference applies unless diet is given.
+// @Tags recipe
+// @Produce json
+// @Param diet query string false "Diet type, overrides the user's preference"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.RecipeSuggestionsResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /recipe/suggest [get]
+func (h *RecipeHandler) SuggestRecipesHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	query := dtos.RecipeSuggestQuery{
+		Diet:     r.URL.Query().Get("diet"),
+		Page:     1,
+		PageSize: 10,
+	}
+
+	if page := r.URL.Query().Get("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid page"})
+			return
+		}
+		query.Page = n
+	}
+
+	if pageSize := r.URL.Query().Get("page_size"); pageSize != "" {
+		n, err := strconv.Atoi(pageSize)
+		if err != nil || n < 1 || n > 50 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid page size"})
+			return
+		}
+		query.PageSize = n
+	}
+
+	suggestions, err := h.Repo.SuggestRecipes(query, owner)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to suggest recipes"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suggestions)
+}
@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+)
+
+type ShoppingListHandler struct {
+	Repo          repository.ShoppingListRepository
+	HouseholdRepo repository.HouseholdRepository
+}
+
+func NewShoppingListHandler(repo repository.ShoppingListRepository, householdRepo repository.HouseholdRepository) *ShoppingListHandler {
+	return &ShoppingListHandler{Repo: repo, HouseholdRepo: householdRepo}
+}
+
+// writeShoppingListError maps a repository error to a 404 or a 500 response
+func writeShoppingListError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Shopping list or item not found"})
+		return
+	} else if errors.Is(err, repository.ErrShoppingListUnitConflict) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(dtos.ConflictResponse{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: message})
+}
+
+// @Summary Get all user's shopping lists
+// @Description Get all shopping lists of the authenticated user
+// @Tags shopping_list
+// @Produce json
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.ShoppingListsResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /shopping_list [get]
+func (h *ShoppingListHandler) GetAllShoppingListsHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	lists, err := h.Repo.GetAllShoppingLists(owner)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get shopping lists"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lists)
+}
+
+// @Summary Get a shopping list
+// @Description Get a shopping list of the authenticated user by ID
+// @Tags shopping_list
+// @Produce json
+// @Param id path int true "Shopping List ID"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.ShoppingListResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /shopping_list/{id} [get]
+func (h *ShoppingListHandler) GetShoppingListHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	listID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid shopping list ID"})
+		return
+	}
+
+	list, err := h.Repo.GetShoppingList(uint(listID), owner)
+	if err != nil {
+		writeShoppingListError(w, err, "Failed to get shopping list")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// @Summary Create a shopping list
+// @Description Create a new shopping list for the authenticated user
+// @Tags shopping_list
+// @Accept json
+// @Produce json
+// @Param shoppingList body dtos.ShoppingListRequest true "Create Shopping List"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 201 {object} dtos.ShoppingListResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /shopping_list [post]
+func (h *ShoppingListHandler) CreateShoppingListHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	var req dtos.ShoppingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
+
+	list, err := h.Repo.CreateShoppingList(req, owner)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to create shopping list"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(list)
+}
+
+// @Summary Update a shopping list
+// @Description Rename a shopping list of the authenticated user
+// @Tags shopping_list
+// @Accept json
+// @Produce json
+// @Param id path int true "Shopping List ID"
+// @Param shoppingList body dtos.ShoppingListRequest true "Update Shopping List"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.ShoppingListResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /shopping_list/{id} [put]
+func (h *ShoppingListHandler) UpdateShoppingListHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	listID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid shopping list ID"})
+		return
+	}
+
+	var req dtos.ShoppingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
+
+	list, err := h.Repo.UpdateShoppingList(req, uint(listID), owner)
+	if err != nil {
+		writeShoppingListError(w, err, "Failed to update shopping list")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// @Summary Delete a shopping list
+// @Description Delete a shopping list of the authenticated user
+// @Tags shopping_list
+// @Param id path int true "Shopping List ID"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 204
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /shopping_list/{id} [delete]
+func (h *ShoppingListHandler) DeleteShoppingListHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	listID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid shopping list ID"})
+		return
+	}
+
+	if err := h.Repo.DeleteShoppingList(uint(listID), owner); err != nil {
+		writeShoppingListError(w, err, "Failed to delete shopping list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Add an item to a shopping list
+// @Description Add an item to a shopping list; the amount is increased if the item is already on the list, and the request fails with 409 if its unit cannot be converted to the listed one
+// @Tags shopping_list
+// @Accept json
+// @Produce json
+// @Param id path int true "Shopping List ID"
+// @Param shoppingListItem body dtos.ShoppingListItemRequest true "Add Shopping List Item"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.ShoppingListResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /shopping_list/{id}/item [post]
+func (h *ShoppingListHandler) AddShoppingListItemHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	listID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid shopping list ID"})
+		return
+	}
+
+	var req dtos.ShoppingListItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
+
+	list, err := h.Repo.AddShoppingListItem(req, uint(listID), owner)
+	if err != nil {
+		writeShoppingListError(w, err, "Failed to add shopping list item")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// @Summary Update an item of a shopping list
+// @Description Update the amount and unit of an item on a shopping list
+// @Tags shopping_list
+// @Accept json
+// @Produce json
+// @Param id path int true "Shopping List ID"
+// @Param item_id path int true "Item ID"
+// @Param shoppingListItem body dtos.ShoppingListItemRequest true "Update Shopping List Item"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.ShoppingListResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /shopping_list/{id}/item/{item_id} [put]
+func (h *ShoppingListHandler) UpdateShoppingListItemHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	listID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid shopping list ID"})
+		return
+	}
+
+	itemID, err := strconv.ParseUint(chi.URLParam(r, "item_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid item ID"})
+		return
+	}
+
+	var req dtos.ShoppingListItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
+
+	list, err := h.Repo.UpdateShoppingListItem(req, uint(listID), uint(itemID), owner)
+	if err != nil {
+		writeShoppingListError(w, err, "Failed to update shopping list item")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// @Summary Check off an item of a shopping list
+// @Description Mark an item on a shopping list as purchased or not purchased
+// @Tags shopping_list
+// @Accept json
+// @Produce json
+// @Param id path int true "Shopping List ID"
+// @Param item_id path int true "Item ID"
+// @Param check body dtos.ShoppingListItemCheckRequest true "Check Shopping List Item"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.ShoppingListResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /shopping_list/{id}/item/{item_id}/check [put]
+func (h *ShoppingListHandler) CheckShoppingListItemHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	listID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid shopping list ID"})
+		return
+	}
+
+	itemID, err := strconv.ParseUint(chi.URLParam(r, "item_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid item ID"})
+		return
+	}
+
+	var req dtos.ShoppingListItemCheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
+
+	list, err := h.Repo.CheckShoppingListItem(req, uint(listID), uint(itemID), owner)
+	if err != nil {
+		writeShoppingListError(w, err, "Failed to check shopping list item")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// @Summary Remove an item from a shopping list
+// @Description Remove an item from a shopping list of the authenticated user
+// @Tags shopping_list
+// @Param id path int true "Shopping List ID"
+// @Param item_id path int true "Item ID"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 204
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /shopping_list/{id}/item/{item_id} [delete]
+func (h *ShoppingListHandler) DeleteShoppingListItemHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	listID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid shopping list ID"})
+		return
+	}
+
+	itemID, err := strconv.ParseUint(chi.URLParam(r, "item_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid item ID"})
+		return
+	}
+
+	if err := h.Repo.DeleteShoppingListItem(uint(listID), uint(itemID), owner); err != nil {
+		writeShoppingListError(w, err, "Failed to delete shopping list item")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Move purchased items into the pantry
+// @Description Adds every checked item of a shopping list to the authenticated user's items and removes it from the list
+// @Tags shopping_list
+// @Produce json
+// @Param id path int true "Shopping List ID"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.UserItemsResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /shopping_list/{id}/purchase [post]
+func (h *ShoppingListHandler) MovePurchasedItemsHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	listID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid shopping list ID"})
+		return
+	}
+
+	userItems, err := h.Repo.MovePurchasedItems(uint(listID), owner)
+	if err != nil {
+		writeShoppingListError(w, err, "Failed to move purchased items")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userItems)
+}
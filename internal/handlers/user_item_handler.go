@@ -1,40 +1,48 @@
 package handlers
 
 import (
-	"bytes"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
-	"mime/multipart"
 	"net/http"
-	"os"
 	"strconv"
+	"time"
 
+	"github.com/GroceryTrak/GroceryTrakService/internal/detector"
 	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
-	"github.com/GroceryTrak/GroceryTrakService/internal/middlewares"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"github.com/GroceryTrak/GroceryTrakService/internal/productdb"
 	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
+	"github.com/GroceryTrak/GroceryTrakService/internal/shelflife"
+	"github.com/GroceryTrak/GroceryTrakService/internal/utils"
 	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
 )
 
 type UserItemHandler struct {
-	Repo repository.UserItemRepository
+	Repo          repository.UserItemRepository
+	HouseholdRepo repository.HouseholdRepository
 }
 
-func NewUserItemHandler(repo repository.UserItemRepository) *UserItemHandler {
-	return &UserItemHandler{Repo: repo}
+func NewUserItemHandler(repo repository.UserItemRepository, householdRepo repository.HouseholdRepository) *UserItemHandler {
+	return &UserItemHandler{Repo: repo, HouseholdRepo: householdRepo}
 }
 
 // @Summary Get all user's items
 // @Description Get all items for the authenticated user
 // @Tags user_item
 // @Produce json
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
 // @Success 200 {object} dtos.UserItemsResponse
 // @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
 // @Router /user_item [get]
 func (h *UserItemHandler) GetAllUserItemsHandler(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middlewares.IDKey).(uint)
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
 
-	userItems, err := h.Repo.GetAllUserItems(userID)
+	userItems, err := h.Repo.GetAllUserItems(owner)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get all user items"})
@@ -50,11 +58,15 @@ func (h *UserItemHandler) GetAllUserItemsHandler(w http.ResponseWriter, r *http.
 // @Tags user_item
 // @Produce json
 // @Param item_id path int true "Item ID"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
 // @Success 200 {object} dtos.UserItemResponse
 // @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
 // @Router /user_item/{item_id} [get]
 func (h *UserItemHandler) GetUserItemHandler(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middlewares.IDKey).(uint)
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
 
 	itemID, err := strconv.ParseUint(chi.URLParam(r, "item_id"), 10, 32)
 	if err != nil {
@@ -63,7 +75,7 @@ func (h *UserItemHandler) GetUserItemHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	userItem, err := h.Repo.GetUserItem(uint(itemID), userID)
+	userItem, err := h.Repo.GetUserItem(uint(itemID), owner)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Failed to get user item"})
@@ -80,11 +92,15 @@ func (h *UserItemHandler) GetUserItemHandler(w http.ResponseWriter, r *http.Requ
 // @Accept json
 // @Produce json
 // @Param userItem body dtos.UserItemRequest true "Create User Item"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
 // @Success 201 {object} dtos.UserItemResponse
 // @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
 // @Router /user_item [post]
 func (h *UserItemHandler) CreateUserItemHandler(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middlewares.IDKey).(uint)
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
 
 	var req dtos.UserItemRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -93,7 +109,19 @@ func (h *UserItemHandler) CreateUserItemHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	userItem, err := h.Repo.CreateUserItem(req, userID)
+	if req.Location != "" && !shelflife.IsValidLocation(models.StorageLocation(req.Location)) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid storage location"})
+		return
+	}
+
+	if req.Price != nil && *req.Price < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Price cannot be negative"})
+		return
+	}
+
+	userItem, err := h.Repo.CreateUserItem(req, owner)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to create user item"})
@@ -106,17 +134,21 @@ func (h *UserItemHandler) CreateUserItemHandler(w http.ResponseWriter, r *http.R
 }
 
 // @Summary Update a user_item for the authenticated user
-// @Description Update a user_item for the authenticated user
+// @Description Set the total amount of a user_item for the authenticated user. A price is recorded as a purchase of the amount added and is refused when the amount does not go up.
 // @Tags user_item
 // @Accept json
 // @Produce json
 // @Param item_id path int true "Item ID"
 // @Param userItem body dtos.UserItemRequest true "Update User Item"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
 // @Success 200 {object} dtos.UserItemResponse
 // @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
 // @Router /user_item/{item_id} [put]
 func (h *UserItemHandler) UpdateUserItemHandler(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middlewares.IDKey).(uint)
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
 
 	itemID, err := strconv.ParseUint(chi.URLParam(r, "item_id"), 10, 32)
 	if err != nil {
@@ -132,8 +164,28 @@ func (h *UserItemHandler) UpdateUserItemHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	userItem, err := h.Repo.UpdateUserItem(req, uint(itemID), userID)
-	if err != nil {
+	if req.Location != "" && !shelflife.IsValidLocation(models.StorageLocation(req.Location)) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid storage location"})
+		return
+	}
+
+	if req.Price != nil && *req.Price < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Price cannot be negative"})
+		return
+	}
+
+	userItem, err := h.Repo.UpdateUserItem(req, uint(itemID), owner)
+	if err == gorm.ErrRecordNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "User item not found"})
+		return
+	} else if errors.Is(err, repository.ErrPriceWithoutAdded) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "A price can only be given when the amount goes up"})
+		return
+	} else if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to update user item"})
 		return
@@ -147,11 +199,15 @@ func (h *UserItemHandler) UpdateUserItemHandler(w http.ResponseWriter, r *http.R
 // @Description Delete a user_item for the authenticated user
 // @Tags user_item
 // @Param item_id path int true "Item ID"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
 // @Success 204
 // @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
 // @Router /user_item/{item_id} [delete]
 func (h *UserItemHandler) DeleteUserItemHandler(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middlewares.IDKey).(uint)
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
 
 	itemID, err := strconv.ParseUint(chi.URLParam(r, "item_id"), 10, 32)
 	if err != nil {
@@ -160,7 +216,7 @@ func (h *UserItemHandler) DeleteUserItemHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	err = h.Repo.DeleteUserItem(uint(itemID), userID)
+	err = h.Repo.DeleteUserItem(uint(itemID), owner)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to delete user item"})
@@ -170,21 +226,272 @@ func (h *UserItemHandler) DeleteUserItemHandler(w http.ResponseWriter, r *http.R
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// @Summary Discard a user_item
+// @Description Throw away an amount of an item, or all of it when no amount is given, for a reason: expired, spoiled or leftover. The lots that expire first go first. Unlike a delete, this counts as food waste in the waste report.
+// @Tags user_item
+// @Accept json
+// @Produce json
+// @Param item_id path int true "Item ID"
+// @Param discard body dtos.DiscardRequest true "What to throw away and why"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.DiscardResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /user_item/{item_id}/discard [post]
+func (h *UserItemHandler) DiscardUserItemHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	itemID, err := strconv.ParseUint(chi.URLParam(r, "item_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid item ID"})
+		return
+	}
+
+	var req dtos.DiscardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
+
+	switch models.WasteReason(req.Reason) {
+	case models.ExpiredReason, models.SpoiledReason, models.LeftoverReason:
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "reason must be expired, spoiled or leftover"})
+		return
+	}
+
+	if req.Amount != nil && *req.Amount <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Amount must be positive"})
+		return
+	}
+
+	discarded, err := h.Repo.DiscardUserItem(req, uint(itemID), owner)
+	if err == gorm.ErrRecordNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "User item not found"})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to discard user item"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(discarded)
+}
+
+// @Summary Update a single lot of a user_item
+// @Description Replace the amount and unit of one lot; an amount of zero removes the lot
+// @Tags user_item
+// @Accept json
+// @Produce json
+// @Param lot_id path int true "Lot ID"
+// @Param lot body dtos.UserItemLotRequest true "Update Lot"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.UserItemResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /user_item/lot/{lot_id} [put]
+func (h *UserItemHandler) UpdateUserItemLotHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	lotID, err := strconv.ParseUint(chi.URLParam(r, "lot_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid lot ID"})
+		return
+	}
+
+	var req dtos.UserItemLotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
+
+	if req.Location != "" && !shelflife.IsValidLocation(models.StorageLocation(req.Location)) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid storage location"})
+		return
+	}
+
+	userItem, err := h.Repo.UpdateUserItemLot(req, uint(lotID), owner)
+	if err == gorm.ErrRecordNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Lot not found"})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to update lot"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userItem)
+}
+
+// @Summary Delete a single lot of a user_item
+// @Description Delete one lot, leaving the other lots of the item in the pantry
+// @Tags user_item
+// @Param lot_id path int true "Lot ID"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 204
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /user_item/lot/{lot_id} [delete]
+func (h *UserItemHandler) DeleteUserItemLotHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	lotID, err := strconv.ParseUint(chi.URLParam(r, "lot_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid lot ID"})
+		return
+	}
+
+	err = h.Repo.DeleteUserItemLot(uint(lotID), owner)
+	if err == gorm.ErrRecordNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Lot not found"})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to delete lot"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get the history of a user item
+// @Description Lists every change to the pantry's lots of an item, newest first: what was added, consumed, adjusted, discarded or thrown out expired, by whom and through which feature. Lots that have since been used up or deleted are included.
+// @Tags user_item
+// @Accept json
+// @Produce json
+// @Param item_id path int true "Item ID"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.PantryEventsResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /user_item/{item_id}/history [get]
+func (h *UserItemHandler) GetUserItemHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	itemID, err := strconv.ParseUint(chi.URLParam(r, "item_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid user item ID"})
+		return
+	}
+
+	history, err := h.Repo.GetUserItemHistory(uint(itemID), owner)
+	if err == gorm.ErrRecordNotFound {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Item not found"})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get user item history"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// @Summary Get a waste report
+// @Description Sum the food the authenticated user threw away in a period, month by month: how often, why, the estimated cost and the items wasted most. Costs are estimated from the prices paid for each item. Deleted items only count when they had expired.
+// @Tags user_item
+// @Produce json
+// @Param from query string false "First day, defaults to the first of the month five months ago"
+// @Param to query string false "Last day, defaults to today"
+// @Param limit query int false "Most-wasted items listed per month" default(5)
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.WasteReportResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /report/waste [get]
+func (h *UserItemHandler) GetWasteReportHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	query := dtos.WasteReportQuery{
+		From:  today.AddDate(0, -5, 1-today.Day()),
+		To:    today,
+		Limit: 5,
+	}
+	for param, date := range map[string]*time.Time{"from": &query.From, "to": &query.To} {
+		if value := r.URL.Query().Get(param); value != "" {
+			t, err := utils.ParseDate(value)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid " + param + " date"})
+				return
+			}
+			*date = t
+		}
+	}
+	if query.To.Before(query.From) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "from must not be after to"})
+		return
+	}
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "limit must be a positive number"})
+			return
+		}
+		query.Limit = limit
+	}
+
+	report, err := h.Repo.GetWasteReport(query, owner)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get waste report"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
 // @Summary Search user items
 // @Description Searches for user items by name
 // @Tags user_item
 // @Accept json
 // @Produce json
 // @Param name query string false "Name of user item"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
 // @Success 200 {object} dtos.UserItemsResponse
 // @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
 // @Router /user_item/search [get]
 func (h *UserItemHandler) SearchUserItemsHandler(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middlewares.IDKey).(uint)
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
 
 	query := dtos.UserItemQuery{}
 	query.Name = r.URL.Query().Get("name")
-	userItems, err := h.Repo.SearchUserItems(query, userID)
+	userItems, err := h.Repo.SearchUserItems(query, owner)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to search user items"})
@@ -195,37 +502,58 @@ func (h *UserItemHandler) SearchUserItemsHandler(w http.ResponseWriter, r *http.
 	json.NewEncoder(w).Encode(userItems)
 }
 
-// @Summary Detect items from an uploaded image
-// @Description Detect items from an uploaded image for the authenticated user using OpenAI's vision model
+// @Summary Get user's items about to expire
+// @Description Get the authenticated user's items that expire within the given period, including already expired ones, soonest first
 // @Tags user_item
-// @Accept multipart/form-data
 // @Produce json
-// @Param image formData file true "Image file"
-// @Success 200 {array} dtos.UserItemsResponse
+// @Param within query string false "Period such as 3d, 1w or 12h" default(3d)
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.UserItemsResponse
 // @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
-// @Router /user_item/detect [post]
-func (h *UserItemHandler) DetectUserItemsHandler(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middlewares.IDKey).(uint)
+// @Router /user_item/expiring [get]
+func (h *UserItemHandler) GetExpiringUserItemsHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
 
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
+	within := 3 * 24 * time.Hour
+	if param := r.URL.Query().Get("within"); param != "" {
+		d, err := utils.ParseDuration(param)
+		if err != nil || d < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid within period"})
+			return
+		}
+		within = d
+	}
+
+	userItems, err := h.Repo.GetExpiringUserItems(within, owner)
+	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "OPENAI_API_KEY is not set"})
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get expiring user items"})
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userItems)
+}
+
+// readImage reads the image file uploaded as the "image" form field, writing a 400 or 500
+// response when there is none
+func readImage(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
 	err := r.ParseMultipartForm(10 << 20) // 10 MB max file size
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Failed to parse form"})
-		return
+		return nil, false
 	}
 
 	file, _, err := r.FormFile("image")
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Image file is required"})
-		return
+		return nil, false
 	}
 	defer file.Close()
 
@@ -233,115 +561,296 @@ func (h *UserItemHandler) DetectUserItemsHandler(w http.ResponseWriter, r *http.
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to read image"})
+		return nil, false
+	}
+
+	return fileBytes, true
+}
+
+// detectUserItems reads the uploaded image and runs the configured detector on it; the detect
+// and predict endpoints both go through it. With draft=true the results are stored as a pending
+// scan for review, otherwise they are added to the selected pantry right away. source is the
+// endpoint, for the pantry log.
+func (h *UserItemHandler) detectUserItems(w http.ResponseWriter, r *http.Request, source models.PantryEventSource) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
 		return
 	}
 
-	userItems, err := h.Repo.DetectUserItems(fileBytes, userID, apiKey)
-	if err != nil {
+	draft, _ := strconv.ParseBool(r.URL.Query().Get("draft"))
+
+	fileBytes, ok := readImage(w, r)
+	if !ok {
+		return
+	}
+
+	var result interface{}
+	var err error
+	status := http.StatusOK
+	if draft {
+		result, err = h.Repo.ScanUserItems(r.Context(), fileBytes, source, owner)
+		status = http.StatusCreated
+	} else {
+		result, err = h.Repo.DetectUserItems(r.Context(), fileBytes, source, owner)
+	}
+	if errors.Is(err, detector.ErrNotConfigured) {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: err.Error()})
 		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to detect items"})
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(userItems)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
+
+// @Summary Detect items from an uploaded image
+// @Description Detect items and their amounts in an uploaded image with the configured detector and add them to the authenticated user's items. With draft=true nothing is added; the results are returned as a pending scan to review and commit.
+// @Tags user_item
+// @Accept multipart/form-data
+// @Produce json
+// @Param image formData file true "Image file"
+// @Param draft query bool false "Store the results as a pending scan instead of adding them"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.UserItemsResponse
+// @Success 201 {object} dtos.ScanResponse "Pending scan, when draft is set"
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /user_item/detect [post]
+func (h *UserItemHandler) DetectUserItemsHandler(w http.ResponseWriter, r *http.Request) {
+	h.detectUserItems(w, r, models.DetectSource)
 }
 
 // @Summary Predict items from an uploaded image
-// @Description Predict items from an uploaded image for the authenticated user
+// @Description Same as /user_item/detect, kept for older clients
 // @Tags user_item
 // @Accept multipart/form-data
 // @Produce json
 // @Param image formData file true "Image file"
+// @Param draft query bool false "Store the results as a pending scan instead of adding them"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
 // @Success 200 {object} dtos.UserItemsResponse
+// @Success 201 {object} dtos.ScanResponse "Pending scan, when draft is set"
 // @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
 // @Router /user_item/predict [post]
 func (h *UserItemHandler) PredictUserItemsHandler(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(middlewares.IDKey).(uint)
+	h.detectUserItems(w, r, models.PredictSource)
+}
 
-	err := r.ParseMultipartForm(10 << 20)
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Failed to parse form"})
+// @Summary Import a grocery receipt
+// @Description Read a photo of a grocery receipt and record it as a purchase with its store, date, lines and prices. Lines matching a known item are added to the authenticated user's items, bought on the receipt's date; the others are returned as a pending scan to review and commit.
+// @Tags user_item
+// @Accept multipart/form-data
+// @Produce json
+// @Param image formData file true "Receipt image"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 201 {object} dtos.ReceiptResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /user_item/receipt [post]
+func (h *UserItemHandler) ImportReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
 		return
 	}
 
-	file, _, err := r.FormFile("image")
-	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Image file is required"})
+	fileBytes, ok := readImage(w, r)
+	if !ok {
 		return
 	}
-	defer file.Close()
 
-	fileBytes, err := io.ReadAll(file)
-	if err != nil {
+	receipt, err := h.Repo.ImportReceipt(r.Context(), fileBytes, owner)
+	if errors.Is(err, detector.ErrNotConfigured) || errors.Is(err, detector.ErrReceiptsNotSupported) {
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to read image"})
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: err.Error()})
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to import receipt"})
 		return
 	}
 
-	detectDomain := os.Getenv("HUGGINGFACE_URL")
-	if detectDomain == "" {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "HUGGINGFACE_URL is not set"})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(receipt)
+}
+
+// @Summary Add a scanned package
+// @Description Resolve an EAN/UPC barcode to an item and add a package of it to the authenticated user's items. Codes not in the catalog are looked up in the product database; item_id links a code the database does not know.
+// @Tags user_item
+// @Accept json
+// @Produce json
+// @Param barcode body dtos.BarcodeRequest true "Scanned barcode"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 201 {object} dtos.UserItemResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /user_item/barcode [post]
+func (h *UserItemHandler) AddBarcodeUserItemHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
 		return
 	}
 
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
+	var req dtos.BarcodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
+		return
+	}
 
-	filePart, err := writer.CreateFormFile("image", "upload.png")
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to create form file"})
+	if req.Location != "" && !shelflife.IsValidLocation(models.StorageLocation(req.Location)) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid storage location"})
 		return
 	}
 
-	if _, err := filePart.Write(fileBytes); err != nil {
+	userItem, err := h.Repo.AddBarcodeUserItem(r.Context(), req, owner)
+	switch {
+	case errors.Is(err, productdb.ErrInvalidBarcode):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid barcode"})
+		return
+	case errors.Is(err, repository.ErrUnknownBarcode):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Unknown barcode, send item_id to link it to an item"})
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Item not found"})
+		return
+	case err != nil:
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to write file data"})
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to add scanned item"})
 		return
 	}
 
-	writer.Close()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(userItem)
+}
 
-	predictURL := fmt.Sprintf("%s/predict", detectDomain)
-	req, err := http.NewRequest("POST", predictURL, &buf)
-	if err != nil {
+// writeScanError maps a repository error to a 400, 404, 409 or 500 response
+func writeScanError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(dtos.NotFoundResponse{Error: "Scan or item not found"})
+	case errors.Is(err, repository.ErrInvalidScanDecision):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: err.Error()})
+	case errors.Is(err, repository.ErrScanNotPending):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(dtos.ConflictResponse{Error: err.Error()})
+	default:
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to create request"})
+		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: message})
+	}
+}
+
+func parseScanID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	scanID, err := strconv.ParseUint(chi.URLParam(r, "scan_id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid scan ID"})
+		return 0, false
+	}
+	return uint(scanID), true
+}
+
+// @Summary Get a scan
+// @Description Get a scan created by a draft detection, with the detected lines and their confidences
+// @Tags user_item
+// @Produce json
+// @Param scan_id path int true "Scan ID"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.ScanResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /user_item/scan/{scan_id} [get]
+func (h *UserItemHandler) GetScanHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
 		return
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	scanID, ok := parseScanID(w, r)
+	if !ok {
+		return
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	scan, err := h.Repo.GetScan(scanID, owner)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to get prediction"})
+		writeScanError(w, err, "Failed to get scan")
 		return
 	}
-	defer resp.Body.Close()
 
-	var response struct {
-		Items          []string `json:"items"`
-		AnnotatedImage string   `json:"annotated_image"`
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scan)
+}
+
+// @Summary Commit a reviewed scan
+// @Description Accept, edit or reject each line of a pending scan. Accepted and edited lines are added to the pantry; lines without a decision are rejected.
+// @Tags user_item
+// @Accept json
+// @Produce json
+// @Param scan_id path int true "Scan ID"
+// @Param review body dtos.ScanCommitRequest true "Line decisions"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 200 {object} dtos.UserItemsResponse
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /user_item/scan/{scan_id}/commit [post]
+func (h *UserItemHandler) CommitScanHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to parse prediction response"})
+
+	scanID, ok := parseScanID(w, r)
+	if !ok {
+		return
+	}
+
+	var req dtos.ScanCommitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(dtos.BadRequestResponse{Error: "Invalid request payload"})
 		return
 	}
 
-	result, err := h.Repo.PredictUserItems(response.Items, userID)
+	userItems, err := h.Repo.CommitScan(r.Context(), req, scanID, owner)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(dtos.InternalServerErrorResponse{Error: "Failed to process prediction results"})
+		writeScanError(w, err, "Failed to commit scan")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	json.NewEncoder(w).Encode(userItems)
+}
+
+// @Summary Discard a scan
+// @Description Discard a pending scan without adding anything to the pantry
+// @Tags user_item
+// @Param scan_id path int true "Scan ID"
+// @Param X-Household-ID header int false "Household whose shared pantry to use instead of the user's own"
+// @Success 204
+// @Failure default {object} dtos.ErrorResponse "Standard Error Responses"
+// @Router /user_item/scan/{scan_id} [delete]
+func (h *UserItemHandler) DiscardScanHandler(w http.ResponseWriter, r *http.Request) {
+	owner, ok := resolveOwner(w, r, h.HouseholdRepo)
+	if !ok {
+		return
+	}
+
+	scanID, ok := parseScanID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Repo.DiscardScan(scanID, owner); err != nil {
+		writeScanError(w, err, "Failed to discard scan")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
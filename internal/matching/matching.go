@@ -0,0 +1,67 @@
+package matching
+
+import (
+	"strings"
+	"unicode"
+)
+
+// DefaultThreshold is the lowest similarity at which a detected name is taken to be a known
+// item. "apple" against "pineapple" scores well below it.
+const DefaultThreshold = 0.6
+
+// DefaultDuplicateThreshold is the lowest similarity at which two catalog items are reported as
+// possible duplicates. It is looser than DefaultThreshold since an admin reviews every candidate:
+// "milk" and "whole milk" score about 0.45.
+const DefaultDuplicateThreshold = 0.4
+
+// uncountable words end in s without being plurals
+var uncountable = map[string]bool{
+	"asparagus": true, "couscous": true, "hummus": true, "molasses": true, "swiss": true,
+	"citrus": true, "lettuce": true, "grits": true, "series": true, "species": true,
+}
+
+// Normalize turns a free-form item name into the form names are compared in: lower case,
+// punctuation dropped, whitespace collapsed and the last word made singular, so that
+// " Green  Apples!" and "green apple" are the same name.
+func Normalize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '\'':
+			// "baker's yeast" and "bakers yeast" alike
+		default:
+			b.WriteRune(' ')
+		}
+	}
+
+	words := strings.Fields(b.String())
+	if len(words) == 0 {
+		return ""
+	}
+	words[len(words)-1] = Singular(words[len(words)-1])
+	return strings.Join(words, " ")
+}
+
+// Singular strips the common English plural endings from a lower case word
+func Singular(word string) string {
+	if len(word) <= 3 || uncountable[word] {
+		return word
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
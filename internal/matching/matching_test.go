@@ -0,0 +1,27 @@
+package matching
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		" Green  Apples!":   "green apple",
+		"green apple":       "green apple",
+		"Baker's Yeast":     "bakers yeast",
+		"Cherry-Tomatoes":   "cherry tomato",
+		"BERRIES":           "berry",
+		"Peaches":           "peach",
+		"boxes":             "box",
+		"Swiss":             "swiss",
+		"Asparagus":         "asparagus",
+		"glass":             "glass",
+		"Tomatoes (canned)": "tomatoes canned",
+		"peas":              "pea",
+		"2% Milk":           "2 milk",
+		"  ":                "",
+	}
+	for name, want := range tests {
+		if got := Normalize(name); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
 	"github.com/GroceryTrak/GroceryTrakService/internal/utils"
 )
 
@@ -46,6 +47,29 @@ func AuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// RequireRole only lets through requests whose authenticated role is one of roles.
+// It must be mounted after AuthMiddleware.
+func RequireRole(roles ...models.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role := models.Role(GetRoleFromContext(r))
+			if role == "" {
+				http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
+				return
+			}
+
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		})
+	}
+}
+
 func GetUserIDFromContext(r *http.Request) uint {
 	if userID, ok := r.Context().Value(IDKey).(uint); ok {
 		return userID
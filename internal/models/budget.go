@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Budget caps what is spent on groceries per calendar month, overall when Category is empty or
+// on one item category. Budgets with a HouseholdID apply to that household's purchases.
+type Budget struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint      `gorm:"not null;index" json:"user_id"`
+	HouseholdID *uint     `gorm:"index" json:"household_id"`
+	Category    string    `gorm:"type:varchar(20);not null;default:''" json:"category"`
+	Amount      float64   `gorm:"type:numeric(10,2);not null" json:"amount"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Household *Household `gorm:"foreignKey:HouseholdID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
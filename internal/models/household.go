@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+type HouseholdRole string
+
+const (
+	HouseholdOwnerRole  HouseholdRole = "owner"
+	HouseholdMemberRole HouseholdRole = "member"
+)
+
+// Household groups several users around one shared pantry and set of shopping lists. New
+// members join with the household's invite code.
+type Household struct {
+	ID         uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name       string            `gorm:"type:varchar(100);not null" json:"name"`
+	InviteCode string            `gorm:"type:varchar(16);uniqueIndex;not null" json:"invite_code"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	Members    []HouseholdMember `gorm:"foreignKey:HouseholdID;constraint:OnDelete:CASCADE" json:"members"`
+}
+
+type HouseholdMember struct {
+	HouseholdID uint          `gorm:"primaryKey" json:"household_id"`
+	UserID      uint          `gorm:"primaryKey;index" json:"user_id"`
+	Role        HouseholdRole `gorm:"type:household_role;not null;default:'member'" json:"role"`
+	CreatedAt   time.Time     `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
@@ -1,9 +1,65 @@
 package models
 
+import (
+	"time"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/matching"
+	"gorm.io/gorm"
+)
+
+type ItemCategory string
+
+const (
+	ProduceCategory   ItemCategory = "produce"
+	DairyCategory     ItemCategory = "dairy"
+	MeatCategory      ItemCategory = "meat"
+	SeafoodCategory   ItemCategory = "seafood"
+	BakeryCategory    ItemCategory = "bakery"
+	FrozenCategory    ItemCategory = "frozen"
+	DryGoodsCategory  ItemCategory = "dry_goods"
+	BeverageCategory  ItemCategory = "beverage"
+	CondimentCategory ItemCategory = "condiment"
+	OtherCategory     ItemCategory = "other"
+)
+
+// EnrichmentStatus says whether an item has been filled in with an image, category and nutrients
+type EnrichmentStatus string
+
+const (
+	EnrichmentPending  EnrichmentStatus = "pending"
+	EnrichmentEnriched EnrichmentStatus = "enriched"
+	// EnrichmentFailed means the last attempt failed; it may be retried
+	EnrichmentFailed   EnrichmentStatus = "failed"
+	EnrichmentNotFound EnrichmentStatus = "not_found"
+)
+
+// EnrichmentSource is where an item's data came from. Only Spoonacular data is refreshed.
+type EnrichmentSource string
+
+const (
+	SpoonacularEnrichment EnrichmentSource = "spoonacular"
+	ManualEnrichment      EnrichmentSource = "manual"
+)
+
 type Item struct {
-	ID            uint           `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name          string         `gorm:"type:varchar(255);not null" json:"name"`
-	Image         string         `json:"image"`
-	SpoonacularID uint           `json:"spoonacular_id"`
-	Nutrients     []ItemNutrient `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"nutrients"`
+	ID               uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name             string            `gorm:"type:varchar(255);not null" json:"name"`
+	NormalizedName   string            `gorm:"type:varchar(255);not null;default:'';index" json:"-"` // Name as matching.Normalize puts it
+	Image            string            `json:"image"`
+	SpoonacularID    uint              `json:"spoonacular_id"`
+	Density          *float64          `json:"density"` // g/ml, used to convert between volume and mass
+	Category         ItemCategory      `gorm:"type:varchar(20);not null;default:'other'" json:"category"`
+	ShelfLifeDays    *int              `json:"shelf_life_days"` // overrides the category's default shelf life
+	EnrichmentStatus EnrichmentStatus  `gorm:"type:enrichment_status;not null;default:'pending'" json:"enrichment_status"`
+	EnrichmentSource *EnrichmentSource `gorm:"type:enrichment_source" json:"enrichment_source"`
+	EnrichedAt       *time.Time        `json:"enriched_at"`
+	Nutrients        []ItemNutrient    `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"nutrients"`
+	Barcodes         []ItemBarcode     `gorm:"foreignKey:ItemID" json:"barcodes"`
+}
+
+// BeforeSave keeps NormalizedName in step with Name, so that detected names can be matched to
+// items exactly
+func (item *Item) BeforeSave(tx *gorm.DB) error {
+	item.NormalizedName = matching.Normalize(item.Name)
+	return nil
 }
@@ -0,0 +1,11 @@
+package models
+
+// ItemAlias is another name an item is known by, such as "courgette" for zucchini. Aliases
+// are stored normalized so detections can be looked up directly.
+type ItemAlias struct {
+	ID     uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	ItemID uint   `gorm:"not null;index" json:"item_id"`
+	Alias  string `gorm:"type:varchar(100);uniqueIndex;not null" json:"alias"`
+
+	Item Item `gorm:"foreignKey:ItemID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
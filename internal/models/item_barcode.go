@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ItemBarcode links an EAN/UPC code, normalized to EAN-13 where possible, to the item it is a
+// package of. Amount and Unit are the package size when known, used as the default amount when
+// the code is scanned.
+type ItemBarcode struct {
+	Code      string    `gorm:"type:varchar(14);primaryKey" json:"code"`
+	ItemID    uint      `gorm:"not null;index" json:"item_id"`
+	Amount    float32   `json:"amount"`
+	Unit      string    `gorm:"type:varchar(20)" json:"unit"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Item Item `gorm:"foreignKey:ItemID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
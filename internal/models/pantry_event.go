@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+type PantryEventType string
+
+const (
+	AddedEvent     PantryEventType = "added"
+	ConsumedEvent  PantryEventType = "consumed"
+	AdjustedEvent  PantryEventType = "adjusted"
+	DiscardedEvent PantryEventType = "discarded"
+	ExpiredEvent   PantryEventType = "expired"
+)
+
+type PantryEventSource string
+
+const (
+	ManualSource       PantryEventSource = "manual"
+	DetectSource       PantryEventSource = "detect"
+	PredictSource      PantryEventSource = "predict"
+	CookSource         PantryEventSource = "cook"
+	ReceiptSource      PantryEventSource = "receipt"
+	BarcodeSource      PantryEventSource = "barcode"
+	ShoppingListSource PantryEventSource = "shopping_list"
+)
+
+// WasteReason says why something was thrown away
+type WasteReason string
+
+const (
+	ExpiredReason  WasteReason = "expired"
+	SpoiledReason  WasteReason = "spoiled"
+	LeftoverReason WasteReason = "leftover"
+)
+
+// PantryEvent records one change to a pantry lot. Events are only ever appended, so they keep
+// the history of lots that have since been used up or deleted. Amount is the change in Unit:
+// positive when something was added, negative when it was taken out. UserID is who made the
+// change; events with a HouseholdID belong to that household's pantry. Reason is set when food
+// was wasted rather than removed to correct the pantry.
+type PantryEvent struct {
+	ID          uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint              `gorm:"not null;index" json:"user_id"`
+	HouseholdID *uint             `gorm:"index" json:"household_id"`
+	ItemID      uint              `gorm:"not null;index" json:"item_id"`
+	LotID       uint              `gorm:"not null;index" json:"lot_id"`
+	Type        PantryEventType   `gorm:"type:pantry_event_type;not null" json:"type"`
+	Source      PantryEventSource `gorm:"type:pantry_event_source;not null" json:"source"`
+	Amount      float32           `json:"amount"`
+	Unit        string            `gorm:"type:varchar(20)" json:"unit"`
+	Reason      *WasteReason      `gorm:"type:waste_reason" json:"reason"`
+	CreatedAt   time.Time         `gorm:"index" json:"created_at"`
+
+	Item      Item       `gorm:"foreignKey:ItemID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Household *Household `gorm:"foreignKey:HouseholdID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
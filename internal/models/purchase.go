@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Purchase is one shopping trip, such as an imported receipt. Purchases with a HouseholdID were
+// made for that household; UserID then records who made them.
+type Purchase struct {
+	ID          uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint           `gorm:"not null;index" json:"user_id"`
+	HouseholdID *uint          `gorm:"index" json:"household_id"`
+	Store       string         `gorm:"type:varchar(100)" json:"store"`
+	PurchasedAt time.Time      `gorm:"not null;index" json:"purchased_at"`
+	Currency    string         `gorm:"type:varchar(3)" json:"currency"`
+	Total       float64        `gorm:"type:numeric(10,2)" json:"total"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Lines       []PurchaseLine `gorm:"foreignKey:PurchaseID;constraint:OnDelete:CASCADE" json:"lines"`
+
+	Household *Household `gorm:"foreignKey:HouseholdID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// PurchaseLine is one product bought. Price is what was paid for the whole line; ItemID is nil
+// while the line is not matched to a catalog item.
+type PurchaseLine struct {
+	ID         uint    `gorm:"primaryKey;autoIncrement" json:"id"`
+	PurchaseID uint    `gorm:"not null;index" json:"purchase_id"`
+	ItemID     *uint   `gorm:"index" json:"item_id"`
+	Name       string  `gorm:"type:varchar(255);not null" json:"name"`
+	Amount     float32 `json:"amount"`
+	Unit       string  `gorm:"type:varchar(20)" json:"unit"`
+	Price      float64 `gorm:"type:numeric(10,2)" json:"price"`
+
+	Item *Item `gorm:"foreignKey:ItemID;references:ID;constraint:OnDelete:SET NULL" json:"-"`
+}
@@ -10,9 +10,13 @@ const (
 	LowPriority     Priority = -1
 )
 
+// QueueItem is an enrichment job. Attempts counts the failed tries so far and LastError says why
+// the last one failed.
 type QueueItem struct {
 	ItemID    uint      `json:"item_id"`
 	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
 	Priority  Priority  `json:"priority"`
+	Attempts  int       `json:"attempts,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
 }
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+type ScanStatus string
+
+const (
+	ScanPending   ScanStatus = "pending"
+	ScanCommitted ScanStatus = "committed"
+	ScanDiscarded ScanStatus = "discarded"
+)
+
+// Scan holds the results of an image detection until the user has reviewed them. Nothing is
+// added to the pantry before the scan is committed. Source is the endpoint the image came from.
+type Scan struct {
+	ID          uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint              `gorm:"not null;index" json:"user_id"`
+	HouseholdID *uint             `gorm:"index" json:"household_id"`
+	Status      ScanStatus        `gorm:"type:scan_status;not null;default:'pending'" json:"status"`
+	Source      PantryEventSource `gorm:"type:pantry_event_source;not null;default:'detect'" json:"source"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Lines       []ScanLine        `gorm:"foreignKey:ScanID;constraint:OnDelete:CASCADE" json:"lines"`
+
+	Household *Household `gorm:"foreignKey:HouseholdID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+type BoundingBox struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// ScanLine is one detected item. ItemID is set when the name confidently matched a known item;
+// otherwise SuggestedItemID may hold the closest one, and committing the line as-is creates a
+// new item. PurchaseLineID links a receipt line to the purchase line it was recorded as, which
+// gets the item once the line is committed.
+type ScanLine struct {
+	ID              uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	ScanID          uint         `gorm:"not null;index" json:"scan_id"`
+	Name            string       `gorm:"type:varchar(100);not null" json:"name"`
+	ItemID          *uint        `json:"item_id"`
+	SuggestedItemID *uint        `json:"suggested_item_id"`
+	MatchScore      float32      `json:"match_score"`
+	Amount          float32      `json:"amount"`
+	Unit            string       `gorm:"type:varchar(20)" json:"unit"`
+	Confidence      float32      `json:"confidence"`
+	Box             *BoundingBox `gorm:"type:jsonb;serializer:json" json:"box"`
+	PurchaseLineID  *uint        `gorm:"index" json:"purchase_line_id"`
+
+	Item          *Item         `gorm:"foreignKey:ItemID;references:ID;constraint:OnDelete:SET NULL" json:"-"`
+	SuggestedItem *Item         `gorm:"foreignKey:SuggestedItemID;references:ID;constraint:OnDelete:SET NULL" json:"-"`
+	PurchaseLine  *PurchaseLine `gorm:"foreignKey:PurchaseLineID;references:ID;constraint:OnDelete:SET NULL" json:"-"`
+}
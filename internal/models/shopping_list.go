@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ShoppingList belongs to a user, or to a household when HouseholdID is set
+type ShoppingList struct {
+	ID          uint               `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint               `gorm:"not null;index" json:"user_id"`
+	HouseholdID *uint              `gorm:"index" json:"household_id"`
+	Name        string             `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	Items       []ShoppingListItem `gorm:"foreignKey:ShoppingListID;constraint:OnDelete:CASCADE" json:"items"`
+
+	Household *Household `gorm:"foreignKey:HouseholdID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
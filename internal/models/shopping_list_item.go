@@ -0,0 +1,11 @@
+package models
+
+type ShoppingListItem struct {
+	ShoppingListID uint    `gorm:"primaryKey" json:"shopping_list_id"`
+	ItemID         uint    `gorm:"primaryKey;constraint:OnDelete:CASCADE;" json:"item_id"`
+	Amount         float32 `json:"amount"`
+	Unit           string  `gorm:"type:varchar(20)" json:"unit"`
+	Checked        bool    `gorm:"not null;default:false" json:"checked"`
+
+	Item Item `gorm:"foreignKey:ItemID;references:ID"`
+}
@@ -1,8 +1,29 @@
 package models
 
+import "time"
+
+type StorageLocation string
+
+const (
+	PantryLocation  StorageLocation = "pantry"
+	FridgeLocation  StorageLocation = "fridge"
+	FreezerLocation StorageLocation = "freezer"
+)
+
+// UserItem is one lot of an item in a user's pantry. Every purchase is its own lot with its own
+// amount, unit and expiry; a user may hold several lots of the same item. Lots with a
+// HouseholdID belong to that household's shared pantry, UserID then records who added them.
 type UserItem struct {
-	UserID uint    `gorm:"primaryKey;constraint:OnDelete:CASCADE;" json:"user_id"`
-	ItemID uint    `gorm:"primaryKey;constraint:OnDelete:CASCADE;" json:"item_id"`
-	Amount float32 `json:"amount"`
-	Unit   string  `gorm:"type:varchar(20)" json:"unit"`
+	ID          uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint            `gorm:"not null;index:idx_user_items_user_item" json:"user_id"`
+	ItemID      uint            `gorm:"not null;index:idx_user_items_user_item" json:"item_id"`
+	HouseholdID *uint           `gorm:"index" json:"household_id"`
+	Amount      float32         `json:"amount"`
+	Unit        string          `gorm:"type:varchar(20)" json:"unit"`
+	PurchasedAt *time.Time      `json:"purchased_at"`
+	ExpiresAt   *time.Time      `gorm:"index" json:"expires_at"`
+	Location    StorageLocation `gorm:"type:storage_location;not null;default:'pantry'" json:"location"`
+
+	Item      Item       `gorm:"foreignKey:ItemID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	Household *Household `gorm:"foreignKey:HouseholdID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
 }
@@ -0,0 +1,61 @@
+package planner
+
+import (
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/units"
+)
+
+// ScaleFactor returns the multiplier to apply to recipe amounts to cook the requested servings
+func ScaleFactor(recipeServings, servings float32) float32 {
+	if recipeServings <= 0 || servings <= 0 {
+		return 1
+	}
+	return servings / recipeServings
+}
+
+// Density returns the item's density in g/ml, or 0 if it is unknown
+func Density(item dtos.ItemResponse) float64 {
+	if item.Density == nil {
+		return 0
+	}
+	return *item.Density
+}
+
+// MissingItems compares the recipe's ingredients, scaled to servings, against the pantry and
+// returns what still has to be bought. Pantry amounts are converted to the recipe's unit;
+// amounts that cannot be converted (e.g. a count against a mass without a density) are not counted.
+func MissingItems(recipe *dtos.RecipeResponse, pantry dtos.UserItemsResponse, servings float32) []dtos.MissingItemResponse {
+	available := make(map[uint]dtos.UserItemResponse, len(pantry.UserItems))
+	for _, userItem := range pantry.UserItems {
+		available[userItem.Item.ID] = userItem
+	}
+
+	factor := ScaleFactor(recipe.Servings, servings)
+
+	missingItems := []dtos.MissingItemResponse{}
+	for _, ingredient := range recipe.Ingredients {
+		required := ingredient.Amount * factor
+
+		var have float32
+		if userItem, ok := available[ingredient.Item.ID]; ok {
+			converted, err := units.Convert(float64(userItem.Amount), userItem.Unit, ingredient.Unit, Density(ingredient.Item))
+			if err == nil {
+				have = float32(converted)
+			}
+		}
+
+		if have >= required {
+			continue
+		}
+
+		missingItems = append(missingItems, dtos.MissingItemResponse{
+			Item:      ingredient.Item,
+			Required:  required,
+			Available: have,
+			Missing:   required - have,
+			Unit:      units.Canonical(ingredient.Unit),
+		})
+	}
+
+	return missingItems
+}
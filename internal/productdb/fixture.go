@@ -0,0 +1,54 @@
+package productdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// SampleProducts is what the fixture database holds when no fixture file is given
+var SampleProducts = []Product{
+	{Barcode: "3017620422003", Name: "Nutella", Brand: "Ferrero", Amount: 400, Unit: "g"},
+	{Barcode: "5449000000996", Name: "Coca-Cola", Brand: "Coca-Cola", Amount: 330, Unit: "ml"},
+	{Barcode: "0012000161155", Name: "Pepsi", Brand: "Pepsi", Amount: 355, Unit: "ml"},
+}
+
+// FixtureClient answers lookups from a fixed set of products without calling any service, for
+// tests and local development
+type FixtureClient struct {
+	Products map[string]Product
+}
+
+func NewFixtureClient(products ...Product) *FixtureClient {
+	c := &FixtureClient{Products: make(map[string]Product, len(products))}
+	for _, product := range products {
+		if code, err := Normalize(product.Barcode); err == nil {
+			product.Barcode = code
+		}
+		c.Products[product.Barcode] = product
+	}
+	return c
+}
+
+// LoadFixtureClient reads the products from a JSON array in the file at path
+func LoadFixtureClient(path string) (*FixtureClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read product fixture: %w", err)
+	}
+
+	var products []Product
+	if err := json.Unmarshal(data, &products); err != nil {
+		return nil, fmt.Errorf("failed to decode product fixture: %w", err)
+	}
+	return NewFixtureClient(products...), nil
+}
+
+func (c *FixtureClient) Lookup(ctx context.Context, barcode string) (Product, error) {
+	product, ok := c.Products[barcode]
+	if !ok {
+		return Product{}, ErrNotFound
+	}
+	return product, nil
+}
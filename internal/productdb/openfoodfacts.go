@@ -0,0 +1,94 @@
+package productdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const DefaultOpenFoodFactsURL = "https://world.openfoodfacts.org"
+
+// OpenFoodFactsClient looks products up in the Open Food Facts database
+type OpenFoodFactsClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewOpenFoodFactsClient(baseURL string) *OpenFoodFactsClient {
+	if baseURL == "" {
+		baseURL = DefaultOpenFoodFactsURL
+	}
+	return &OpenFoodFactsClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{},
+	}
+}
+
+func (c *OpenFoodFactsClient) Lookup(ctx context.Context, barcode string) (Product, error) {
+	url := fmt.Sprintf("%s/api/v2/product/%s.json?fields=product_name,generic_name,brands,image_front_url,product_quantity,product_quantity_unit", c.baseURL, barcode)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return Product{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	// Open Food Facts asks API users to identify themselves
+	req.Header.Set("User-Agent", "GroceryTrak/1.0 (grocerytrak@gmail.com)")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Product{}, fmt.Errorf("failed to look up product: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return Product{}, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Product{}, fmt.Errorf("product lookup failed with status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Status  int `json:"status"`
+		Product struct {
+			ProductName         string          `json:"product_name"`
+			GenericName         string          `json:"generic_name"`
+			Brands              string          `json:"brands"`
+			ImageFrontURL       string          `json:"image_front_url"`
+			ProductQuantity     json.RawMessage `json:"product_quantity"`
+			ProductQuantityUnit string          `json:"product_quantity_unit"`
+		} `json:"product"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return Product{}, fmt.Errorf("failed to decode product: %w", err)
+	}
+
+	product := response.Product
+	name := strings.TrimSpace(product.ProductName)
+	if name == "" {
+		name = strings.TrimSpace(product.GenericName)
+	}
+	if response.Status != 1 || name == "" {
+		return Product{}, ErrNotFound
+	}
+
+	result := Product{
+		Barcode: barcode,
+		Name:    name,
+		Brand:   strings.TrimSpace(strings.Split(product.Brands, ",")[0]),
+		Image:   product.ImageFrontURL,
+	}
+	// product_quantity is a number or a numeric string depending on the product
+	var amount json.Number
+	if json.Unmarshal([]byte(strings.Trim(string(product.ProductQuantity), `"`)), &amount) == nil {
+		if value, err := amount.Float64(); err == nil && value > 0 {
+			result.Amount = value
+			result.Unit = product.ProductQuantityUnit
+			if result.Unit == "" {
+				result.Unit = "g"
+			}
+		}
+	}
+
+	return result, nil
+}
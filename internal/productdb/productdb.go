@@ -0,0 +1,102 @@
+package productdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Product is what a product database knows about a barcode. Amount and Unit describe one
+// package and are zero when the database does not list a quantity.
+type Product struct {
+	Barcode string  `json:"barcode"`
+	Name    string  `json:"name"`
+	Brand   string  `json:"brand"`
+	Image   string  `json:"image"`
+	Amount  float64 `json:"amount"`
+	Unit    string  `json:"unit"`
+}
+
+// Client looks products up by barcode
+type Client interface {
+	Lookup(ctx context.Context, barcode string) (Product, error)
+}
+
+const (
+	OpenFoodFacts = "openfoodfacts"
+	Fixture       = "fixture"
+)
+
+var (
+	ErrNotConfigured  = errors.New("no product database is configured")
+	ErrNotFound       = errors.New("product not found")
+	ErrInvalidBarcode = errors.New("invalid barcode")
+)
+
+// Config selects and configures a product database. An empty Name picks Open Food Facts, which
+// needs no API key.
+type Config struct {
+	Name             string
+	OpenFoodFactsURL string
+	FixturePath      string
+}
+
+func New(cfg Config) (Client, error) {
+	switch cfg.Name {
+	case "", OpenFoodFacts:
+		return NewOpenFoodFactsClient(cfg.OpenFoodFactsURL), nil
+	case Fixture:
+		if cfg.FixturePath == "" {
+			return NewFixtureClient(SampleProducts...), nil
+		}
+		// Returned directly, a nil *FixtureClient would make a non-nil Client
+		client, err := LoadFixtureClient(cfg.FixturePath)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unknown product database %q", cfg.Name)
+	}
+}
+
+// Normalize validates an EAN-8, UPC-A, EAN-13 or GTIN-14 code by its check digit and returns it
+// in the form barcodes are stored in. UPC-A codes are padded to EAN-13, the form product
+// databases use, so a can scanned as either resolves to the same item.
+func Normalize(code string) (string, error) {
+	code = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, code)
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidBarcode
+		}
+	}
+	switch len(code) {
+	case 8, 13, 14:
+	case 12:
+		code = "0" + code
+	default:
+		return "", ErrInvalidBarcode
+	}
+
+	// GS1 check digit: weights alternate 3 and 1 from the digit left of the check digit
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	if (10-sum%10)%10 != int(code[len(code)-1]-'0') {
+		return "", ErrInvalidBarcode
+	}
+
+	return code, nil
+}
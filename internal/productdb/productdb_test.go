@@ -0,0 +1,63 @@
+package productdb
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+		err  error
+	}{
+		{code: "96385074", want: "96385074"},
+		{code: "4006381333931", want: "4006381333931"},
+		{code: "012000161155", want: "0012000161155"},
+		{code: "0012000161155", want: "0012000161155"},
+		{code: "10012345678902", want: "10012345678902"},
+		{code: "4006-3813 33931", want: "4006381333931"},
+		{code: "4006381333932", err: ErrInvalidBarcode},
+		{code: "012000161156", err: ErrInvalidBarcode},
+		{code: "40063813339a1", err: ErrInvalidBarcode},
+		{code: "1234567", err: ErrInvalidBarcode},
+		{code: "", err: ErrInvalidBarcode},
+	}
+
+	for _, tt := range tests {
+		got, err := Normalize(tt.code)
+		if !errors.Is(err, tt.err) || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v, want %q, %v", tt.code, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestFixtureClientLookup(t *testing.T) {
+	client := NewFixtureClient(SampleProducts...)
+
+	// The Pepsi sample is listed as EAN-13; a UPC-A scan normalizes to the same code
+	code, err := Normalize("012000161155")
+	if err != nil {
+		t.Fatal(err)
+	}
+	product, err := client.Lookup(context.Background(), code)
+	if err != nil || product.Name != "Pepsi" {
+		t.Errorf("Lookup(%q) = %+v, %v, want Pepsi", code, product, err)
+	}
+
+	if _, err := client.Lookup(context.Background(), "4006381333931"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup() of an unknown code error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestNewMissingFixture(t *testing.T) {
+	client, err := New(Config{Name: Fixture, FixturePath: filepath.Join(t.TempDir(), "missing.json")})
+	if err == nil {
+		t.Fatal("New() with a missing fixture returned no error")
+	}
+	if client != nil {
+		t.Errorf("New() with a missing fixture returned client %#v, want nil", client)
+	}
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"log"
 
 	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
 	"github.com/GroceryTrak/GroceryTrakService/internal/models"
@@ -10,8 +11,12 @@ import (
 )
 
 type AuthRepository interface {
-	RegisterUser(req dtos.RegisterRequest, role string) (dtos.RegisterResponse, error)
+	RegisterUser(req dtos.RegisterRequest, role models.Role) (dtos.RegisterResponse, error)
 	LoginUser(req dtos.LoginRequest) (dtos.LoginResponse, error)
+	RefreshToken(req dtos.RefreshRequest) (dtos.LoginResponse, error)
+	LogoutUser(req dtos.LogoutRequest, accessToken string) (dtos.LogoutResponse, error)
+	EnsureAdmin(username, password string) error
+	CreateAdminIfMissing(username, password string) (bool, error)
 }
 
 type AuthRepositoryImpl struct {
@@ -22,7 +27,7 @@ func NewAuthRepository(db *gorm.DB) AuthRepository {
 	return &AuthRepositoryImpl{db: db}
 }
 
-func (r *AuthRepositoryImpl) RegisterUser(req dtos.RegisterRequest, role string) (dtos.RegisterResponse, error) {
+func (r *AuthRepositoryImpl) RegisterUser(req dtos.RegisterRequest, role models.Role) (dtos.RegisterResponse, error) {
 	var existingUser models.User
 	result := r.db.Where("username = ?", req.Username).First(&existingUser).Error
 	if result == nil {
@@ -32,7 +37,7 @@ func (r *AuthRepositoryImpl) RegisterUser(req dtos.RegisterRequest, role string)
 	user := models.User{
 		Username: req.Username,
 		Password: utils.HashPassword(req.Password),
-		Role:     "user",
+		Role:     role,
 	}
 
 	if err := r.db.Create(&user).Error; err != nil {
@@ -53,10 +58,101 @@ func (r *AuthRepositoryImpl) LoginUser(req dtos.LoginRequest) (dtos.LoginRespons
 		return dtos.LoginResponse{}, errors.New("invalid credentials")
 	}
 
-	token, err := utils.GenerateJWT(user.ID, user.Username, user.Role)
+	tokens, err := utils.GenerateTokenPair(user.ID, user.Username, user.Role, "")
 	if err != nil {
 		return dtos.LoginResponse{}, errors.New("could not generate token")
 	}
 
-	return dtos.LoginResponse{Token: token}, nil
+	return dtos.LoginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	}, nil
+}
+
+func (r *AuthRepositoryImpl) RefreshToken(req dtos.RefreshRequest) (dtos.LoginResponse, error) {
+	claims, err := utils.VerifyRefreshToken(req.RefreshToken)
+	if err != nil {
+		return dtos.LoginResponse{}, err
+	}
+
+	// Reload the user so role changes and deletions take effect on the next refresh
+	var user models.User
+	if err := r.db.First(&user, "id = ?", claims.ID).Error; err != nil {
+		if err := utils.RevokeTokenFamily(claims.Family); err != nil {
+			return dtos.LoginResponse{}, err
+		}
+		return dtos.LoginResponse{}, errors.New("user not found")
+	}
+
+	tokens, err := utils.GenerateTokenPair(user.ID, user.Username, user.Role, claims.Family)
+	if err != nil {
+		return dtos.LoginResponse{}, errors.New("could not generate token")
+	}
+
+	return dtos.LoginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	}, nil
+}
+
+func (r *AuthRepositoryImpl) LogoutUser(req dtos.LogoutRequest, accessToken string) (dtos.LogoutResponse, error) {
+	if err := utils.RevokeRefreshToken(req.RefreshToken); err != nil {
+		return dtos.LogoutResponse{}, err
+	}
+
+	// The access token is best effort: it is usually of the same family and may already be expired
+	if accessToken != "" {
+		if err := utils.RevokeAccessToken(accessToken); err != nil {
+			log.Printf("Failed to revoke access token on logout: %v", err)
+		}
+	}
+
+	return dtos.LogoutResponse{Message: "Logged out successfully"}, nil
+}
+
+// EnsureAdmin creates the given admin account, or promotes and resets the password of an
+// existing user with that username. It backs the explicit create-admin command.
+func (r *AuthRepositoryImpl) EnsureAdmin(username, password string) error {
+	if username == "" || password == "" {
+		return errors.New("admin username and password are required")
+	}
+
+	var user models.User
+	err := r.db.Where("username = ?", username).First(&user).Error
+	if err == gorm.ErrRecordNotFound {
+		user = models.User{
+			Username: username,
+			Password: utils.HashPassword(password),
+			Role:     models.AdminRole,
+		}
+		return r.db.Create(&user).Error
+	} else if err != nil {
+		return err
+	}
+
+	user.Password = utils.HashPassword(password)
+	user.Role = models.AdminRole
+	return r.db.Save(&user).Error
+}
+
+// CreateAdminIfMissing creates the given admin account unless a user with that username exists,
+// reporting whether it did. An existing user is left alone, so that bootstrapping on every start
+// neither undoes the admin's password changes nor promotes a regular account.
+func (r *AuthRepositoryImpl) CreateAdminIfMissing(username, password string) (bool, error) {
+	if username == "" || password == "" {
+		return false, errors.New("admin username and password are required")
+	}
+
+	user := models.User{
+		Username: username,
+		Password: utils.HashPassword(password),
+		Role:     models.AdminRole,
+	}
+	result := r.db.Where(models.User{Username: username}).FirstOrCreate(&user)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
 }
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"math"
+	"time"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"gorm.io/gorm"
+)
+
+type BudgetRepository interface {
+	GetBudgets(owner Owner) (dtos.BudgetsResponse, error)
+	SetBudget(req dtos.BudgetRequest, owner Owner) (dtos.BudgetResponse, error)
+	DeleteBudget(budgetID uint, owner Owner) error
+	GetSpendReport(query dtos.SpendReportQuery, owner Owner) (dtos.SpendReportResponse, error)
+}
+
+type BudgetRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewBudgetRepository(db *gorm.DB) BudgetRepository {
+	return &BudgetRepositoryImpl{db: db}
+}
+
+// spendKeys are the SQL expressions purchase lines are grouped by in a spend report
+var spendKeys = map[string]string{
+	dtos.SpendByCategory: "COALESCE(items.category, 'other')",
+	dtos.SpendByStore:    "purchases.store",
+	dtos.SpendByWeek:     "TO_CHAR(DATE_TRUNC('week', purchases.purchased_at), 'YYYY-MM-DD')",
+}
+
+// spend totals the owner's purchase lines from from up to, not including, to
+func spend(db *gorm.DB, from, to time.Time, groupBy string, owner Owner) ([]dtos.SpendGroupResponse, error) {
+	key := spendKeys[groupBy]
+	order := "total DESC"
+	if groupBy == dtos.SpendByWeek {
+		order = "key"
+	}
+
+	var groups []dtos.SpendGroupResponse
+	err := db.Table("purchase_lines").
+		Select(key+" AS key, SUM(purchase_lines.price) AS total, COUNT(*) AS lines").
+		Joins("JOIN purchases ON purchases.id = purchase_lines.purchase_id").
+		Joins("LEFT JOIN items ON items.id = purchase_lines.item_id").
+		Scopes(owner.scope("purchases")).
+		Where("purchases.purchased_at >= ? AND purchases.purchased_at < ?", from, to).
+		Group(key).
+		Order(order).
+		Scan(&groups).Error
+	return groups, err
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// monthBudgets returns the owner's budgets with this month's spend, and alerts for those exceeded
+func (r *BudgetRepositoryImpl) monthBudgets(owner Owner) (string, []dtos.BudgetResponse, []dtos.BudgetAlert, error) {
+	start := monthStart(time.Now())
+	month := start.Format("2006-01")
+
+	var budgets []models.Budget
+	if err := r.db.Scopes(owner.scope("budgets")).Order("category").Find(&budgets).Error; err != nil {
+		return "", nil, nil, err
+	}
+
+	groups, err := spend(r.db, start, start.AddDate(0, 1, 0), dtos.SpendByCategory, owner)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	spent := make(map[string]float64)
+	for _, group := range groups {
+		spent[group.Key] += group.Total
+		spent[""] += group.Total
+	}
+
+	budgetResponses := make([]dtos.BudgetResponse, len(budgets))
+	alerts := []dtos.BudgetAlert{}
+	for i, budget := range budgets {
+		budgetResponses[i] = dtos.BudgetResponse{
+			ID:        budget.ID,
+			Category:  budget.Category,
+			Amount:    budget.Amount,
+			Spent:     roundCents(spent[budget.Category]),
+			Remaining: roundCents(budget.Amount - spent[budget.Category]),
+			Exceeded:  spent[budget.Category] > budget.Amount,
+		}
+		if budgetResponses[i].Exceeded {
+			alerts = append(alerts, dtos.BudgetAlert{
+				Category: budget.Category,
+				Month:    month,
+				Amount:   budget.Amount,
+				Spent:    budgetResponses[i].Spent,
+			})
+		}
+	}
+
+	return month, budgetResponses, alerts, nil
+}
+
+func (r *BudgetRepositoryImpl) GetBudgets(owner Owner) (dtos.BudgetsResponse, error) {
+	month, budgets, alerts, err := r.monthBudgets(owner)
+	if err != nil {
+		return dtos.BudgetsResponse{}, err
+	}
+
+	return dtos.BudgetsResponse{
+		Month:   month,
+		Budgets: budgets,
+		Alerts:  alerts,
+	}, nil
+}
+
+// SetBudget creates the budget for the category or replaces its amount
+func (r *BudgetRepositoryImpl) SetBudget(req dtos.BudgetRequest, owner Owner) (dtos.BudgetResponse, error) {
+	var budget models.Budget
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Scopes(owner.scope("budgets")).Where("budgets.category = ?", req.Category).First(&budget).Error
+		if err == gorm.ErrRecordNotFound {
+			budget = models.Budget{
+				UserID:      owner.UserID,
+				HouseholdID: owner.HouseholdID,
+				Category:    req.Category,
+			}
+		} else if err != nil {
+			return err
+		}
+
+		budget.Amount = req.Amount
+		return tx.Save(&budget).Error
+	})
+	if err != nil {
+		return dtos.BudgetResponse{}, err
+	}
+
+	_, budgets, _, err := r.monthBudgets(owner)
+	if err != nil {
+		return dtos.BudgetResponse{}, err
+	}
+	for _, budgetResponse := range budgets {
+		if budgetResponse.ID == budget.ID {
+			return budgetResponse, nil
+		}
+	}
+	return dtos.BudgetResponse{}, gorm.ErrRecordNotFound
+}
+
+func (r *BudgetRepositoryImpl) DeleteBudget(budgetID uint, owner Owner) error {
+	result := r.db.Scopes(owner.scope("budgets")).Delete(&models.Budget{}, "id = ?", budgetID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetSpendReport totals the owner's purchase lines in the period, grouped in SQL by item
+// category, store or week
+func (r *BudgetRepositoryImpl) GetSpendReport(query dtos.SpendReportQuery, owner Owner) (dtos.SpendReportResponse, error) {
+	groups, err := spend(r.db, query.From, query.To.AddDate(0, 0, 1), query.GroupBy, owner)
+	if err != nil {
+		return dtos.SpendReportResponse{}, err
+	}
+
+	report := dtos.SpendReportResponse{
+		From:    query.From,
+		To:      query.To,
+		GroupBy: query.GroupBy,
+		Groups:  []dtos.SpendGroupResponse{},
+	}
+	for _, group := range groups {
+		group.Total = roundCents(group.Total)
+		report.Total += group.Total
+		report.Groups = append(report.Groups, group)
+	}
+	report.Total = roundCents(report.Total)
+
+	_, _, report.Alerts, err = r.monthBudgets(owner)
+	if err != nil {
+		return dtos.SpendReportResponse{}, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GroceryTrak/GroceryTrakService/config"
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	testDBOnce sync.Once
+	testDB     *gorm.DB
+	testDBErr  error
+)
+
+// openTestDB returns a transaction on the Postgres database in TEST_DATABASE_URL, migrated like
+// the app's, that is rolled back when the test ends. Tests that need a database are skipped
+// when the variable is not set.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	testDBOnce.Do(func() {
+		testDB, testDBErr = gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		if testDBErr == nil {
+			testDBErr = config.Migrate(testDB)
+		}
+	})
+	if testDBErr != nil {
+		t.Fatalf("failed to set up test database: %v", testDBErr)
+	}
+
+	tx := testDB.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+func createTestOwner(t *testing.T, db *gorm.DB, username string) Owner {
+	t.Helper()
+
+	user := models.User{Username: username, Password: "x"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return Owner{UserID: user.ID}
+}
+
+// createTestItem adds an enriched item to the catalog, so that only the items a test creates
+// through the repository end up in the enrichment queue
+func createTestItem(t *testing.T, db *gorm.DB, name string) models.Item {
+	t.Helper()
+
+	source := models.ManualEnrichment
+	now := time.Now()
+	item := models.Item{
+		Name:             name,
+		Category:         models.OtherCategory,
+		EnrichmentStatus: models.EnrichmentEnriched,
+		EnrichmentSource: &source,
+		EnrichedAt:       &now,
+	}
+	if err := db.Create(&item).Error; err != nil {
+		t.Fatalf("failed to create item: %v", err)
+	}
+	return item
+}
+
+// fakeQueue records the items queued for enrichment, and those removed, instead of using Redis
+type fakeQueue struct {
+	added   []models.QueueItem
+	removed []uint
+}
+
+func (q *fakeQueue) AddItem(ctx context.Context, item models.QueueItem) error {
+	q.added = append(q.added, item)
+	return nil
+}
+
+func (q *fakeQueue) ClaimBatch(ctx context.Context, batchSize int, lease time.Duration) ([]models.QueueItem, error) {
+	return nil, nil
+}
+
+func (q *fakeQueue) CompleteItem(ctx context.Context, item models.QueueItem) error { return nil }
+
+func (q *fakeQueue) ReleaseItem(ctx context.Context, item models.QueueItem) error { return nil }
+
+func (q *fakeQueue) RetryItem(ctx context.Context, item models.QueueItem, cause error) error {
+	return nil
+}
+
+func (q *fakeQueue) DeadLetterItem(ctx context.Context, item models.QueueItem, cause error) error {
+	return nil
+}
+
+func (q *fakeQueue) GetQueueStats(ctx context.Context) (dtos.QueueStatsResponse, error) {
+	return dtos.QueueStatsResponse{}, nil
+}
+
+func (q *fakeQueue) GetDeadItems(ctx context.Context) (dtos.DeadQueueItemsResponse, error) {
+	return dtos.DeadQueueItemsResponse{}, nil
+}
+
+func (q *fakeQueue) RequeueDeadItem(ctx context.Context, itemID uint) error { return nil }
+
+func (q *fakeQueue) RequeueDeadItems(ctx context.Context) (int, error) { return 0, nil }
+
+func (q *fakeQueue) RemoveItem(ctx context.Context, itemID uint) error {
+	q.removed = append(q.removed, itemID)
+	return nil
+}
+
+func createTestLot(t *testing.T, db *gorm.DB, owner Owner, item models.Item, amount float32, unit string) models.UserItem {
+	t.Helper()
+
+	lot := models.UserItem{UserID: owner.UserID, HouseholdID: owner.HouseholdID, ItemID: item.ID, Amount: amount, Unit: unit}
+	if err := db.Omit("Item").Create(&lot).Error; err != nil {
+		t.Fatalf("failed to create lot: %v", err)
+	}
+	return lot
+}
+
+// createTestPurchase records a purchase of a single line of item
+func createTestPurchase(t *testing.T, db *gorm.DB, owner Owner, item models.Item, amount float32, unit string, price float64) models.Purchase {
+	t.Helper()
+
+	purchase := models.Purchase{
+		UserID:      owner.UserID,
+		HouseholdID: owner.HouseholdID,
+		PurchasedAt: time.Now(),
+		Total:       price,
+		Lines:       []models.PurchaseLine{{ItemID: &item.ID, Name: item.Name, Amount: amount, Unit: unit, Price: price}},
+	}
+	if err := db.Omit("Lines.Item").Create(&purchase).Error; err != nil {
+		t.Fatalf("failed to create purchase: %v", err)
+	}
+	return purchase
+}
@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"crypto/rand"
+	"errors"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNotHouseholdMember = errors.New("user is not a member of the household")
+	ErrNotHouseholdOwner  = errors.New("only a household owner can do this")
+	ErrLastHouseholdOwner = errors.New("a household needs at least one owner")
+	ErrAlreadyMember      = errors.New("user is already a member of the household")
+)
+
+// inviteCodeAlphabet leaves out characters that are easy to mix up when read aloud
+const inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const inviteCodeLength = 8
+
+// Owner identifies whose pantry and shopping lists an operation targets: the user's own, or
+// those of a household the user belongs to
+type Owner struct {
+	UserID      uint
+	HouseholdID *uint
+}
+
+// scope restricts a query on table to the rows of o
+func (o Owner) scope(table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if o.HouseholdID != nil {
+			return db.Where(table+".household_id = ?", *o.HouseholdID)
+		}
+		return db.Where(table+".user_id = ? AND "+table+".household_id IS NULL", o.UserID)
+	}
+}
+
+type HouseholdRepository interface {
+	GetAllHouseholds(userID uint) (dtos.HouseholdsResponse, error)
+	GetHousehold(householdID, userID uint) (dtos.HouseholdResponse, error)
+	CreateHousehold(req dtos.HouseholdRequest, userID uint) (dtos.HouseholdResponse, error)
+	UpdateHousehold(req dtos.HouseholdRequest, householdID, userID uint) (dtos.HouseholdResponse, error)
+	DeleteHousehold(householdID, userID uint) error
+	RegenerateInviteCode(householdID, userID uint) (dtos.HouseholdResponse, error)
+	JoinHousehold(req dtos.HouseholdJoinRequest, userID uint) (dtos.HouseholdResponse, error)
+	LeaveHousehold(householdID, userID uint) error
+	UpdateHouseholdMember(req dtos.HouseholdMemberRequest, householdID, memberID, userID uint) (dtos.HouseholdResponse, error)
+	RemoveHouseholdMember(householdID, memberID, userID uint) error
+	GetMemberRole(householdID, userID uint) (models.HouseholdRole, error)
+}
+
+type HouseholdRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewHouseholdRepository(db *gorm.DB) HouseholdRepository {
+	return &HouseholdRepositoryImpl{db: db}
+}
+
+func generateInviteCode() (string, error) {
+	b := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = inviteCodeAlphabet[int(b[i])%len(inviteCodeAlphabet)]
+	}
+	return string(b), nil
+}
+
+// toHouseholdResponse only shows the invite code to owners, who are the ones handing it out
+func toHouseholdResponse(household models.Household, role models.HouseholdRole) dtos.HouseholdResponse {
+	members := make([]dtos.HouseholdMemberResponse, len(household.Members))
+	for i, member := range household.Members {
+		members[i] = dtos.HouseholdMemberResponse{
+			UserID:   member.UserID,
+			Username: member.User.Username,
+			Role:     string(member.Role),
+			JoinedAt: member.CreatedAt,
+		}
+	}
+
+	resp := dtos.HouseholdResponse{
+		ID:        household.ID,
+		Name:      household.Name,
+		CreatedAt: household.CreatedAt,
+		Members:   members,
+	}
+	if role == models.HouseholdOwnerRole {
+		resp.InviteCode = household.InviteCode
+	}
+	return resp
+}
+
+func findHousehold(db *gorm.DB, householdID uint) (models.Household, error) {
+	var household models.Household
+	err := db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at")
+	}).Preload("Members.User").First(&household, householdID).Error
+	return household, err
+}
+
+func (r *HouseholdRepositoryImpl) GetMemberRole(householdID, userID uint) (models.HouseholdRole, error) {
+	return getMemberRole(r.db, householdID, userID)
+}
+
+func getMemberRole(db *gorm.DB, householdID, userID uint) (models.HouseholdRole, error) {
+	var member models.HouseholdMember
+	if err := db.First(&member, "household_id = ? AND user_id = ?", householdID, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrNotHouseholdMember
+		}
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// requireOwner fails unless userID owns the household
+func requireOwner(db *gorm.DB, householdID, userID uint) error {
+	role, err := getMemberRole(db, householdID, userID)
+	if err != nil {
+		return err
+	}
+	if role != models.HouseholdOwnerRole {
+		return ErrNotHouseholdOwner
+	}
+	return nil
+}
+
+func (r *HouseholdRepositoryImpl) getHousehold(db *gorm.DB, householdID, userID uint) (dtos.HouseholdResponse, error) {
+	role, err := getMemberRole(db, householdID, userID)
+	if err != nil {
+		return dtos.HouseholdResponse{}, err
+	}
+
+	household, err := findHousehold(db, householdID)
+	if err != nil {
+		return dtos.HouseholdResponse{}, err
+	}
+
+	return toHouseholdResponse(household, role), nil
+}
+
+func (r *HouseholdRepositoryImpl) GetAllHouseholds(userID uint) (dtos.HouseholdsResponse, error) {
+	var memberships []models.HouseholdMember
+	if err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&memberships).Error; err != nil {
+		return dtos.HouseholdsResponse{}, err
+	}
+
+	householdResponses := make([]dtos.HouseholdResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		household, err := findHousehold(r.db, membership.HouseholdID)
+		if err != nil {
+			return dtos.HouseholdsResponse{}, err
+		}
+		householdResponses = append(householdResponses, toHouseholdResponse(household, membership.Role))
+	}
+
+	return dtos.HouseholdsResponse{Households: householdResponses}, nil
+}
+
+func (r *HouseholdRepositoryImpl) GetHousehold(householdID, userID uint) (dtos.HouseholdResponse, error) {
+	return r.getHousehold(r.db, householdID, userID)
+}
+
+// CreateHousehold creates a household with userID as its first owner
+func (r *HouseholdRepositoryImpl) CreateHousehold(req dtos.HouseholdRequest, userID uint) (dtos.HouseholdResponse, error) {
+	inviteCode, err := generateInviteCode()
+	if err != nil {
+		return dtos.HouseholdResponse{}, err
+	}
+
+	household := models.Household{
+		Name:       req.Name,
+		InviteCode: inviteCode,
+		Members: []models.HouseholdMember{
+			{UserID: userID, Role: models.HouseholdOwnerRole},
+		},
+	}
+	if err := r.db.Create(&household).Error; err != nil {
+		return dtos.HouseholdResponse{}, err
+	}
+
+	return r.GetHousehold(household.ID, userID)
+}
+
+func (r *HouseholdRepositoryImpl) UpdateHousehold(req dtos.HouseholdRequest, householdID, userID uint) (dtos.HouseholdResponse, error) {
+	if err := requireOwner(r.db, householdID, userID); err != nil {
+		return dtos.HouseholdResponse{}, err
+	}
+
+	if err := r.db.Model(&models.Household{}).Where("id = ?", householdID).Update("name", req.Name).Error; err != nil {
+		return dtos.HouseholdResponse{}, err
+	}
+
+	return r.GetHousehold(householdID, userID)
+}
+
+// DeleteHousehold deletes the household together with its shared pantry and shopping lists
+func (r *HouseholdRepositoryImpl) DeleteHousehold(householdID, userID uint) error {
+	if err := requireOwner(r.db, householdID, userID); err != nil {
+		return err
+	}
+
+	return r.db.Delete(&models.Household{}, householdID).Error
+}
+
+// RegenerateInviteCode replaces the invite code, so the old one can no longer be used to join
+func (r *HouseholdRepositoryImpl) RegenerateInviteCode(householdID, userID uint) (dtos.HouseholdResponse, error) {
+	if err := requireOwner(r.db, householdID, userID); err != nil {
+		return dtos.HouseholdResponse{}, err
+	}
+
+	inviteCode, err := generateInviteCode()
+	if err != nil {
+		return dtos.HouseholdResponse{}, err
+	}
+
+	if err := r.db.Model(&models.Household{}).Where("id = ?", householdID).Update("invite_code", inviteCode).Error; err != nil {
+		return dtos.HouseholdResponse{}, err
+	}
+
+	return r.GetHousehold(householdID, userID)
+}
+
+func (r *HouseholdRepositoryImpl) JoinHousehold(req dtos.HouseholdJoinRequest, userID uint) (dtos.HouseholdResponse, error) {
+	var household models.Household
+	if err := r.db.First(&household, "invite_code = ?", req.InviteCode).Error; err != nil {
+		return dtos.HouseholdResponse{}, err
+	}
+
+	member := models.HouseholdMember{
+		HouseholdID: household.ID,
+		UserID:      userID,
+		Role:        models.HouseholdMemberRole,
+	}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&member)
+	if result.Error != nil {
+		return dtos.HouseholdResponse{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return dtos.HouseholdResponse{}, ErrAlreadyMember
+	}
+
+	return r.GetHousehold(household.ID, userID)
+}
+
+// LeaveHousehold removes userID from the household. The last owner cannot leave while other
+// members remain; when nobody is left, the household is deleted.
+func (r *HouseholdRepositoryImpl) LeaveHousehold(householdID, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := removeMember(tx, householdID, userID); err != nil {
+			return err
+		}
+
+		var remaining int64
+		if err := tx.Model(&models.HouseholdMember{}).Where("household_id = ?", householdID).Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining == 0 {
+			return tx.Delete(&models.Household{}, householdID).Error
+		}
+		return nil
+	})
+}
+
+func (r *HouseholdRepositoryImpl) UpdateHouseholdMember(req dtos.HouseholdMemberRequest, householdID, memberID, userID uint) (dtos.HouseholdResponse, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := requireOwner(tx, householdID, userID); err != nil {
+			return err
+		}
+
+		role, err := getMemberRole(tx, householdID, memberID)
+		if err != nil {
+			return err
+		}
+
+		newRole := models.HouseholdRole(req.Role)
+		if role == models.HouseholdOwnerRole && newRole != models.HouseholdOwnerRole {
+			if err := ensureAnotherOwner(tx, householdID, memberID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.HouseholdMember{}).
+			Where("household_id = ? AND user_id = ?", householdID, memberID).
+			Update("role", newRole).Error
+	})
+	if err != nil {
+		return dtos.HouseholdResponse{}, err
+	}
+
+	return r.GetHousehold(householdID, userID)
+}
+
+func (r *HouseholdRepositoryImpl) RemoveHouseholdMember(householdID, memberID, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := requireOwner(tx, householdID, userID); err != nil {
+			return err
+		}
+		if _, err := getMemberRole(tx, householdID, memberID); err != nil {
+			return err
+		}
+
+		return removeMember(tx, householdID, memberID)
+	})
+}
+
+// removeMember deletes a membership, refusing to remove the last owner of a household that
+// still has other members
+func removeMember(tx *gorm.DB, householdID, userID uint) error {
+	role, err := getMemberRole(tx, householdID, userID)
+	if err != nil {
+		return err
+	}
+
+	if role == models.HouseholdOwnerRole {
+		var others int64
+		if err := tx.Model(&models.HouseholdMember{}).Where("household_id = ? AND user_id <> ?", householdID, userID).Count(&others).Error; err != nil {
+			return err
+		}
+		if others > 0 {
+			if err := ensureAnotherOwner(tx, householdID, userID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Delete(&models.HouseholdMember{}, "household_id = ? AND user_id = ?", householdID, userID).Error
+}
+
+func ensureAnotherOwner(tx *gorm.DB, householdID, userID uint) error {
+	var owners int64
+	if err := tx.Model(&models.HouseholdMember{}).
+		Where("household_id = ? AND user_id <> ? AND role = ?", householdID, userID, models.HouseholdOwnerRole).
+		Count(&owners).Error; err != nil {
+		return err
+	}
+	if owners == 0 {
+		return ErrLastHouseholdOwner
+	}
+	return nil
+}
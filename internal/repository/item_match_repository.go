@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/matching"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidAlias = errors.New("alias has no letters or digits")
+
+// ItemMatch is the catalog item closest to a name. Item is nil when nothing comes close;
+// Confident is false when the best candidate scores below the threshold, in which case a new
+// item should be proposed to the user rather than created or matched silently.
+type ItemMatch struct {
+	Item      *models.Item
+	Score     float64
+	Confident bool
+}
+
+type ItemMatchRepository interface {
+	MatchItem(name string) (ItemMatch, error)
+	GetItemAliases(itemID uint) (dtos.ItemAliasesResponse, error)
+	AddItemAlias(req dtos.ItemAliasRequest, itemID uint) (dtos.ItemAliasResponse, error)
+	DeleteItemAlias(itemID, aliasID uint) error
+}
+
+type ItemMatchRepositoryImpl struct {
+	db        *gorm.DB
+	threshold float64
+}
+
+func NewItemMatchRepository(db *gorm.DB, threshold float64) ItemMatchRepository {
+	if threshold <= 0 || threshold > 1 {
+		threshold = matching.DefaultThreshold
+	}
+	return &ItemMatchRepositoryImpl{
+		db:        db,
+		threshold: threshold,
+	}
+}
+
+// MatchItem resolves a detected name to a catalog item: first by exact name after
+// normalization, then through the aliases table, then by trigram similarity
+func (r *ItemMatchRepositoryImpl) MatchItem(name string) (ItemMatch, error) {
+	normalized := matching.Normalize(name)
+	if normalized == "" {
+		return ItemMatch{}, nil
+	}
+
+	var items []models.Item
+	if err := r.db.Where("normalized_name = ?", normalized).Order("id").Limit(1).Find(&items).Error; err != nil {
+		return ItemMatch{}, err
+	}
+	if len(items) > 0 {
+		return ItemMatch{Item: &items[0], Score: 1, Confident: true}, nil
+	}
+
+	var aliases []models.ItemAlias
+	if err := r.db.Preload("Item").Where("alias = ?", normalized).Limit(1).Find(&aliases).Error; err != nil {
+		return ItemMatch{}, err
+	}
+	if len(aliases) > 0 {
+		return ItemMatch{Item: &aliases[0].Item, Score: 1, Confident: true}, nil
+	}
+
+	var candidates []struct {
+		models.Item
+		Score float64
+	}
+	if err := r.db.Model(&models.Item{}).
+		Select("items.*, similarity(LOWER(name), ?) AS score", normalized).
+		Where("LOWER(name) % ?", normalized).
+		Order("score DESC, id").
+		Limit(1).
+		Find(&candidates).Error; err != nil {
+		return ItemMatch{}, err
+	}
+	if len(candidates) == 0 {
+		return ItemMatch{}, nil
+	}
+
+	item := candidates[0].Item
+	return ItemMatch{
+		Item:      &item,
+		Score:     candidates[0].Score,
+		Confident: candidates[0].Score >= r.threshold,
+	}, nil
+}
+
+func toItemAliasResponse(alias models.ItemAlias) dtos.ItemAliasResponse {
+	return dtos.ItemAliasResponse{
+		ID:     alias.ID,
+		ItemID: alias.ItemID,
+		Alias:  alias.Alias,
+	}
+}
+
+func (r *ItemMatchRepositoryImpl) GetItemAliases(itemID uint) (dtos.ItemAliasesResponse, error) {
+	if err := r.db.First(&models.Item{}, itemID).Error; err != nil {
+		return dtos.ItemAliasesResponse{}, err
+	}
+
+	var aliases []models.ItemAlias
+	if err := r.db.Where("item_id = ?", itemID).Order("alias").Find(&aliases).Error; err != nil {
+		return dtos.ItemAliasesResponse{}, err
+	}
+
+	aliasResponses := make([]dtos.ItemAliasResponse, len(aliases))
+	for i, alias := range aliases {
+		aliasResponses[i] = toItemAliasResponse(alias)
+	}
+
+	return dtos.ItemAliasesResponse{Aliases: aliasResponses}, nil
+}
+
+// AddItemAlias stores the normalized alias; an alias already pointing at another item is moved
+func (r *ItemMatchRepositoryImpl) AddItemAlias(req dtos.ItemAliasRequest, itemID uint) (dtos.ItemAliasResponse, error) {
+	normalized := matching.Normalize(req.Alias)
+	if normalized == "" {
+		return dtos.ItemAliasResponse{}, ErrInvalidAlias
+	}
+	if err := r.db.First(&models.Item{}, itemID).Error; err != nil {
+		return dtos.ItemAliasResponse{}, err
+	}
+
+	var alias models.ItemAlias
+	err := r.db.Where(models.ItemAlias{Alias: normalized}).
+		Assign(models.ItemAlias{ItemID: itemID}).
+		FirstOrCreate(&alias).Error
+	if err != nil {
+		return dtos.ItemAliasResponse{}, err
+	}
+
+	return toItemAliasResponse(alias), nil
+}
+
+func (r *ItemMatchRepositoryImpl) DeleteItemAlias(itemID, aliasID uint) error {
+	result := r.db.Delete(&models.ItemAlias{}, "id = ? AND item_id = ?", aliasID, itemID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+)
+
+func TestMatchItemNormalizesCatalogNames(t *testing.T) {
+	db := openTestDB(t)
+	tomatoes := createTestItem(t, db, "Cherry Tomatoes")
+	repo := NewItemMatchRepository(db, 0)
+
+	for _, name := range []string{"cherry tomato", " Cherry  tomatoes!", "CHERRY-TOMATO"} {
+		match, err := repo.MatchItem(name)
+		if err != nil {
+			t.Fatalf("MatchItem(%q) error = %v", name, err)
+		}
+		if match.Item == nil || match.Item.ID != tomatoes.ID || match.Score != 1 || !match.Confident {
+			t.Errorf("MatchItem(%q) = %+v, want an exact match of Cherry Tomatoes", name, match)
+		}
+	}
+}
+
+func TestAddItemAliasRejectsEmptyAlias(t *testing.T) {
+	repo := NewItemMatchRepository(nil, 0)
+
+	if _, err := repo.AddItemAlias(dtos.ItemAliasRequest{Alias: "!!!"}, 1); !errors.Is(err, ErrInvalidAlias) {
+		t.Errorf("AddItemAlias(\"!!!\") error = %v, want %v", err, ErrInvalidAlias)
+	}
+}
@@ -3,25 +3,127 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
 	"github.com/GroceryTrak/GroceryTrakService/internal/models"
 	"github.com/redis/go-redis/v9"
 )
 
+// A job is keyed by its item's ID, so that an item has at most one. The ID moves between four
+// sorted sets: the queue, ordered by priority then age; the in-flight set, scored by when the
+// worker's lease runs out; the retry set, scored by when the next attempt is due; and the
+// dead-letter set, scored by when the job was given up on. The job itself is kept in a hash.
 const (
-	queueKey       = "item_enrichment_queue"
-	apiCreditsKey  = "spoonacular_api_credits"
-	defaultCredits = 150
+	jobsKey     = "item_enrichment_jobs"
+	queueKey    = "item_enrichment_queue"
+	inFlightKey = "item_enrichment_in_flight"
+	retryKey    = "item_enrichment_retry"
+	deadKey     = "item_enrichment_dead"
+
+	// maxAttempts is how many times a job is tried before it goes to the dead-letter set
+	maxAttempts    = 5
+	baseRetryDelay = time.Minute
+	maxRetryDelay  = 6 * time.Hour
+)
+
+var (
+	ErrQueueItemNotFound = errors.New("no such job in the dead-letter set")
+	errLeaseExpired      = errors.New("lease expired before the job was finished")
 )
 
+// addScript queues job ARGV[2] for item ARGV[1] at priority ARGV[3] and age ARGV[4]. An item that
+// already has a job keeps it: a higher priority is taken over, moving it up if it is waiting in
+// the queue, and a dead-lettered job is left alone. Returns 1 when the job was added or raised.
+var addScript = redis.NewScript(`
+local job = redis.call('HGET', KEYS[1], ARGV[1])
+local priority = tonumber(ARGV[3])
+if not job then
+	redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+	redis.call('ZADD', KEYS[2], tonumber(ARGV[4]) - priority * 1e10, ARGV[1])
+	return 1
+end
+if redis.call('ZSCORE', KEYS[3], ARGV[1]) then
+	return 0
+end
+
+job = cjson.decode(job)
+local old = job.priority or 0
+if priority <= old then
+	return 0
+end
+job.priority = priority
+redis.call('HSET', KEYS[1], ARGV[1], cjson.encode(job))
+local score = redis.call('ZSCORE', KEYS[2], ARGV[1])
+if score then
+	redis.call('ZADD', KEYS[2], tonumber(score) - (priority - old) * 1e10, ARGV[1])
+end
+return 1
+`)
+
+// claimScript pops up to ARGV[2] jobs off the queue into the in-flight set, leased until ARGV[1],
+// and returns them. IDs whose job has gone missing are dropped.
+var claimScript = redis.NewScript(`
+local ids = redis.call('ZPOPMIN', KEYS[1], ARGV[2])
+local claimed = {}
+for i = 1, #ids, 2 do
+	local job = redis.call('HGET', KEYS[3], ids[i])
+	if job then
+		redis.call('ZADD', KEYS[2], ARGV[1], ids[i])
+		table.insert(claimed, job)
+	end
+end
+return claimed
+`)
+
+// moveScript moves the job of item ARGV[1] from KEYS[1] to KEYS[2] with score ARGV[2], unless
+// another worker has moved it already, and records ARGV[3] attempts and last error ARGV[4]. When
+// ARGV[5] is 1 the score is the job's age and its priority, which may have been raised since the
+// caller read it, is added.
+var moveScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+local job = redis.call('HGET', KEYS[3], ARGV[1])
+if not job then
+	return 0
+end
+
+job = cjson.decode(job)
+job.attempts = tonumber(ARGV[3])
+job.last_error = ARGV[4]
+redis.call('HSET', KEYS[3], ARGV[1], cjson.encode(job))
+local score = tonumber(ARGV[2])
+if ARGV[5] == '1' then
+	score = score - (job.priority or 0) * 1e10
+end
+redis.call('ZADD', KEYS[2], score, ARGV[1])
+return 1
+`)
+
+// completeScript drops the job of item ARGV[1] if it is still in KEYS[1]
+var completeScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 1 then
+	redis.call('HDEL', KEYS[2], ARGV[1])
+end
+return 1
+`)
+
 type ItemQueueRepository interface {
 	AddItem(ctx context.Context, item models.QueueItem) error
-	GetNextBatch(ctx context.Context, batchSize int) ([]models.QueueItem, error)
-	RemoveItem(ctx context.Context, item models.QueueItem) error
-	CheckAPICredits(ctx context.Context) (int, error)
-	DecrementAPICredits(ctx context.Context) error
+	ClaimBatch(ctx context.Context, batchSize int, lease time.Duration) ([]models.QueueItem, error)
+	CompleteItem(ctx context.Context, item models.QueueItem) error
+	ReleaseItem(ctx context.Context, item models.QueueItem) error
+	RetryItem(ctx context.Context, item models.QueueItem, cause error) error
+	DeadLetterItem(ctx context.Context, item models.QueueItem, cause error) error
+	GetQueueStats(ctx context.Context) (dtos.QueueStatsResponse, error)
+	GetDeadItems(ctx context.Context) (dtos.DeadQueueItemsResponse, error)
+	RequeueDeadItem(ctx context.Context, itemID uint) error
+	RequeueDeadItems(ctx context.Context) (int, error)
+	RemoveItem(ctx context.Context, itemID uint) error
 }
 
 type ItemQueueRepositoryImpl struct {
@@ -34,74 +136,321 @@ func NewItemQueueRepository(redis *redis.Client) ItemQueueRepository {
 	}
 }
 
+func jobID(itemID uint) string {
+	return strconv.FormatUint(uint64(itemID), 10)
+}
+
+// queueAge orders jobs of the same priority in the queue, oldest first
+func queueAge(item models.QueueItem) float64 {
+	return float64(item.CreatedAt.Unix())
+}
+
+// retryDelay doubles the wait after each failed attempt, up to maxRetryDelay
+func retryDelay(attempts int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// AddItem queues an item for enrichment. If it is already queued, being worked on or waiting for
+// a retry, no second job is made; the existing one takes the higher of the two priorities.
 func (r *ItemQueueRepositoryImpl) AddItem(ctx context.Context, item models.QueueItem) error {
-	itemBytes, err := json.Marshal(item)
+	job, err := json.Marshal(item)
 	if err != nil {
 		return fmt.Errorf("failed to marshal item: %w", err)
 	}
 
-	score := float64(item.Priority)*1000000 + float64(item.CreatedAt.Unix())
-	if err := r.redis.ZAdd(ctx, queueKey, redis.Z{
-		Score:  score,
-		Member: itemBytes,
-	}).Err(); err != nil {
+	keys := []string{jobsKey, queueKey, deadKey}
+	if err := addScript.Run(ctx, r.redis, keys, jobID(item.ItemID), job, int(item.Priority), queueAge(item)).Err(); err != nil {
 		return fmt.Errorf("failed to add item to queue: %w", err)
 	}
 
 	return nil
 }
 
-func (r *ItemQueueRepositoryImpl) GetNextBatch(ctx context.Context, batchSize int) ([]models.QueueItem, error) {
-	// Get items from queue
-	result, err := r.redis.ZRange(ctx, queueKey, 0, int64(batchSize-1)).Result()
+// jobs looks up the jobs of the given item IDs in set. IDs without a job, such as members left
+// from before jobs were keyed by item, are dropped from the set.
+func (r *ItemQueueRepositoryImpl) jobs(ctx context.Context, set string, ids []string) ([]models.QueueItem, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	values, err := r.redis.HMGet(ctx, jobsKey, ids...).Result()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get items from queue: %w", err)
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
 	}
 
-	var items []models.QueueItem
-	for _, itemStr := range result {
+	items := make([]models.QueueItem, 0, len(values))
+	var orphans []interface{}
+	for i, value := range values {
+		job, ok := value.(string)
+		if !ok {
+			orphans = append(orphans, ids[i])
+			continue
+		}
 		var item models.QueueItem
-		if err := json.Unmarshal([]byte(itemStr), &item); err != nil {
+		if err := json.Unmarshal([]byte(job), &item); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal item: %w", err)
 		}
 		items = append(items, item)
 	}
 
+	if len(orphans) > 0 {
+		if err := r.redis.ZRem(ctx, set, orphans...).Err(); err != nil {
+			return nil, fmt.Errorf("failed to drop orphaned jobs: %w", err)
+		}
+	}
 	return items, nil
 }
 
-func (r *ItemQueueRepositoryImpl) RemoveItem(ctx context.Context, item models.QueueItem) error {
-	itemBytes, err := json.Marshal(item)
+// move atomically moves a job from one set to another, recording its attempts and last error,
+// and reports whether it was still there to move. Into the queue, score is the job's age.
+func (r *ItemQueueRepositoryImpl) move(ctx context.Context, from, to string, item models.QueueItem, score float64) (bool, error) {
+	queued := 0
+	if to == queueKey {
+		queued = 1
+	}
+
+	keys := []string{from, to, jobsKey}
+	moved, err := moveScript.Run(ctx, r.redis, keys, jobID(item.ItemID), score, item.Attempts, item.LastError, queued).Int()
 	if err != nil {
-		return fmt.Errorf("failed to marshal item: %w", err)
+		return false, fmt.Errorf("failed to move item from %s to %s: %w", from, to, err)
 	}
+	return moved == 1, nil
+}
 
-	if err := r.redis.ZRem(ctx, queueKey, itemBytes).Err(); err != nil {
+// fail counts a failed attempt at a job in from. It is scheduled for a retry after a backoff, or
+// dead-lettered once it has used up its attempts.
+func (r *ItemQueueRepositoryImpl) fail(ctx context.Context, from string, item models.QueueItem, cause error) error {
+	item.Attempts++
+	item.LastError = cause.Error()
+	if item.Attempts >= maxAttempts {
+		return r.deadLetter(ctx, from, item)
+	}
+
+	due := time.Now().Add(retryDelay(item.Attempts))
+	_, err := r.move(ctx, from, retryKey, item, float64(due.Unix()))
+	return err
+}
+
+func (r *ItemQueueRepositoryImpl) deadLetter(ctx context.Context, from string, item models.QueueItem) error {
+	_, err := r.move(ctx, from, deadKey, item, float64(time.Now().Unix()))
+	return err
+}
+
+// recoverItems returns the jobs whose leases have run out to the queue, counting the lost attempt,
+// and puts the retries that are due back in the queue
+func (r *ItemQueueRepositoryImpl) recoverItems(ctx context.Context, now time.Time) error {
+	until := strconv.FormatInt(now.Unix(), 10)
+
+	expiredIDs, err := r.redis.ZRangeByScore(ctx, inFlightKey, &redis.ZRangeBy{Min: "-inf", Max: until}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get expired leases: %w", err)
+	}
+	expired, err := r.jobs(ctx, inFlightKey, expiredIDs)
+	if err != nil {
+		return err
+	}
+	for _, item := range expired {
+		if err := r.fail(ctx, inFlightKey, item, errLeaseExpired); err != nil {
+			return err
+		}
+	}
+
+	dueIDs, err := r.redis.ZRangeByScore(ctx, retryKey, &redis.ZRangeBy{Min: "-inf", Max: until}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get due retries: %w", err)
+	}
+	due, err := r.jobs(ctx, retryKey, dueIDs)
+	if err != nil {
+		return err
+	}
+	for _, item := range due {
+		if _, err := r.move(ctx, retryKey, queueKey, item, queueAge(item)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClaimBatch takes up to batchSize jobs off the queue for this worker alone. Each must be
+// completed, retried or dead-lettered within lease; after that it is counted as failed and
+// handed out again, so the jobs of a worker that crashed are not lost.
+func (r *ItemQueueRepositoryImpl) ClaimBatch(ctx context.Context, batchSize int, lease time.Duration) ([]models.QueueItem, error) {
+	now := time.Now()
+	if err := r.recoverItems(ctx, now); err != nil {
+		return nil, err
+	}
+
+	keys := []string{queueKey, inFlightKey, jobsKey}
+	result, err := claimScript.Run(ctx, r.redis, keys, now.Add(lease).Unix(), batchSize).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim items from queue: %w", err)
+	}
+
+	items := make([]models.QueueItem, 0, len(result))
+	for _, job := range result {
+		var item models.QueueItem
+		if err := json.Unmarshal([]byte(job), &item); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// CompleteItem releases a claimed job that is done
+func (r *ItemQueueRepositoryImpl) CompleteItem(ctx context.Context, item models.QueueItem) error {
+	if err := completeScript.Run(ctx, r.redis, []string{inFlightKey, jobsKey}, jobID(item.ItemID)).Err(); err != nil {
 		return fmt.Errorf("failed to remove item from queue: %w", err)
 	}
 
 	return nil
 }
 
-func (r *ItemQueueRepositoryImpl) CheckAPICredits(ctx context.Context) (int, error) {
-	// Get current credits
-	credits, err := r.redis.Get(ctx, apiCreditsKey).Int()
-	if err == redis.Nil {
-		// Initialize credits if not set
-		if err := r.redis.Set(ctx, apiCreditsKey, defaultCredits, 24*time.Hour).Err(); err != nil {
-			return 0, fmt.Errorf("failed to initialize API credits: %w", err)
+// ReleaseItem hands a claimed job back to the queue untried, as when a worker shuts down
+func (r *ItemQueueRepositoryImpl) ReleaseItem(ctx context.Context, item models.QueueItem) error {
+	_, err := r.move(ctx, inFlightKey, queueKey, item, queueAge(item))
+	return err
+}
+
+// RetryItem records a failed attempt at a claimed job and schedules it to be tried again later,
+// or dead-letters it once it has failed maxAttempts times
+func (r *ItemQueueRepositoryImpl) RetryItem(ctx context.Context, item models.QueueItem, cause error) error {
+	return r.fail(ctx, inFlightKey, item, cause)
+}
+
+// DeadLetterItem gives up on a claimed job right away, for failures that retrying cannot fix
+func (r *ItemQueueRepositoryImpl) DeadLetterItem(ctx context.Context, item models.QueueItem, cause error) error {
+	item.Attempts++
+	item.LastError = cause.Error()
+	return r.deadLetter(ctx, inFlightKey, item)
+}
+
+func (r *ItemQueueRepositoryImpl) GetQueueStats(ctx context.Context) (dtos.QueueStatsResponse, error) {
+	var stats dtos.QueueStatsResponse
+	for key, count := range map[string]*int64{queueKey: &stats.Queued, inFlightKey: &stats.InFlight, retryKey: &stats.Retrying, deadKey: &stats.Dead} {
+		n, err := r.redis.ZCard(ctx, key).Result()
+		if err != nil {
+			return dtos.QueueStatsResponse{}, fmt.Errorf("failed to count %s: %w", key, err)
+		}
+		*count = n
+	}
+	return stats, nil
+}
+
+// deadItems returns the dead-lettered jobs, most recent first, with the time each was given up on
+func (r *ItemQueueRepositoryImpl) deadItems(ctx context.Context) ([]models.QueueItem, map[uint]time.Time, error) {
+	result, err := r.redis.ZRevRangeWithScores(ctx, deadKey, 0, -1).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get dead-lettered items: %w", err)
+	}
+
+	ids := make([]string, len(result))
+	for i, z := range result {
+		ids[i] = z.Member.(string)
+	}
+	items, err := r.jobs(ctx, deadKey, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	failedAt := make(map[uint]time.Time, len(result))
+	for _, z := range result {
+		id, err := strconv.ParseUint(z.Member.(string), 10, 64)
+		if err != nil {
+			continue
+		}
+		failedAt[uint(id)] = time.Unix(int64(z.Score), 0)
+	}
+	return items, failedAt, nil
+}
+
+func (r *ItemQueueRepositoryImpl) GetDeadItems(ctx context.Context) (dtos.DeadQueueItemsResponse, error) {
+	items, failedAt, err := r.deadItems(ctx)
+	if err != nil {
+		return dtos.DeadQueueItemsResponse{}, err
+	}
+
+	resp := dtos.DeadQueueItemsResponse{Items: make([]dtos.QueueItemResponse, len(items))}
+	for i, item := range items {
+		resp.Items[i] = dtos.QueueItemResponse{
+			ItemID:    item.ItemID,
+			Name:      item.Name,
+			Priority:  int(item.Priority),
+			Attempts:  item.Attempts,
+			LastError: item.LastError,
+			CreatedAt: item.CreatedAt,
+			FailedAt:  failedAt[item.ItemID],
 		}
-		return defaultCredits, nil
-	} else if err != nil {
-		return 0, fmt.Errorf("failed to get API credits: %w", err)
 	}
+	return resp, nil
+}
 
-	return credits, nil
+// requeueDead puts a dead-lettered job back in the queue with its attempts reset
+func (r *ItemQueueRepositoryImpl) requeueDead(ctx context.Context, item models.QueueItem) (bool, error) {
+	item.Attempts = 0
+	item.LastError = ""
+	return r.move(ctx, deadKey, queueKey, item, queueAge(item))
 }
 
-func (r *ItemQueueRepositoryImpl) DecrementAPICredits(ctx context.Context) error {
-	if err := r.redis.Decr(ctx, apiCreditsKey).Err(); err != nil {
-		return fmt.Errorf("failed to decrement API credits: %w", err)
+// RequeueDeadItem gives the dead-lettered job of an item another round of attempts
+func (r *ItemQueueRepositoryImpl) RequeueDeadItem(ctx context.Context, itemID uint) error {
+	item, err := r.jobs(ctx, deadKey, []string{jobID(itemID)})
+	if err != nil {
+		return err
+	}
+	if len(item) == 0 {
+		return ErrQueueItemNotFound
+	}
+
+	requeued, err := r.requeueDead(ctx, item[0])
+	if err != nil {
+		return err
+	}
+	if !requeued {
+		return ErrQueueItemNotFound
+	}
+	return nil
+}
+
+// RequeueDeadItems gives every dead-lettered job another round of attempts
+func (r *ItemQueueRepositoryImpl) RequeueDeadItems(ctx context.Context) (int, error) {
+	items, _, err := r.deadItems(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	for _, item := range items {
+		moved, err := r.requeueDead(ctx, item)
+		if err != nil {
+			return requeued, err
+		}
+		if moved {
+			requeued++
+		}
+	}
+	return requeued, nil
+}
+
+// RemoveItem drops the job of an item wherever it is, as when the item has been merged away. A
+// worker holding the job finds it gone when it reports back.
+func (r *ItemQueueRepositoryImpl) RemoveItem(ctx context.Context, itemID uint) error {
+	id := jobID(itemID)
+	_, err := r.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range []string{queueKey, inFlightKey, retryKey, deadKey} {
+			pipe.ZRem(ctx, key, id)
+		}
+		pipe.HDel(ctx, jobsKey, id)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove item from queue: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestQueue(t *testing.T) (*ItemQueueRepositoryImpl, *redis.Client) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewItemQueueRepository(client).(*ItemQueueRepositoryImpl), client
+}
+
+// testJob is a job for item id, created age ago
+func testJob(id uint, priority models.Priority, age time.Duration) models.QueueItem {
+	return models.QueueItem{ItemID: id, Name: "item", CreatedAt: time.Now().Add(-age), Priority: priority}
+}
+
+func addJobs(t *testing.T, queue ItemQueueRepository, jobs ...models.QueueItem) {
+	t.Helper()
+
+	for _, job := range jobs {
+		if err := queue.AddItem(context.Background(), job); err != nil {
+			t.Fatalf("AddItem(%d) error = %v", job.ItemID, err)
+		}
+	}
+}
+
+func claim(t *testing.T, queue ItemQueueRepository, n int, lease time.Duration) []models.QueueItem {
+	t.Helper()
+
+	jobs, err := queue.ClaimBatch(context.Background(), n, lease)
+	if err != nil {
+		t.Fatalf("ClaimBatch() error = %v", err)
+	}
+	return jobs
+}
+
+func claimedIDs(jobs []models.QueueItem) []uint {
+	ids := make([]uint, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ItemID
+	}
+	return ids
+}
+
+func checkIDs(t *testing.T, what string, got []models.QueueItem, want ...uint) {
+	t.Helper()
+
+	ids := claimedIDs(got)
+	if len(ids) != len(want) {
+		t.Fatalf("%s = %v, want %v", what, ids, want)
+	}
+	for i := range ids {
+		if ids[i] != want[i] {
+			t.Fatalf("%s = %v, want %v", what, ids, want)
+		}
+	}
+}
+
+func checkStats(t *testing.T, queue ItemQueueRepository, want dtos.QueueStatsResponse) {
+	t.Helper()
+
+	stats, err := queue.GetQueueStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetQueueStats() error = %v", err)
+	}
+	if stats != want {
+		t.Errorf("GetQueueStats() = %+v, want %+v", stats, want)
+	}
+}
+
+// storedJob reads the job of item id as it is kept in Redis
+func storedJob(t *testing.T, queue *ItemQueueRepositoryImpl, id uint) models.QueueItem {
+	t.Helper()
+
+	jobs, err := queue.jobs(context.Background(), queueKey, []string{jobID(id)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("item %d has no job", id)
+	}
+	return jobs[0]
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := map[int]time.Duration{
+		0:   time.Minute,
+		1:   time.Minute,
+		2:   2 * time.Minute,
+		3:   4 * time.Minute,
+		5:   16 * time.Minute,
+		9:   256 * time.Minute,
+		10:  6 * time.Hour,
+		100: 6 * time.Hour,
+	}
+	for attempts, want := range tests {
+		if got := retryDelay(attempts); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestClaimBatch(t *testing.T) {
+	queue, _ := newTestQueue(t)
+	addJobs(t, queue, testJob(1, models.DefaultPriority, time.Hour), testJob(2, models.DefaultPriority, 2*time.Hour), testJob(3, models.DefaultPriority, 0))
+
+	checkIDs(t, "first claim", claim(t, queue, 2, time.Minute), 2, 1)
+	checkStats(t, queue, dtos.QueueStatsResponse{Queued: 1, InFlight: 2})
+
+	checkIDs(t, "second claim", claim(t, queue, 2, time.Minute), 3)
+	checkIDs(t, "claim of an empty queue", claim(t, queue, 2, time.Minute))
+	checkStats(t, queue, dtos.QueueStatsResponse{InFlight: 3})
+}
+
+func TestCompleteItem(t *testing.T) {
+	queue, _ := newTestQueue(t)
+	addJobs(t, queue, testJob(1, models.DefaultPriority, 0))
+	jobs := claim(t, queue, 1, time.Minute)
+
+	if err := queue.CompleteItem(context.Background(), jobs[0]); err != nil {
+		t.Fatalf("CompleteItem() error = %v", err)
+	}
+	checkStats(t, queue, dtos.QueueStatsResponse{})
+
+	// The job is gone, so the item can be queued afresh
+	addJobs(t, queue, testJob(1, models.DefaultPriority, 0))
+	checkStats(t, queue, dtos.QueueStatsResponse{Queued: 1})
+}
+
+func TestReleaseItem(t *testing.T) {
+	queue, _ := newTestQueue(t)
+	addJobs(t, queue, testJob(1, models.DefaultPriority, 0))
+	jobs := claim(t, queue, 1, time.Minute)
+
+	if err := queue.ReleaseItem(context.Background(), jobs[0]); err != nil {
+		t.Fatalf("ReleaseItem() error = %v", err)
+	}
+	checkStats(t, queue, dtos.QueueStatsResponse{Queued: 1})
+	if job := storedJob(t, queue, 1); job.Attempts != 0 {
+		t.Errorf("released job has %d attempts, want 0", job.Attempts)
+	}
+}
+
+func TestClaimBatchRecoversExpiredLease(t *testing.T) {
+	queue, client := newTestQueue(t)
+	addJobs(t, queue, testJob(1, models.DefaultPriority, 0))
+
+	// A worker that never finishes: its lease has run out by the next claim
+	checkIDs(t, "claim", claim(t, queue, 1, -time.Second), 1)
+	checkIDs(t, "claim after the lease ran out", claim(t, queue, 1, time.Minute))
+	checkStats(t, queue, dtos.QueueStatsResponse{Retrying: 1})
+
+	job := storedJob(t, queue, 1)
+	if job.Attempts != 1 || job.LastError != errLeaseExpired.Error() {
+		t.Errorf("job after the lease ran out = %+v, want 1 attempt failed by the lease", job)
+	}
+	due, err := client.ZScore(context.Background(), retryKey, jobID(1)).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait := time.Until(time.Unix(int64(due), 0)); wait < baseRetryDelay-5*time.Second || wait > baseRetryDelay {
+		t.Errorf("retry due in %v, want %v", wait, baseRetryDelay)
+	}
+}
+
+func TestClaimBatchRequeuesDueRetries(t *testing.T) {
+	queue, client := newTestQueue(t)
+	addJobs(t, queue, testJob(1, models.DefaultPriority, 0), testJob(2, models.DefaultPriority, 0))
+	jobs := claim(t, queue, 2, time.Minute)
+	for _, job := range jobs {
+		if err := queue.RetryItem(context.Background(), job, errors.New("timeout")); err != nil {
+			t.Fatalf("RetryItem() error = %v", err)
+		}
+	}
+	checkStats(t, queue, dtos.QueueStatsResponse{Retrying: 2})
+
+	// Only the retry that has come due goes back to the queue
+	past := float64(time.Now().Add(-time.Second).Unix())
+	if err := client.ZAdd(context.Background(), retryKey, redis.Z{Score: past, Member: jobID(2)}).Err(); err != nil {
+		t.Fatal(err)
+	}
+	retried := claim(t, queue, 2, time.Minute)
+	checkIDs(t, "claim", retried, 2)
+	if retried[0].Attempts != 1 || retried[0].LastError != "timeout" {
+		t.Errorf("retried job = %+v, want 1 attempt failed by timeout", retried[0])
+	}
+	checkStats(t, queue, dtos.QueueStatsResponse{InFlight: 1, Retrying: 1})
+}
+
+func TestRetryItemDeadLetters(t *testing.T) {
+	queue, _ := newTestQueue(t)
+	job := testJob(1, models.DefaultPriority, 0)
+	job.Attempts = maxAttempts - 2
+	addJobs(t, queue, job)
+
+	jobs := claim(t, queue, 1, time.Minute)
+	if err := queue.RetryItem(context.Background(), jobs[0], errors.New("timeout")); err != nil {
+		t.Fatalf("RetryItem() error = %v", err)
+	}
+	checkStats(t, queue, dtos.QueueStatsResponse{Retrying: 1})
+
+	// The last attempt, after its lease ran out
+	jobs = claim(t, queue, 1, time.Minute)
+	if len(jobs) != 0 {
+		t.Fatalf("claimed %v before the retry was due", claimedIDs(jobs))
+	}
+	job = storedJob(t, queue, 1)
+	if _, err := queue.move(context.Background(), retryKey, inFlightKey, job, float64(time.Now().Add(-time.Second).Unix())); err != nil {
+		t.Fatal(err)
+	}
+	claim(t, queue, 1, time.Minute)
+	checkStats(t, queue, dtos.QueueStatsResponse{Dead: 1})
+
+	dead, err := queue.GetDeadItems(context.Background())
+	if err != nil {
+		t.Fatalf("GetDeadItems() error = %v", err)
+	}
+	if len(dead.Items) != 1 || dead.Items[0].ItemID != 1 || dead.Items[0].Attempts != maxAttempts || dead.Items[0].LastError != errLeaseExpired.Error() {
+		t.Errorf("GetDeadItems() = %+v, want item 1 after %d attempts", dead.Items, maxAttempts)
+	}
+}
+
+func TestRequeueDeadItem(t *testing.T) {
+	queue, _ := newTestQueue(t)
+	addJobs(t, queue, testJob(1, models.DefaultPriority, 0), testJob(2, models.DefaultPriority, 0))
+	for _, job := range claim(t, queue, 2, time.Minute) {
+		if err := queue.DeadLetterItem(context.Background(), job, errors.New("not found")); err != nil {
+			t.Fatalf("DeadLetterItem() error = %v", err)
+		}
+	}
+	checkStats(t, queue, dtos.QueueStatsResponse{Dead: 2})
+
+	if err := queue.RequeueDeadItem(context.Background(), 1); err != nil {
+		t.Fatalf("RequeueDeadItem() error = %v", err)
+	}
+	checkStats(t, queue, dtos.QueueStatsResponse{Queued: 1, Dead: 1})
+	if job := storedJob(t, queue, 1); job.Attempts != 0 || job.LastError != "" {
+		t.Errorf("requeued job = %+v, want its attempts reset", job)
+	}
+
+	// Only dead-lettered jobs can be requeued
+	for _, id := range []uint{1, 3} {
+		if err := queue.RequeueDeadItem(context.Background(), id); !errors.Is(err, ErrQueueItemNotFound) {
+			t.Errorf("RequeueDeadItem(%d) error = %v, want %v", id, err, ErrQueueItemNotFound)
+		}
+	}
+
+	n, err := queue.RequeueDeadItems(context.Background())
+	if err != nil || n != 1 {
+		t.Errorf("RequeueDeadItems() = %d, %v, want 1", n, err)
+	}
+	checkIDs(t, "claim", claim(t, queue, 2, time.Minute), 1, 2)
+}
+
+func TestAddItemKeepsOneJobPerItem(t *testing.T) {
+	queue, _ := newTestQueue(t)
+	addJobs(t, queue, testJob(1, models.DefaultPriority, time.Hour), testJob(1, models.DefaultPriority, 0))
+	checkStats(t, queue, dtos.QueueStatsResponse{Queued: 1})
+
+	// Nor is a second job made while the first is being worked on
+	claim(t, queue, 1, time.Minute)
+	addJobs(t, queue, testJob(1, models.DefaultPriority, 0))
+	checkStats(t, queue, dtos.QueueStatsResponse{InFlight: 1})
+}
+
+func TestAddItemRaisesPriority(t *testing.T) {
+	queue, _ := newTestQueue(t)
+	addJobs(t, queue, testJob(1, models.DefaultPriority, 2*time.Hour), testJob(2, models.DefaultPriority, time.Hour), testJob(3, models.LowPriority, 3*time.Hour))
+
+	// Raising the newer job moves it ahead of the older one
+	addJobs(t, queue, testJob(2, models.HighPriority, 0))
+	if job := storedJob(t, queue, 2); job.Priority != models.HighPriority {
+		t.Errorf("raised job priority = %d, want %d", job.Priority, models.HighPriority)
+	}
+
+	// A lower priority never takes over
+	addJobs(t, queue, testJob(2, models.LowPriority, 0), testJob(1, models.LowPriority, 0))
+	if job := storedJob(t, queue, 2); job.Priority != models.HighPriority {
+		t.Errorf("job priority after a lower one = %d, want %d", job.Priority, models.HighPriority)
+	}
+
+	checkIDs(t, "claim", claim(t, queue, 3, time.Minute), 2, 1, 3)
+}
+
+func TestAddItemRaisesPriorityOfClaimedJob(t *testing.T) {
+	queue, _ := newTestQueue(t)
+	addJobs(t, queue, testJob(1, models.LowPriority, 0), testJob(2, models.DefaultPriority, 0))
+	jobs := claim(t, queue, 1, time.Minute)
+	checkIDs(t, "claim", jobs, 2)
+
+	// The raise is kept for when the job goes back to the queue
+	addJobs(t, queue, testJob(2, models.HighPriority, 0))
+	checkStats(t, queue, dtos.QueueStatsResponse{Queued: 1, InFlight: 1})
+	if err := queue.ReleaseItem(context.Background(), jobs[0]); err != nil {
+		t.Fatalf("ReleaseItem() error = %v", err)
+	}
+	if job := storedJob(t, queue, 2); job.Priority != models.HighPriority {
+		t.Errorf("released job priority = %d, want %d", job.Priority, models.HighPriority)
+	}
+	checkIDs(t, "claim", claim(t, queue, 2, time.Minute), 2, 1)
+}
+
+func TestAddItemLeavesDeadJob(t *testing.T) {
+	queue, _ := newTestQueue(t)
+	addJobs(t, queue, testJob(1, models.DefaultPriority, 0))
+	jobs := claim(t, queue, 1, time.Minute)
+	if err := queue.DeadLetterItem(context.Background(), jobs[0], errors.New("not found")); err != nil {
+		t.Fatalf("DeadLetterItem() error = %v", err)
+	}
+
+	addJobs(t, queue, testJob(1, models.HighPriority, 0))
+	checkStats(t, queue, dtos.QueueStatsResponse{Dead: 1})
+	if job := storedJob(t, queue, 1); job.Priority != models.DefaultPriority || job.Attempts != 1 {
+		t.Errorf("dead job after AddItem = %+v, want it unchanged", job)
+	}
+}
+
+func TestRemoveItem(t *testing.T) {
+	queue, _ := newTestQueue(t)
+	addJobs(t, queue, testJob(1, models.DefaultPriority, 0), testJob(2, models.DefaultPriority, 0), testJob(3, models.DefaultPriority, 0))
+	jobs := claim(t, queue, 2, time.Minute)
+
+	for _, id := range []uint{1, 3} {
+		if err := queue.RemoveItem(context.Background(), id); err != nil {
+			t.Fatalf("RemoveItem(%d) error = %v", id, err)
+		}
+	}
+	checkStats(t, queue, dtos.QueueStatsResponse{InFlight: 1})
+
+	// The worker holding the removed job finds it gone
+	if err := queue.RetryItem(context.Background(), jobs[0], errors.New("timeout")); err != nil {
+		t.Fatalf("RetryItem() error = %v", err)
+	}
+	checkStats(t, queue, dtos.QueueStatsResponse{InFlight: 1})
+}
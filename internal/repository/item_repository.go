@@ -1,32 +1,105 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
 	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/matching"
 	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"github.com/GroceryTrak/GroceryTrakService/internal/units"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidItemMerge  = errors.New("an item cannot be merged into itself and needs at least one duplicate")
+	ErrItemMergeConflict = errors.New("the items are on the same recipe or shopping list in units that cannot be added up")
 )
 
 type ItemRepositoryImpl struct {
-	db *gorm.DB
+	db    *gorm.DB
+	queue ItemQueueRepository
 }
 
 type ItemRepository interface {
 	GetItem(id uint) (dtos.ItemResponse, error)
 	CreateItem(req dtos.ItemRequest) (dtos.ItemResponse, error)
 	UpdateItem(id uint, req dtos.ItemRequest) (dtos.ItemResponse, error)
+	EnrichItem(id uint, req dtos.ItemRequest) (dtos.ItemResponse, error)
+	SetEnrichmentStatus(id uint, status models.EnrichmentStatus) error
+	FindStaleItems(before time.Time) ([]models.Item, error)
 	DeleteItem(id uint) error
 	SearchItems(keyword string) (dtos.ItemsResponse, error)
+	FindDuplicateItems(threshold float64) (dtos.ItemDuplicatesResponse, error)
+	MergeItems(req dtos.ItemMergeRequest) (dtos.ItemResponse, error)
+}
+
+// NewItemRepository moves items users look at up the enrichment queue when queue is not nil
+func NewItemRepository(db *gorm.DB, queue ItemQueueRepository) ItemRepository {
+	return &ItemRepositoryImpl{db: db, queue: queue}
+}
+
+// prioritizeEnrichment queues an item that has not been enriched yet at high priority, or moves
+// its job up if it is already queued, because a user is looking at it or adding it. Failing to
+// only costs the user a wait, so errors are logged.
+func prioritizeEnrichment(ctx context.Context, queue ItemQueueRepository, item models.Item) {
+	if queue == nil || (item.EnrichmentStatus != models.EnrichmentPending && item.EnrichmentStatus != models.EnrichmentFailed) {
+		return
+	}
+
+	queueItem := models.QueueItem{
+		ItemID:    item.ID,
+		Name:      item.Name,
+		CreatedAt: time.Now(),
+		Priority:  models.HighPriority,
+	}
+	if err := queue.AddItem(ctx, queueItem); err != nil {
+		log.Printf("Failed to prioritize item %d for enrichment: %v", item.ID, err)
+	}
 }
 
-func NewItemRepository(db *gorm.DB) ItemRepository {
-	return &ItemRepositoryImpl{db: db}
+// markEnriched records that an item's data has just been filled in from source
+func markEnriched(item *models.Item, source models.EnrichmentSource) {
+	now := time.Now()
+	item.EnrichmentStatus = models.EnrichmentEnriched
+	item.EnrichmentSource = &source
+	item.EnrichedAt = &now
+}
+
+// toItemResponse maps an item without its nutrients, as embedded in pantry, recipe and list entries
+func toItemResponse(item models.Item) dtos.ItemResponse {
+	return dtos.ItemResponse{
+		ID:               item.ID,
+		Name:             item.Name,
+		Image:            item.Image,
+		SpoonacularID:    item.SpoonacularID,
+		Density:          item.Density,
+		Category:         string(item.Category),
+		ShelfLifeDays:    item.ShelfLifeDays,
+		EnrichmentStatus: string(item.EnrichmentStatus),
+		EnrichedAt:       item.EnrichedAt,
+	}
+}
+
+// itemDensity returns the item's density in g/ml, or 0 if it is unknown
+func itemDensity(item models.Item) float64 {
+	if item.Density == nil {
+		return 0
+	}
+	return *item.Density
 }
 
 func (r *ItemRepositoryImpl) GetItem(id uint) (dtos.ItemResponse, error) {
 	var item models.Item
-	if err := r.db.Preload("Nutrients").First(&item, "id = ?", id).Error; err != nil {
+	if err := r.db.Preload("Nutrients").Preload("Barcodes").First(&item, "id = ?", id).Error; err != nil {
 		return dtos.ItemResponse{}, err
 	}
+	prioritizeEnrichment(context.Background(), r.queue, item)
 
 	nutrients := make([]dtos.ItemNutrientResponse, len(item.Nutrients))
 	for i, n := range item.Nutrients {
@@ -38,12 +111,23 @@ func (r *ItemRepositoryImpl) GetItem(id uint) (dtos.ItemResponse, error) {
 		}
 	}
 
+	barcodes := make([]string, len(item.Barcodes))
+	for i, b := range item.Barcodes {
+		barcodes[i] = b.Code
+	}
+
 	return dtos.ItemResponse{
-		ID:            item.ID,
-		Name:          item.Name,
-		Image:         item.Image,
-		SpoonacularID: item.SpoonacularID,
-		Nutrients:     nutrients,
+		ID:               item.ID,
+		Name:             item.Name,
+		Image:            item.Image,
+		SpoonacularID:    item.SpoonacularID,
+		Density:          item.Density,
+		Category:         string(item.Category),
+		ShelfLifeDays:    item.ShelfLifeDays,
+		EnrichmentStatus: string(item.EnrichmentStatus),
+		EnrichedAt:       item.EnrichedAt,
+		Barcodes:         barcodes,
+		Nutrients:        nutrients,
 	}, nil
 }
 
@@ -57,6 +141,15 @@ func (r *ItemRepositoryImpl) CreateItem(req dtos.ItemRequest) (dtos.ItemResponse
 		Name:          req.Name,
 		Image:         req.Image,
 		SpoonacularID: req.SpoonacularID,
+		Density:       req.Density,
+		Category:      models.ItemCategory(req.Category),
+		ShelfLifeDays: req.ShelfLifeDays,
+	}
+	if item.Category == "" {
+		item.Category = models.OtherCategory
+	}
+	if len(req.Nutrients) > 0 {
+		markEnriched(&item, models.ManualEnrichment)
 	}
 
 	if err := tx.Create(&item).Error; err != nil {
@@ -95,15 +188,59 @@ func (r *ItemRepositoryImpl) CreateItem(req dtos.ItemRequest) (dtos.ItemResponse
 	}
 
 	return dtos.ItemResponse{
-		ID:            item.ID,
-		Name:          item.Name,
-		Image:         item.Image,
-		SpoonacularID: item.SpoonacularID,
-		Nutrients:     nutrientResponses,
+		ID:               item.ID,
+		Name:             item.Name,
+		Image:            item.Image,
+		SpoonacularID:    item.SpoonacularID,
+		Density:          item.Density,
+		Category:         string(item.Category),
+		ShelfLifeDays:    item.ShelfLifeDays,
+		EnrichmentStatus: string(item.EnrichmentStatus),
+		EnrichedAt:       item.EnrichedAt,
+		Nutrients:        nutrientResponses,
 	}, nil
 }
 
+// UpdateItem replaces an item's data by hand. Given nutrients, it counts as enriched manually and
+// is no longer refreshed from Spoonacular.
 func (r *ItemRepositoryImpl) UpdateItem(id uint, req dtos.ItemRequest) (dtos.ItemResponse, error) {
+	if len(req.Nutrients) == 0 {
+		return r.updateItem(id, req, nil)
+	}
+	source := models.ManualEnrichment
+	return r.updateItem(id, req, &source)
+}
+
+// EnrichItem replaces an item's data with what Spoonacular has on it
+func (r *ItemRepositoryImpl) EnrichItem(id uint, req dtos.ItemRequest) (dtos.ItemResponse, error) {
+	source := models.SpoonacularEnrichment
+	return r.updateItem(id, req, &source)
+}
+
+// SetEnrichmentStatus records that enriching an item failed or found nothing. An item enriched
+// before keeps its status, and the data it has, when refreshing it does not work out.
+func (r *ItemRepositoryImpl) SetEnrichmentStatus(id uint, status models.EnrichmentStatus) error {
+	return r.db.Model(&models.Item{}).
+		Where("id = ? AND enrichment_status <> ?", id, models.EnrichmentEnriched).
+		Update("enrichment_status", status).Error
+}
+
+// FindStaleItems returns the items waiting to be enriched and those last filled in from
+// Spoonacular before before, least recently enriched first
+func (r *ItemRepositoryImpl) FindStaleItems(before time.Time) ([]models.Item, error) {
+	var items []models.Item
+	if err := r.db.
+		Where("enrichment_status = ?", models.EnrichmentPending).
+		Or("enrichment_status = ? AND enrichment_source = ? AND enriched_at < ?", models.EnrichmentEnriched, models.SpoonacularEnrichment, before).
+		Order("enriched_at ASC NULLS FIRST, id ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// updateItem replaces an item's data and nutrients, marking it enriched from source unless nil
+func (r *ItemRepositoryImpl) updateItem(id uint, req dtos.ItemRequest, source *models.EnrichmentSource) (dtos.ItemResponse, error) {
 	var item models.Item
 	if err := r.db.First(&item, "id = ?", id).Error; err != nil {
 		return dtos.ItemResponse{}, err
@@ -127,6 +264,18 @@ func (r *ItemRepositoryImpl) UpdateItem(id uint, req dtos.ItemRequest) (dtos.Ite
 	item.Name = req.Name
 	item.Image = req.Image
 	item.SpoonacularID = req.SpoonacularID
+	if req.Density != nil {
+		item.Density = req.Density
+	}
+	if req.Category != "" {
+		item.Category = models.ItemCategory(req.Category)
+	}
+	if req.ShelfLifeDays != nil {
+		item.ShelfLifeDays = req.ShelfLifeDays
+	}
+	if source != nil {
+		markEnriched(&item, *source)
+	}
 
 	tx := r.db.Begin()
 	if tx.Error != nil {
@@ -162,11 +311,16 @@ func (r *ItemRepositoryImpl) UpdateItem(id uint, req dtos.ItemRequest) (dtos.Ite
 	}
 
 	return dtos.ItemResponse{
-		ID:            item.ID,
-		Name:          item.Name,
-		Image:         item.Image,
-		SpoonacularID: item.SpoonacularID,
-		Nutrients:     nutrients,
+		ID:               item.ID,
+		Name:             item.Name,
+		Image:            item.Image,
+		SpoonacularID:    item.SpoonacularID,
+		Density:          item.Density,
+		Category:         string(item.Category),
+		ShelfLifeDays:    item.ShelfLifeDays,
+		EnrichmentStatus: string(item.EnrichmentStatus),
+		EnrichedAt:       item.EnrichedAt,
+		Nutrients:        nutrients,
 	}, nil
 }
 
@@ -199,13 +353,318 @@ func (r *ItemRepositoryImpl) SearchItems(keyword string) (dtos.ItemsResponse, er
 		}
 
 		itemResponses = append(itemResponses, dtos.ItemResponse{
-			ID:            item.ID,
-			Name:          item.Name,
-			Image:         item.Image,
-			SpoonacularID: item.SpoonacularID,
-			Nutrients:     nutrients,
+			ID:               item.ID,
+			Name:             item.Name,
+			Image:            item.Image,
+			SpoonacularID:    item.SpoonacularID,
+			Density:          item.Density,
+			Category:         string(item.Category),
+			ShelfLifeDays:    item.ShelfLifeDays,
+			EnrichmentStatus: string(item.EnrichmentStatus),
+			EnrichedAt:       item.EnrichedAt,
+			Nutrients:        nutrients,
 		})
 	}
 
 	return dtos.ItemsResponse{Items: itemResponses}, nil
 }
+
+// FindDuplicateItems groups catalog items that are probably the same product: names equal after
+// normalization, or names at least threshold similar by trigrams. Similarity chains, so "Milk",
+// "milk" and "Whole milk" end up in one group; its score is that of its weakest link.
+func (r *ItemRepositoryImpl) FindDuplicateItems(threshold float64) (dtos.ItemDuplicatesResponse, error) {
+	if threshold <= 0 || threshold > 1 {
+		threshold = matching.DefaultDuplicateThreshold
+	}
+
+	var items []models.Item
+	if err := r.db.Order("id").Find(&items).Error; err != nil {
+		return dtos.ItemDuplicatesResponse{}, err
+	}
+
+	var pairs []struct {
+		AID   uint
+		BID   uint
+		Score float64
+	}
+	err := r.db.Raw(`SELECT a.id AS a_id, b.id AS b_id, similarity(LOWER(a.name), LOWER(b.name)) AS score
+		FROM items a JOIN items b ON a.id < b.id AND LOWER(a.name) % LOWER(b.name)
+		WHERE similarity(LOWER(a.name), LOWER(b.name)) >= ?`, threshold).Scan(&pairs).Error
+	if err != nil {
+		return dtos.ItemDuplicatesResponse{}, err
+	}
+
+	type edge struct {
+		a, b  uint
+		score float64
+	}
+	edges := make([]edge, 0, len(pairs))
+	byName := make(map[string]uint)
+	for _, item := range items {
+		name := matching.Normalize(item.Name)
+		if first, ok := byName[name]; ok {
+			edges = append(edges, edge{first, item.ID, 1})
+		} else {
+			byName[name] = item.ID
+		}
+	}
+	for _, pair := range pairs {
+		edges = append(edges, edge{pair.AID, pair.BID, pair.Score})
+	}
+
+	parent := make(map[uint]uint, len(items))
+	for _, item := range items {
+		parent[item.ID] = item.ID
+	}
+	var find func(id uint) uint
+	find = func(id uint) uint {
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+	for _, e := range edges {
+		if ra, rb := find(e.a), find(e.b); ra != rb {
+			parent[rb] = ra
+		}
+	}
+	scores := make(map[uint]float64)
+	for _, e := range edges {
+		root := find(e.a)
+		if score, ok := scores[root]; !ok || e.score < score {
+			scores[root] = e.score
+		}
+	}
+
+	members := make(map[uint][]models.Item)
+	for _, item := range items {
+		root := find(item.ID)
+		members[root] = append(members[root], item)
+	}
+
+	groups := []dtos.ItemDuplicateGroup{}
+	for root, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		suggested := group[0].ID
+		for _, item := range group {
+			if item.SpoonacularID != 0 {
+				suggested = item.ID
+				break
+			}
+		}
+		itemResponses := make([]dtos.ItemResponse, len(group))
+		for i, item := range group {
+			itemResponses[i] = toItemResponse(item)
+		}
+		groups = append(groups, dtos.ItemDuplicateGroup{
+			SuggestedID: suggested,
+			Score:       scores[root],
+			Items:       itemResponses,
+		})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Score != groups[j].Score {
+			return groups[i].Score > groups[j].Score
+		}
+		return groups[i].Items[0].ID < groups[j].Items[0].ID
+	})
+
+	return dtos.ItemDuplicatesResponse{Groups: groups}, nil
+}
+
+// MergeItems folds the duplicates into the kept item in one transaction. Pantry lots, recipe and
+// shopping list entries, scan lines, aliases and barcodes are repointed; where a recipe or list
+// already has the kept item, amounts in the same unit are added up and the duplicate entry is
+// dropped. The kept item takes over nutrients and details it lacks, including a duplicate's
+// enrichment when it has none of its own, and each duplicate's name becomes an alias so later
+// detections resolve to it. The duplicates' enrichment jobs are dropped.
+func (r *ItemRepositoryImpl) MergeItems(req dtos.ItemMergeRequest) (dtos.ItemResponse, error) {
+	duplicateIDs := make([]uint, 0, len(req.DuplicateIDs))
+	seen := make(map[uint]bool)
+	for _, id := range req.DuplicateIDs {
+		if id == req.ItemID {
+			return dtos.ItemResponse{}, ErrInvalidItemMerge
+		}
+		if !seen[id] {
+			seen[id] = true
+			duplicateIDs = append(duplicateIDs, id)
+		}
+	}
+	if len(duplicateIDs) == 0 {
+		return dtos.ItemResponse{}, ErrInvalidItemMerge
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var item models.Item
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, req.ItemID).Error; err != nil {
+			return err
+		}
+
+		var duplicates []models.Item
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("id").Find(&duplicates, duplicateIDs).Error; err != nil {
+			return err
+		}
+		if len(duplicates) != len(duplicateIDs) {
+			return gorm.ErrRecordNotFound
+		}
+
+		for _, duplicate := range duplicates {
+			if err := mergeItem(tx, &item, duplicate); err != nil {
+				return err
+			}
+		}
+
+		return tx.Save(&item).Error
+	})
+	if err != nil {
+		return dtos.ItemResponse{}, err
+	}
+
+	// The duplicates are gone, so their enrichment jobs have nothing left to fill in
+	if r.queue != nil {
+		for _, id := range duplicateIDs {
+			if err := r.queue.RemoveItem(context.Background(), id); err != nil {
+				log.Printf("Failed to drop the enrichment job of merged item %d: %v", id, err)
+			}
+		}
+	}
+
+	return r.GetItem(req.ItemID)
+}
+
+// mergeItem moves everything that references duplicate over to item and deletes duplicate
+func mergeItem(tx *gorm.DB, item *models.Item, duplicate models.Item) error {
+	if item.SpoonacularID == 0 {
+		item.SpoonacularID = duplicate.SpoonacularID
+	}
+	if item.Image == "" {
+		item.Image = duplicate.Image
+	}
+	if item.Density == nil {
+		item.Density = duplicate.Density
+	}
+	if item.ShelfLifeDays == nil {
+		item.ShelfLifeDays = duplicate.ShelfLifeDays
+	}
+	if item.Category == models.OtherCategory {
+		item.Category = duplicate.Category
+	}
+	if item.EnrichmentStatus != models.EnrichmentEnriched && duplicate.EnrichmentStatus == models.EnrichmentEnriched {
+		item.EnrichmentStatus = duplicate.EnrichmentStatus
+		item.EnrichmentSource = duplicate.EnrichmentSource
+		item.EnrichedAt = duplicate.EnrichedAt
+	}
+
+	if err := mergeRecipeItems(tx, *item, duplicate); err != nil {
+		return err
+	}
+	if err := mergeShoppingListItems(tx, *item, duplicate); err != nil {
+		return err
+	}
+
+	statements := []string{
+		"UPDATE user_items SET item_id = @item WHERE item_id = @duplicate",
+		"UPDATE recipe_items SET item_id = @item WHERE item_id = @duplicate",
+		"UPDATE shopping_list_items SET item_id = @item WHERE item_id = @duplicate",
+
+		`UPDATE item_nutrients SET item_id = @item WHERE item_id = @duplicate
+			AND name NOT IN (SELECT name FROM item_nutrients WHERE item_id = @item)`,
+		"DELETE FROM item_nutrients WHERE item_id = @duplicate",
+
+		"UPDATE scan_lines SET item_id = @item WHERE item_id = @duplicate",
+		"UPDATE scan_lines SET suggested_item_id = @item WHERE suggested_item_id = @duplicate",
+		"UPDATE item_aliases SET item_id = @item WHERE item_id = @duplicate",
+		"UPDATE item_barcodes SET item_id = @item WHERE item_id = @duplicate",
+		"UPDATE pantry_events SET item_id = @item WHERE item_id = @duplicate",
+		"UPDATE purchase_lines SET item_id = @item WHERE item_id = @duplicate",
+	}
+	args := map[string]interface{}{"item": item.ID, "duplicate": duplicate.ID}
+	for _, statement := range statements {
+		if err := tx.Exec(statement, args).Error; err != nil {
+			return err
+		}
+	}
+
+	if alias := matching.Normalize(duplicate.Name); alias != "" && alias != matching.Normalize(item.Name) {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "alias"}},
+			DoUpdates: clause.AssignmentColumns([]string{"item_id"}),
+		}).Create(&models.ItemAlias{ItemID: item.ID, Alias: alias}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Delete(&models.Item{}, duplicate.ID).Error
+}
+
+// mergeRecipeItems adds the duplicate's amount to item's on the recipes that call for both and
+// drops the duplicate's entry there. Amounts whose units cannot be added up fail the merge.
+func mergeRecipeItems(tx *gorm.DB, item, duplicate models.Item) error {
+	var duplicateEntries []models.RecipeItem
+	if err := tx.Where("item_id = ?", duplicate.ID).Find(&duplicateEntries).Error; err != nil {
+		return err
+	}
+
+	for _, d := range duplicateEntries {
+		var kept models.RecipeItem
+		err := tx.Where("recipe_id = ? AND item_id = ?", d.RecipeID, item.ID).First(&kept).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		total, err := units.Sum(float64(kept.Amount), kept.Unit, float64(d.Amount), d.Unit, itemDensity(item))
+		if err != nil {
+			return fmt.Errorf("%w: recipe %d has %s and %s", ErrItemMergeConflict, d.RecipeID, kept.Unit, d.Unit)
+		}
+
+		if err := tx.Model(&models.RecipeItem{}).
+			Where("recipe_id = ? AND item_id = ?", d.RecipeID, item.ID).
+			Update("amount", total).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("recipe_id = ? AND item_id = ?", d.RecipeID, duplicate.ID).Delete(&models.RecipeItem{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeShoppingListItems adds the duplicate's amount to item's on the lists that have both and
+// drops the duplicate's line there. The line stays checked only if both were. Amounts whose
+// units cannot be added up fail the merge.
+func mergeShoppingListItems(tx *gorm.DB, item, duplicate models.Item) error {
+	var duplicateLines []models.ShoppingListItem
+	if err := tx.Where("item_id = ?", duplicate.ID).Find(&duplicateLines).Error; err != nil {
+		return err
+	}
+
+	for _, d := range duplicateLines {
+		var kept models.ShoppingListItem
+		err := tx.Where("shopping_list_id = ? AND item_id = ?", d.ShoppingListID, item.ID).First(&kept).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		total, err := units.Sum(float64(kept.Amount), kept.Unit, float64(d.Amount), d.Unit, itemDensity(item))
+		if err != nil {
+			return fmt.Errorf("%w: shopping list %d has %s and %s", ErrItemMergeConflict, d.ShoppingListID, kept.Unit, d.Unit)
+		}
+
+		if err := tx.Model(&models.ShoppingListItem{}).
+			Where("shopping_list_id = ? AND item_id = ?", d.ShoppingListID, item.ID).
+			Updates(map[string]interface{}{"amount": total, "checked": kept.Checked && d.Checked}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("shopping_list_id = ? AND item_id = ?", d.ShoppingListID, duplicate.ID).Delete(&models.ShoppingListItem{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+)
+
+func TestMergeItemsTakesOverEnrichment(t *testing.T) {
+	db := openTestDB(t)
+	duplicate := createTestItem(t, db, "Whole milk")
+	item := models.Item{Name: "Milk", Category: models.DairyCategory}
+	if err := db.Create(&item).Error; err != nil {
+		t.Fatal(err)
+	}
+	queue := &fakeQueue{}
+	repo := NewItemRepository(db, queue)
+
+	merged, err := repo.MergeItems(dtos.ItemMergeRequest{ItemID: item.ID, DuplicateIDs: []uint{duplicate.ID}})
+	if err != nil {
+		t.Fatalf("MergeItems() error = %v", err)
+	}
+	if merged.EnrichmentStatus != string(models.EnrichmentEnriched) || merged.EnrichedAt == nil {
+		t.Errorf("merged item = %+v, want the duplicate's enrichment", merged)
+	}
+
+	var kept models.Item
+	if err := db.First(&kept, item.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if kept.EnrichmentSource == nil || *kept.EnrichmentSource != models.ManualEnrichment {
+		t.Errorf("kept item enrichment source = %v, want %s", kept.EnrichmentSource, models.ManualEnrichment)
+	}
+
+	if len(queue.removed) != 1 || queue.removed[0] != duplicate.ID {
+		t.Errorf("removed jobs %v, want the duplicate's", queue.removed)
+	}
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"github.com/GroceryTrak/GroceryTrakService/internal/units"
+	"gorm.io/gorm"
+)
+
+type PurchaseRepository interface {
+	GetPurchases(query dtos.PurchaseQuery, owner Owner) (dtos.PurchasesResponse, error)
+	GetPurchase(purchaseID uint, owner Owner) (dtos.PurchaseResponse, error)
+	CreatePurchase(req dtos.PurchaseRequest, owner Owner) (dtos.PurchaseResponse, error)
+	DeletePurchase(purchaseID uint, owner Owner) error
+	GetItemPriceHistory(itemID uint, unit string, owner Owner) (dtos.ItemPriceHistoryResponse, error)
+}
+
+type PurchaseRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPurchaseRepository(db *gorm.DB) PurchaseRepository {
+	return &PurchaseRepositoryImpl{db: db}
+}
+
+func toPurchaseResponse(purchase models.Purchase) dtos.PurchaseResponse {
+	lines := make([]dtos.PurchaseLineResponse, len(purchase.Lines))
+	for i, line := range purchase.Lines {
+		lines[i] = dtos.PurchaseLineResponse{
+			ID:     line.ID,
+			Name:   line.Name,
+			Amount: line.Amount,
+			Unit:   line.Unit,
+			Price:  line.Price,
+		}
+		if line.Item != nil {
+			item := toItemResponse(*line.Item)
+			lines[i].Item = &item
+		}
+	}
+
+	return dtos.PurchaseResponse{
+		ID:          purchase.ID,
+		Store:       purchase.Store,
+		PurchasedAt: purchase.PurchasedAt,
+		Currency:    purchase.Currency,
+		Total:       purchase.Total,
+		Lines:       lines,
+	}
+}
+
+func (r *PurchaseRepositoryImpl) GetPurchases(query dtos.PurchaseQuery, owner Owner) (dtos.PurchasesResponse, error) {
+	db := r.db.Preload("Lines.Item").Scopes(owner.scope("purchases"))
+	if query.From != nil {
+		db = db.Where("purchases.purchased_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		db = db.Where("purchases.purchased_at < ?", query.To.AddDate(0, 0, 1))
+	}
+	if query.Store != "" {
+		db = db.Where("LOWER(purchases.store) = LOWER(?)", query.Store)
+	}
+
+	var purchases []models.Purchase
+	if err := db.Order("purchases.purchased_at DESC, purchases.id DESC").Find(&purchases).Error; err != nil {
+		return dtos.PurchasesResponse{}, err
+	}
+
+	purchaseResponses := make([]dtos.PurchaseResponse, len(purchases))
+	for i, purchase := range purchases {
+		purchaseResponses[i] = toPurchaseResponse(purchase)
+	}
+
+	return dtos.PurchasesResponse{Purchases: purchaseResponses}, nil
+}
+
+func (r *PurchaseRepositoryImpl) GetPurchase(purchaseID uint, owner Owner) (dtos.PurchaseResponse, error) {
+	var purchase models.Purchase
+	err := r.db.Preload("Lines.Item").Scopes(owner.scope("purchases")).First(&purchase, "purchases.id = ?", purchaseID).Error
+	if err != nil {
+		return dtos.PurchaseResponse{}, err
+	}
+
+	return toPurchaseResponse(purchase), nil
+}
+
+func (r *PurchaseRepositoryImpl) CreatePurchase(req dtos.PurchaseRequest, owner Owner) (dtos.PurchaseResponse, error) {
+	purchase := models.Purchase{
+		UserID:      owner.UserID,
+		HouseholdID: owner.HouseholdID,
+		Store:       req.Store,
+		PurchasedAt: time.Now(),
+		Currency:    req.Currency,
+		Total:       req.Total,
+		Lines:       make([]models.PurchaseLine, len(req.Lines)),
+	}
+	if req.PurchasedAt != nil {
+		purchase.PurchasedAt = *req.PurchasedAt
+	}
+
+	var total float64
+	for i, lineReq := range req.Lines {
+		var item models.Item
+		if err := r.db.First(&item, lineReq.ItemID).Error; err != nil {
+			return dtos.PurchaseResponse{}, err
+		}
+
+		purchase.Lines[i] = models.PurchaseLine{
+			ItemID: &item.ID,
+			Name:   item.Name,
+			Amount: lineReq.Amount,
+			Unit:   units.Canonical(lineReq.Unit),
+			Price:  lineReq.Price,
+			Item:   &item,
+		}
+		total += lineReq.Price
+	}
+	if purchase.Total == 0 {
+		purchase.Total = total
+	}
+
+	if err := r.db.Omit("Lines.Item").Create(&purchase).Error; err != nil {
+		return dtos.PurchaseResponse{}, err
+	}
+
+	return toPurchaseResponse(purchase), nil
+}
+
+func (r *PurchaseRepositoryImpl) DeletePurchase(purchaseID uint, owner Owner) error {
+	result := r.db.Scopes(owner.scope("purchases")).Delete(&models.Purchase{}, "id = ?", purchaseID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// recordPurchase records one item bought outside a receipt, such as a pantry item added with the
+// price paid for it
+func recordPurchase(tx *gorm.DB, req dtos.UserItemRequest, item models.Item, amount float32, unit string, owner Owner) error {
+	purchase := models.Purchase{
+		UserID:      owner.UserID,
+		HouseholdID: owner.HouseholdID,
+		Store:       req.Store,
+		PurchasedAt: time.Now(),
+		Currency:    req.Currency,
+		Total:       *req.Price,
+		Lines: []models.PurchaseLine{{
+			ItemID: &item.ID,
+			Name:   item.Name,
+			Amount: amount,
+			Unit:   unit,
+			Price:  *req.Price,
+		}},
+	}
+	if req.PurchasedAt != nil {
+		purchase.PurchasedAt = *req.PurchasedAt
+	}
+
+	return tx.Create(&purchase).Error
+}
+
+// pricedLine is a purchase line of an item with the purchase it belongs to
+type pricedLine struct {
+	PurchaseID  uint
+	ItemID      uint
+	Amount      float32
+	Unit        string
+	Price       float64
+	Store       string
+	PurchasedAt time.Time
+	Currency    string
+}
+
+// findPricedLines returns the owner's purchase lines of the given items, newest first
+func findPricedLines(db *gorm.DB, itemIDs []uint, owner Owner) ([]pricedLine, error) {
+	var lines []pricedLine
+	err := db.Table("purchase_lines").
+		Select("purchase_lines.purchase_id, purchase_lines.item_id, purchase_lines.amount, purchase_lines.unit, purchase_lines.price, purchases.store, purchases.purchased_at, purchases.currency").
+		Joins("JOIN purchases ON purchases.id = purchase_lines.purchase_id").
+		Scopes(owner.scope("purchases")).
+		Where("purchase_lines.item_id IN ?", itemIDs).
+		Order("purchases.purchased_at DESC, purchase_lines.id DESC").
+		Scan(&lines).Error
+	return lines, err
+}
+
+// averageUnitPrice is the price per unit over the lines whose amount converts to unit, weighted
+// by the amount bought
+func averageUnitPrice(lines []pricedLine, unit string, density float64) (float64, bool) {
+	var price, amount float64
+	for _, line := range lines {
+		converted, err := units.Convert(float64(line.Amount), line.Unit, unit, density)
+		if err != nil || converted <= 0 {
+			continue
+		}
+		price += line.Price
+		amount += converted
+	}
+	if amount == 0 {
+		return 0, false
+	}
+	return price / amount, true
+}
+
+// GetItemPriceHistory lists what the owner paid for an item, with average unit prices overall and
+// per store. Prices are per the given unit, or per the unit of the latest purchase when none is given.
+func (r *PurchaseRepositoryImpl) GetItemPriceHistory(itemID uint, unit string, owner Owner) (dtos.ItemPriceHistoryResponse, error) {
+	var item models.Item
+	if err := r.db.First(&item, itemID).Error; err != nil {
+		return dtos.ItemPriceHistoryResponse{}, err
+	}
+
+	lines, err := findPricedLines(r.db, []uint{itemID}, owner)
+	if err != nil {
+		return dtos.ItemPriceHistoryResponse{}, err
+	}
+
+	if strings.TrimSpace(unit) == "" && len(lines) > 0 {
+		unit = lines[0].Unit
+	}
+	unit = units.Canonical(unit)
+	density := itemDensity(item)
+
+	history := dtos.ItemPriceHistoryResponse{
+		Item:   toItemResponse(item),
+		Unit:   unit,
+		Stores: []dtos.StorePriceResponse{},
+		Prices: make([]dtos.ItemPriceResponse, len(lines)),
+	}
+	if average, ok := averageUnitPrice(lines, unit, density); ok {
+		history.AverageUnitPrice = &average
+	}
+
+	byStore := make(map[string][]pricedLine)
+	for i, line := range lines {
+		history.Prices[i] = dtos.ItemPriceResponse{
+			PurchaseID:  line.PurchaseID,
+			Store:       line.Store,
+			PurchasedAt: line.PurchasedAt,
+			Currency:    line.Currency,
+			Amount:      line.Amount,
+			Unit:        line.Unit,
+			Price:       line.Price,
+		}
+		if unitPrice, ok := averageUnitPrice([]pricedLine{line}, unit, density); ok {
+			history.Prices[i].UnitPrice = &unitPrice
+		}
+		byStore[line.Store] = append(byStore[line.Store], line)
+	}
+
+	for store, storeLines := range byStore {
+		if average, ok := averageUnitPrice(storeLines, unit, density); ok {
+			history.Stores = append(history.Stores, dtos.StorePriceResponse{
+				Store:            store,
+				AverageUnitPrice: average,
+				Purchases:        len(storeLines),
+			})
+		}
+	}
+	sort.Slice(history.Stores, func(i, j int) bool {
+		return history.Stores[i].AverageUnitPrice < history.Stores[j].AverageUnitPrice
+	})
+
+	return history, nil
+}
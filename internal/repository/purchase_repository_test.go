@@ -0,0 +1,33 @@
+package repository
+
+import "testing"
+
+func TestGetItemPriceHistoryWithoutUnit(t *testing.T) {
+	db := openTestDB(t)
+	owner := createTestOwner(t, db, "prices")
+	rice := createTestItem(t, db, "Rice")
+	createTestPurchase(t, db, owner, rice, 2, "kg", 5)
+	createTestPurchase(t, db, owner, rice, 500, "g", 2)
+	repo := NewPurchaseRepository(db)
+
+	history, err := repo.GetItemPriceHistory(rice.ID, "", owner)
+	if err != nil {
+		t.Fatalf("GetItemPriceHistory() error = %v", err)
+	}
+
+	// Priced per the unit of the latest purchase
+	if history.Unit != "g" {
+		t.Errorf("GetItemPriceHistory() unit = %q, want g", history.Unit)
+	}
+	if history.AverageUnitPrice == nil || *history.AverageUnitPrice != 7.0/2500 {
+		t.Errorf("GetItemPriceHistory() average = %v, want %v", history.AverageUnitPrice, 7.0/2500)
+	}
+	for _, price := range history.Prices {
+		if price.UnitPrice == nil {
+			t.Errorf("purchase %d has no unit price", price.PurchaseID)
+		}
+	}
+	if len(history.Stores) != 1 {
+		t.Errorf("GetItemPriceHistory() stores = %+v, want one", history.Stores)
+	}
+}
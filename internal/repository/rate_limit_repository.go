@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const spoonacularRateLimitKey = "spoonacular_rate_limit"
+
+// takeTokensScript is a token bucket kept in Redis, so that every worker instance draws from the
+// same one. It refills at ARGV[1] tokens a second up to ARGV[2] and takes ARGV[3] tokens when
+// that many are there. It returns 0 when they were taken, or else how many milliseconds to wait
+// before they will be. Redis's clock is used so that instances on skewed clocks agree.
+var takeTokensScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1]) or burst
+local updated = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate / 1000)
+
+local wait = 0
+if tokens >= cost then
+	tokens = tokens - cost
+else
+	wait = math.ceil((cost - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return wait
+`)
+
+// RateLimitRepository spaces out calls to an external API across every process sharing Redis
+type RateLimitRepository interface {
+	// Wait blocks until cost calls may be made, or ctx is done
+	Wait(ctx context.Context, cost int) error
+}
+
+type RateLimitRepositoryImpl struct {
+	redis *redis.Client
+	key   string
+	rate  float64
+	burst int
+}
+
+// NewRateLimitRepository allows rate Spoonacular calls a second on average, and up to burst at
+// once after a quiet spell
+func NewRateLimitRepository(redis *redis.Client, rate float64, burst int) RateLimitRepository {
+	return &RateLimitRepositoryImpl{
+		redis: redis,
+		key:   spoonacularRateLimitKey,
+		rate:  rate,
+		burst: max(burst, 1),
+	}
+}
+
+func (r *RateLimitRepositoryImpl) Wait(ctx context.Context, cost int) error {
+	// More than a full bucket would never be available
+	cost = min(cost, r.burst)
+
+	for {
+		wait, err := takeTokensScript.Run(ctx, r.redis, []string{r.key}, r.rate, r.burst, cost).Int64()
+		if err != nil {
+			return fmt.Errorf("failed to take rate limit tokens: %w", err)
+		}
+		if wait == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(time.Duration(wait) * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
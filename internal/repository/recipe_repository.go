@@ -2,9 +2,10 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"net/http"
+	"errors"
+	"math"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -12,6 +13,8 @@ import (
 	"github.com/GroceryTrak/GroceryTrakService/internal/clients"
 	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
 	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"github.com/GroceryTrak/GroceryTrakService/internal/planner"
+	"github.com/GroceryTrak/GroceryTrakService/internal/units"
 	"gorm.io/gorm"
 )
 
@@ -21,6 +24,7 @@ type RecipeRepository interface {
 	UpdateRecipe(id uint, req dtos.RecipeRequest) (*dtos.RecipeResponse, error)
 	DeleteRecipe(id uint) error
 	SearchRecipes(query dtos.RecipeQuery) (dtos.RecipesResponse, error)
+	SuggestRecipes(query dtos.RecipeSuggestQuery, owner Owner) (dtos.RecipeSuggestionsResponse, error)
 }
 
 type RecipeRepositoryImpl struct {
@@ -45,12 +49,7 @@ func (r *RecipeRepositoryImpl) GetRecipe(id uint) (*dtos.RecipeResponse, error)
 	ingredients := make([]dtos.RecipeItemResponse, len(recipe.Ingredients))
 	for i, item := range recipe.Ingredients {
 		ingredients[i] = dtos.RecipeItemResponse{
-			Item: dtos.ItemResponse{
-				ID:            item.Item.ID,
-				Name:          item.Item.Name,
-				Image:         item.Item.Image,
-				SpoonacularID: item.Item.SpoonacularID,
-			},
+			Item:   toItemResponse(item.Item),
 			Amount: item.Amount,
 			Unit:   item.Unit,
 		}
@@ -125,7 +124,7 @@ func (r *RecipeRepositoryImpl) CreateRecipe(req dtos.RecipeRequest) (*dtos.Recip
 			RecipeID: recipe.ID,
 			ItemID:   item.ItemID,
 			Amount:   item.Amount,
-			Unit:     item.Unit,
+			Unit:     units.Canonical(item.Unit),
 		}
 	}
 
@@ -177,12 +176,7 @@ func (r *RecipeRepositoryImpl) CreateRecipe(req dtos.RecipeRequest) (*dtos.Recip
 	ingredientResponses := make([]dtos.RecipeItemResponse, len(recipe.Ingredients))
 	for i, item := range recipe.Ingredients {
 		ingredientResponses[i] = dtos.RecipeItemResponse{
-			Item: dtos.ItemResponse{
-				ID:            item.Item.ID,
-				Name:          item.Item.Name,
-				Image:         item.Item.Image,
-				SpoonacularID: item.Item.SpoonacularID,
-			},
+			Item:   toItemResponse(item.Item),
 			Amount: item.Amount,
 			Unit:   item.Unit,
 		}
@@ -303,7 +297,7 @@ func (r *RecipeRepositoryImpl) UpdateRecipe(id uint, req dtos.RecipeRequest) (*d
 			RecipeID: id,
 			ItemID:   item.ItemID,
 			Amount:   item.Amount,
-			Unit:     item.Unit,
+			Unit:     units.Canonical(item.Unit),
 		}
 	}
 
@@ -329,12 +323,7 @@ func (r *RecipeRepositoryImpl) UpdateRecipe(id uint, req dtos.RecipeRequest) (*d
 	ingredientResponses := make([]dtos.RecipeItemResponse, len(recipe.Ingredients))
 	for i, item := range recipe.Ingredients {
 		ingredientResponses[i] = dtos.RecipeItemResponse{
-			Item: dtos.ItemResponse{
-				ID:            item.Item.ID,
-				Name:          item.Item.Name,
-				Image:         item.Item.Image,
-				SpoonacularID: item.Item.SpoonacularID,
-			},
+			Item:   toItemResponse(item.Item),
 			Amount: item.Amount,
 			Unit:   item.Unit,
 		}
@@ -452,92 +441,31 @@ func (r *RecipeRepositoryImpl) SearchRecipes(query dtos.RecipeQuery) (dtos.Recip
 			}
 		}
 
-		// Build API URL
-		apiURL := fmt.Sprintf("%s/recipes/complexSearch", r.spoonacular.GetBaseURL())
-		params := map[string]string{
-			"apiKey":                r.spoonacular.GetAPIKey(),
-			"addRecipeInstructions": "true",
-			"addRecipeNutrition":    "true",
-			"number":                "2",
-		}
-
+		params := url.Values{"number": {"2"}}
 		if len(ingredientNames) > 0 {
-			params["includeIngredients"] = strings.Join(ingredientNames, ",")
+			params.Set("includeIngredients", strings.Join(ingredientNames, ","))
 		}
 
 		if query.Diet != "" {
-			params["diet"] = query.Diet
+			params.Set("diet", query.Diet)
 		}
 
 		if query.Title != "" {
-			params["titleMatch"] = query.Title
+			params.Set("titleMatch", query.Title)
 		}
 
-		// Make API request
-		req, err := http.NewRequest("GET", apiURL, nil)
-		if err != nil {
+		apiRecipes, err := r.spoonacular.SearchRecipes(context.Background(), params)
+		if errors.Is(err, clients.ErrQuotaExhausted) {
+			// Nothing more to be found today
+			apiRecipes = nil
+		} else if err != nil {
 			return dtos.RecipesResponse{}, err
 		}
 
-		q := req.URL.Query()
-		for k, v := range params {
-			q.Add(k, v)
-		}
-		req.URL.RawQuery = q.Encode()
-
-		resp, err := r.spoonacular.GetClient().Do(req)
-		if err != nil {
-			return dtos.RecipesResponse{}, err
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return dtos.RecipesResponse{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-		}
-
-		var apiResponse struct {
-			Results []struct {
-				ID                 int     `json:"id"`
-				Title              string  `json:"title"`
-				Image              string  `json:"image"`
-				ReadyInMinutes     int     `json:"readyInMinutes"`
-				PreparationMinutes int     `json:"preparationMinutes"`
-				CookingMinutes     int     `json:"cookingMinutes"`
-				Servings           float32 `json:"servings"`
-				Summary            string  `json:"summary"`
-				Vegan              bool    `json:"vegan"`
-				Vegetarian         bool    `json:"vegetarian"`
-				Nutrition          struct {
-					Nutrients []struct {
-						Name                string  `json:"name"`
-						Amount              float64 `json:"amount"`
-						Unit                string  `json:"unit"`
-						PercentOfDailyNeeds float64 `json:"percentOfDailyNeeds"`
-					} `json:"nutrients"`
-					Ingredients []struct {
-						ID     int     `json:"id"`
-						Name   string  `json:"name"`
-						Amount float64 `json:"amount"`
-						Unit   string  `json:"unit"`
-					} `json:"ingredients"`
-				} `json:"nutrition"`
-				AnalyzedInstructions []struct {
-					Steps []struct {
-						Number int    `json:"number"`
-						Step   string `json:"step"`
-					} `json:"steps"`
-				} `json:"analyzedInstructions"`
-			} `json:"results"`
-		}
-
-		if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-			return dtos.RecipesResponse{}, err
-		}
-
-		totalCount = int64(len(apiResponse.Results))
+		totalCount = int64(len(apiRecipes))
 
 		// Process each recipe from API
-		for _, apiRecipe := range apiResponse.Results {
+		for _, apiRecipe := range apiRecipes {
 			// Check if recipe already exists
 			var existingRecipe models.Recipe
 			if err := r.db.Where("spoonacular_id = ?", apiRecipe.ID).First(&existingRecipe).Error; err == nil {
@@ -614,7 +542,7 @@ func (r *RecipeRepositoryImpl) SearchRecipes(query dtos.RecipeQuery) (dtos.Recip
 				recipe.Ingredients[i] = models.RecipeItem{
 					ItemID: item.ID,
 					Amount: float32(ing.Amount),
-					Unit:   ing.Unit,
+					Unit:   units.Canonical(ing.Unit),
 				}
 			}
 
@@ -632,12 +560,7 @@ func (r *RecipeRepositoryImpl) SearchRecipes(query dtos.RecipeQuery) (dtos.Recip
 		ingredients := make([]dtos.RecipeItemResponse, len(recipe.Ingredients))
 		for j, item := range recipe.Ingredients {
 			ingredients[j] = dtos.RecipeItemResponse{
-				Item: dtos.ItemResponse{
-					ID:            item.Item.ID,
-					Name:          item.Item.Name,
-					Image:         item.Item.Image,
-					SpoonacularID: item.Item.SpoonacularID,
-				},
+				Item:   toItemResponse(item.Item),
 				Amount: item.Amount,
 				Unit:   item.Unit,
 			}
@@ -686,3 +609,164 @@ func (r *RecipeRepositoryImpl) SearchRecipes(query dtos.RecipeQuery) (dtos.Recip
 		DietCounts: dietCounts,
 	}, nil
 }
+
+func toRecipeResponse(recipe models.Recipe) dtos.RecipeResponse {
+	ingredients := make([]dtos.RecipeItemResponse, len(recipe.Ingredients))
+	for i, item := range recipe.Ingredients {
+		ingredients[i] = dtos.RecipeItemResponse{
+			Item:   toItemResponse(item.Item),
+			Amount: item.Amount,
+			Unit:   item.Unit,
+		}
+	}
+
+	nutrients := make([]dtos.RecipeNutrientResponse, len(recipe.Nutrients))
+	for i, n := range recipe.Nutrients {
+		nutrients[i] = dtos.RecipeNutrientResponse{
+			Name:                n.Name,
+			Amount:              n.Amount,
+			Unit:                n.Unit,
+			PercentOfDailyNeeds: n.PercentOfDailyNeeds,
+		}
+	}
+
+	instructions := make([]dtos.RecipeInstructionResponse, len(recipe.Instructions))
+	for i, inst := range recipe.Instructions {
+		instructions[i] = dtos.RecipeInstructionResponse{
+			Number: inst.Number,
+			Step:   inst.Step,
+		}
+	}
+
+	return dtos.RecipeResponse{
+		ID:            recipe.ID,
+		Title:         recipe.Title,
+		Summary:       recipe.Summary,
+		SpoonacularID: recipe.SpoonacularID,
+		Instructions:  instructions,
+		Servings:      recipe.Servings,
+		ReadyTime:     recipe.ReadyTime,
+		CookingTime:   recipe.CookingTime,
+		PrepTime:      recipe.PrepTime,
+		Image:         recipe.Image,
+		KCal:          recipe.KCal,
+		Vegan:         recipe.Vegan,
+		Vegetarian:    recipe.Vegetarian,
+		Ingredients:   ingredients,
+		Nutrients:     nutrients,
+	}
+}
+
+// SuggestRecipes ranks every recipe by how much of it the owner's pantry covers, then by how few
+// ingredients are missing. The user's diet preference applies unless the query sets one.
+// Recipes are scored from their ingredients alone; only those on the requested page are loaded
+// in full.
+func (r *RecipeRepositoryImpl) SuggestRecipes(query dtos.RecipeSuggestQuery, owner Owner) (dtos.RecipeSuggestionsResponse, error) {
+	var userItems []models.UserItem
+	if err := r.db.Joins("Item").Scopes(owner.scope("user_items")).Order(fifoOrder).Find(&userItems).Error; err != nil {
+		return dtos.RecipeSuggestionsResponse{}, err
+	}
+
+	// The planner expects one pantry entry per item, so lots are summed first
+	pantry := dtos.UserItemsResponse{UserItems: aggregateUserItems(userItems)}
+
+	diet := query.Diet
+	if diet == "" {
+		var preference models.UserPreference
+		if err := r.db.Where("user_id = ?", owner.UserID).Limit(1).Find(&preference).Error; err != nil {
+			return dtos.RecipeSuggestionsResponse{}, err
+		}
+		diet = preference.Diet
+	}
+
+	recipesQuery := r.db.Model(&models.Recipe{}).Select("id")
+	validDiets := map[string]string{"vegan": "vegan", "vegetarian": "vegetarian"}
+	if dietField, exists := validDiets[strings.ToLower(diet)]; exists {
+		recipesQuery = recipesQuery.Where(dietField+" = ?", true)
+	}
+
+	var recipeItems []models.RecipeItem
+	if err := r.db.Joins("Item").
+		Where("recipe_items.recipe_id IN (?)", recipesQuery).
+		Order("recipe_items.recipe_id").
+		Find(&recipeItems).Error; err != nil {
+		return dtos.RecipeSuggestionsResponse{}, err
+	}
+
+	// Only the ingredients are needed to score a recipe; missing amounts are per recipe as
+	// written, so servings do not matter here
+	var scored []dtos.RecipeResponse
+	for _, recipeItem := range recipeItems {
+		if len(scored) == 0 || scored[len(scored)-1].ID != recipeItem.RecipeID {
+			scored = append(scored, dtos.RecipeResponse{ID: recipeItem.RecipeID, Servings: 1})
+		}
+		recipe := &scored[len(scored)-1]
+		recipe.Ingredients = append(recipe.Ingredients, dtos.RecipeItemResponse{
+			Item:   toItemResponse(recipeItem.Item),
+			Amount: recipeItem.Amount,
+			Unit:   recipeItem.Unit,
+		})
+	}
+
+	suggestions := make([]dtos.RecipeSuggestionResponse, len(scored))
+	for i := range scored {
+		recipe := &scored[i]
+		missingItems := planner.MissingItems(recipe, pantry, recipe.Servings)
+		matched := len(recipe.Ingredients) - len(missingItems)
+
+		suggestions[i] = dtos.RecipeSuggestionResponse{
+			Recipe:       dtos.RecipeResponse{ID: recipe.ID},
+			Coverage:     math.Round(float64(matched)/float64(len(recipe.Ingredients))*10000) / 100,
+			MatchedCount: matched,
+			MissingCount: len(missingItems),
+			MissingItems: missingItems,
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Coverage != b.Coverage {
+			return a.Coverage > b.Coverage
+		}
+		if a.MissingCount != b.MissingCount {
+			return a.MissingCount < b.MissingCount
+		}
+		return a.Recipe.ID < b.Recipe.ID
+	})
+
+	resp := dtos.RecipeSuggestionsResponse{
+		Suggestions: []dtos.RecipeSuggestionResponse{},
+		Page:        query.Page,
+		PageSize:    query.PageSize,
+		Count:       len(suggestions),
+	}
+	start := (query.Page - 1) * query.PageSize
+	if start >= len(suggestions) {
+		return resp, nil
+	}
+	page := suggestions[start:min(start+query.PageSize, len(suggestions))]
+
+	pageIDs := make([]uint, len(page))
+	for i, suggestion := range page {
+		pageIDs[i] = suggestion.Recipe.ID
+	}
+	var recipes []models.Recipe
+	if err := r.db.Preload("Ingredients.Item").Preload("Nutrients").Preload("Instructions").Find(&recipes, pageIDs).Error; err != nil {
+		return dtos.RecipeSuggestionsResponse{}, err
+	}
+	byID := make(map[uint]models.Recipe, len(recipes))
+	for _, recipe := range recipes {
+		byID[recipe.ID] = recipe
+	}
+
+	for _, suggestion := range page {
+		recipe, ok := byID[suggestion.Recipe.ID]
+		if !ok {
+			continue
+		}
+		suggestion.Recipe = toRecipeResponse(recipe)
+		resp.Suggestions = append(resp.Suggestions, suggestion)
+	}
+
+	return resp, nil
+}
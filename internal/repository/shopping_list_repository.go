@@ -0,0 +1,360 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"github.com/GroceryTrak/GroceryTrakService/internal/units"
+	"gorm.io/gorm"
+)
+
+// ErrShoppingListUnitConflict means an item is already on the list in a unit the added amount
+// cannot be converted to
+var ErrShoppingListUnitConflict = errors.New("item is already on the list in a unit that cannot be added to")
+
+type ShoppingListRepository interface {
+	GetAllShoppingLists(owner Owner) (dtos.ShoppingListsResponse, error)
+	GetShoppingList(listID uint, owner Owner) (dtos.ShoppingListResponse, error)
+	CreateShoppingList(req dtos.ShoppingListRequest, owner Owner) (dtos.ShoppingListResponse, error)
+	UpdateShoppingList(req dtos.ShoppingListRequest, listID uint, owner Owner) (dtos.ShoppingListResponse, error)
+	DeleteShoppingList(listID uint, owner Owner) error
+	AddShoppingListItem(req dtos.ShoppingListItemRequest, listID uint, owner Owner) (dtos.ShoppingListResponse, error)
+	AddShoppingListItems(reqs []dtos.ShoppingListItemRequest, listID uint, owner Owner) (dtos.ShoppingListResponse, error)
+	UpdateShoppingListItem(req dtos.ShoppingListItemRequest, listID, itemID uint, owner Owner) (dtos.ShoppingListResponse, error)
+	CheckShoppingListItem(req dtos.ShoppingListItemCheckRequest, listID, itemID uint, owner Owner) (dtos.ShoppingListResponse, error)
+	DeleteShoppingListItem(listID, itemID uint, owner Owner) error
+	MovePurchasedItems(listID uint, owner Owner) (dtos.UserItemsResponse, error)
+}
+
+type ShoppingListRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewShoppingListRepository(db *gorm.DB) ShoppingListRepository {
+	return &ShoppingListRepositoryImpl{db: db}
+}
+
+func toShoppingListResponse(list models.ShoppingList) dtos.ShoppingListResponse {
+	items := make([]dtos.ShoppingListItemResponse, len(list.Items))
+	for i, listItem := range list.Items {
+		items[i] = dtos.ShoppingListItemResponse{
+			Item:    toItemResponse(listItem.Item),
+			Amount:  listItem.Amount,
+			Unit:    listItem.Unit,
+			Checked: listItem.Checked,
+		}
+	}
+
+	return dtos.ShoppingListResponse{
+		ID:        list.ID,
+		Name:      list.Name,
+		CreatedAt: list.CreatedAt,
+		UpdatedAt: list.UpdatedAt,
+		Items:     items,
+	}
+}
+
+// estimateCosts fills in what the items on the lists are expected to cost, from the average unit
+// price the owner paid for them before
+func estimateCosts(db *gorm.DB, lists []dtos.ShoppingListResponse, owner Owner) error {
+	var itemIDs []uint
+	for _, list := range lists {
+		for _, listItem := range list.Items {
+			itemIDs = append(itemIDs, listItem.Item.ID)
+		}
+	}
+	if len(itemIDs) == 0 {
+		return nil
+	}
+
+	lines, err := findPricedLines(db, itemIDs, owner)
+	if err != nil {
+		return err
+	}
+	byItem := make(map[uint][]pricedLine)
+	for _, line := range lines {
+		byItem[line.ItemID] = append(byItem[line.ItemID], line)
+	}
+
+	for i := range lists {
+		list := &lists[i]
+		for j := range list.Items {
+			listItem := &list.Items[j]
+			var density float64
+			if listItem.Item.Density != nil {
+				density = *listItem.Item.Density
+			}
+			if price, ok := averageUnitPrice(byItem[listItem.Item.ID], listItem.Unit, density); ok {
+				cost := math.Round(price*float64(listItem.Amount)*100) / 100
+				listItem.EstimatedCost = &cost
+				list.EstimatedCost += cost
+			}
+		}
+		list.EstimatedCost = math.Round(list.EstimatedCost*100) / 100
+	}
+
+	return nil
+}
+
+// findShoppingList loads a list with its items, scoped to its owner
+func (r *ShoppingListRepositoryImpl) findShoppingList(db *gorm.DB, listID uint, owner Owner) (models.ShoppingList, error) {
+	var list models.ShoppingList
+	err := db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("checked, item_id")
+	}).Preload("Items.Item").Scopes(owner.scope("shopping_lists")).First(&list, "shopping_lists.id = ?", listID).Error
+	return list, err
+}
+
+func (r *ShoppingListRepositoryImpl) GetAllShoppingLists(owner Owner) (dtos.ShoppingListsResponse, error) {
+	var lists []models.ShoppingList
+	if err := r.db.Preload("Items.Item").Scopes(owner.scope("shopping_lists")).Order("updated_at DESC").Find(&lists).Error; err != nil {
+		return dtos.ShoppingListsResponse{}, err
+	}
+
+	listResponses := make([]dtos.ShoppingListResponse, len(lists))
+	for i, list := range lists {
+		listResponses[i] = toShoppingListResponse(list)
+	}
+	if err := estimateCosts(r.db, listResponses, owner); err != nil {
+		return dtos.ShoppingListsResponse{}, err
+	}
+
+	return dtos.ShoppingListsResponse{ShoppingLists: listResponses}, nil
+}
+
+func (r *ShoppingListRepositoryImpl) GetShoppingList(listID uint, owner Owner) (dtos.ShoppingListResponse, error) {
+	list, err := r.findShoppingList(r.db, listID, owner)
+	if err != nil {
+		return dtos.ShoppingListResponse{}, err
+	}
+
+	return r.withEstimatedCosts(toShoppingListResponse(list), owner)
+}
+
+func (r *ShoppingListRepositoryImpl) withEstimatedCosts(list dtos.ShoppingListResponse, owner Owner) (dtos.ShoppingListResponse, error) {
+	lists := []dtos.ShoppingListResponse{list}
+	if err := estimateCosts(r.db, lists, owner); err != nil {
+		return dtos.ShoppingListResponse{}, err
+	}
+	return lists[0], nil
+}
+
+func (r *ShoppingListRepositoryImpl) CreateShoppingList(req dtos.ShoppingListRequest, owner Owner) (dtos.ShoppingListResponse, error) {
+	list := models.ShoppingList{
+		UserID:      owner.UserID,
+		HouseholdID: owner.HouseholdID,
+		Name:        req.Name,
+	}
+
+	if err := r.db.Create(&list).Error; err != nil {
+		return dtos.ShoppingListResponse{}, err
+	}
+
+	return toShoppingListResponse(list), nil
+}
+
+func (r *ShoppingListRepositoryImpl) UpdateShoppingList(req dtos.ShoppingListRequest, listID uint, owner Owner) (dtos.ShoppingListResponse, error) {
+	list, err := r.findShoppingList(r.db, listID, owner)
+	if err != nil {
+		return dtos.ShoppingListResponse{}, err
+	}
+
+	list.Name = req.Name
+	if err := r.db.Omit("Items").Save(&list).Error; err != nil {
+		return dtos.ShoppingListResponse{}, err
+	}
+
+	return r.withEstimatedCosts(toShoppingListResponse(list), owner)
+}
+
+func (r *ShoppingListRepositoryImpl) DeleteShoppingList(listID uint, owner Owner) error {
+	result := r.db.Scopes(owner.scope("shopping_lists")).Delete(&models.ShoppingList{}, "id = ?", listID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// AddShoppingListItem adds an item to a list, increasing the amount if the item is already on it
+func (r *ShoppingListRepositoryImpl) AddShoppingListItem(req dtos.ShoppingListItemRequest, listID uint, owner Owner) (dtos.ShoppingListResponse, error) {
+	return r.AddShoppingListItems([]dtos.ShoppingListItemRequest{req}, listID, owner)
+}
+
+// AddShoppingListItems adds several items to a list in one transaction
+func (r *ShoppingListRepositoryImpl) AddShoppingListItems(reqs []dtos.ShoppingListItemRequest, listID uint, owner Owner) (dtos.ShoppingListResponse, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := r.findShoppingList(tx, listID, owner); err != nil {
+			return err
+		}
+
+		for _, req := range reqs {
+			if err := addShoppingListItem(tx, req, listID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return dtos.ShoppingListResponse{}, err
+	}
+
+	return r.touchAndGet(listID, owner)
+}
+
+// addShoppingListItem puts an item on the list, adding to the amount already there. An amount
+// that cannot be converted to the line's unit is refused rather than replacing it.
+func addShoppingListItem(tx *gorm.DB, req dtos.ShoppingListItemRequest, listID uint) error {
+	unit := units.Canonical(req.Unit)
+
+	var listItem models.ShoppingListItem
+	err := tx.Preload("Item").First(&listItem, "shopping_list_id = ? AND item_id = ?", listID, req.ItemID).Error
+	if err == gorm.ErrRecordNotFound {
+		listItem = models.ShoppingListItem{
+			ShoppingListID: listID,
+			ItemID:         req.ItemID,
+			Amount:         req.Amount,
+			Unit:           unit,
+		}
+		return tx.Create(&listItem).Error
+	} else if err != nil {
+		return err
+	}
+
+	total, err := units.Sum(float64(listItem.Amount), listItem.Unit, float64(req.Amount), unit, itemDensity(listItem.Item))
+	if err != nil {
+		return fmt.Errorf("%w: %s is listed in %s, not %s", ErrShoppingListUnitConflict, listItem.Item.Name, listItem.Unit, unit)
+	}
+	listItem.Amount = float32(total)
+	listItem.Checked = false
+	return tx.Omit("Item").Save(&listItem).Error
+}
+
+func (r *ShoppingListRepositoryImpl) UpdateShoppingListItem(req dtos.ShoppingListItemRequest, listID, itemID uint, owner Owner) (dtos.ShoppingListResponse, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := r.findShoppingList(tx, listID, owner); err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.ShoppingListItem{}).
+			Where("shopping_list_id = ? AND item_id = ?", listID, itemID).
+			Updates(map[string]interface{}{"amount": req.Amount, "unit": units.Canonical(req.Unit)})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return dtos.ShoppingListResponse{}, err
+	}
+
+	return r.touchAndGet(listID, owner)
+}
+
+func (r *ShoppingListRepositoryImpl) CheckShoppingListItem(req dtos.ShoppingListItemCheckRequest, listID, itemID uint, owner Owner) (dtos.ShoppingListResponse, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := r.findShoppingList(tx, listID, owner); err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.ShoppingListItem{}).
+			Where("shopping_list_id = ? AND item_id = ?", listID, itemID).
+			Update("checked", req.Checked)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return dtos.ShoppingListResponse{}, err
+	}
+
+	return r.touchAndGet(listID, owner)
+}
+
+func (r *ShoppingListRepositoryImpl) DeleteShoppingListItem(listID, itemID uint, owner Owner) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := r.findShoppingList(tx, listID, owner); err != nil {
+			return err
+		}
+
+		result := tx.Delete(&models.ShoppingListItem{}, "shopping_list_id = ? AND item_id = ?", listID, itemID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&models.ShoppingList{}).Where("id = ?", listID).Update("updated_at", gorm.Expr("NOW()")).Error
+	})
+}
+
+// MovePurchasedItems moves every checked item of a list into the owner's pantry as a new lot and
+// removes it from the list
+func (r *ShoppingListRepositoryImpl) MovePurchasedItems(listID uint, owner Owner) (dtos.UserItemsResponse, error) {
+	var userItemResponses []dtos.UserItemResponse
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		list, err := r.findShoppingList(tx, listID, owner)
+		if err != nil {
+			return err
+		}
+
+		change := pantryChange{eventType: models.AddedEvent, source: models.ShoppingListSource, owner: owner}
+		for _, listItem := range list.Items {
+			if !listItem.Checked {
+				continue
+			}
+
+			userItem := models.UserItem{
+				UserID:      owner.UserID,
+				HouseholdID: owner.HouseholdID,
+				ItemID:      listItem.ItemID,
+				Amount:      listItem.Amount,
+				Unit:        listItem.Unit,
+			}
+			prefillUserItem(&userItem, listItem.Item)
+			if err := tx.Create(&userItem).Error; err != nil {
+				return err
+			}
+			if err := change.log(tx, userItem, userItem.Amount, userItem.Unit); err != nil {
+				return err
+			}
+
+			if err := tx.Delete(&models.ShoppingListItem{}, "shopping_list_id = ? AND item_id = ?", listID, listItem.ItemID).Error; err != nil {
+				return err
+			}
+
+			userItemResponses = append(userItemResponses, toUserItemResponse(userItem, listItem.Item))
+		}
+
+		return tx.Model(&models.ShoppingList{}).Where("id = ?", listID).Update("updated_at", gorm.Expr("NOW()")).Error
+	})
+	if err != nil {
+		return dtos.UserItemsResponse{}, err
+	}
+
+	return dtos.UserItemsResponse{
+		UserItems: userItemResponses,
+	}, nil
+}
+
+// touchAndGet bumps the list's updated_at after an item change and returns the fresh list
+func (r *ShoppingListRepositoryImpl) touchAndGet(listID uint, owner Owner) (dtos.ShoppingListResponse, error) {
+	if err := r.db.Model(&models.ShoppingList{}).Where("id = ?", listID).Update("updated_at", gorm.Expr("NOW()")).Error; err != nil {
+		return dtos.ShoppingListResponse{}, err
+	}
+
+	return r.GetShoppingList(listID, owner)
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"github.com/GroceryTrak/GroceryTrakService/internal/productdb"
+	"gorm.io/gorm"
+)
+
+func newBarcodeRepository(db *gorm.DB, queue ItemQueueRepository) UserItemRepository {
+	return NewUserItemRepository(db, queue, nil, NewItemMatchRepository(db, 0), productdb.NewFixtureClient(productdb.SampleProducts...))
+}
+
+func TestAddBarcodeUserItemInvalidCode(t *testing.T) {
+	repo := NewUserItemRepository(nil, nil, nil, nil, productdb.NewFixtureClient(productdb.SampleProducts...))
+
+	_, err := repo.AddBarcodeUserItem(context.Background(), dtos.BarcodeRequest{Barcode: "3017620422004"}, Owner{UserID: 1})
+	if !errors.Is(err, productdb.ErrInvalidBarcode) {
+		t.Errorf("AddBarcodeUserItem() error = %v, want %v", err, productdb.ErrInvalidBarcode)
+	}
+}
+
+func TestAddBarcodeUserItemFromProductDatabase(t *testing.T) {
+	db := openTestDB(t)
+	owner := createTestOwner(t, db, "barcode")
+	queue := &fakeQueue{}
+	repo := newBarcodeRepository(db, queue)
+
+	resp, err := repo.AddBarcodeUserItem(context.Background(), dtos.BarcodeRequest{Barcode: "3017620422003"}, owner)
+	if err != nil {
+		t.Fatalf("AddBarcodeUserItem() error = %v", err)
+	}
+	if resp.Item.Name != "Nutella" || resp.Amount != 400 || resp.Unit != "g" {
+		t.Errorf("AddBarcodeUserItem() = %+v, want 400 g of a new Nutella item", resp)
+	}
+
+	// The code was unknown to the catalog, so a new item is created and queued for enrichment
+	if len(queue.added) == 0 || queue.added[0].ItemID != resp.Item.ID || queue.added[0].Priority != models.HighPriority {
+		t.Errorf("queued %+v, want the new item at high priority", queue.added)
+	}
+
+	var barcode models.ItemBarcode
+	if err := db.First(&barcode, "code = ?", "3017620422003").Error; err != nil {
+		t.Fatalf("barcode was not linked: %v", err)
+	}
+	if barcode.ItemID != resp.Item.ID || barcode.Amount != 400 || barcode.Unit != "g" {
+		t.Errorf("linked barcode = %+v, want 400 g of item %d", barcode, resp.Item.ID)
+	}
+
+	// A second scan resolves through the catalog to the same item
+	again, err := repo.AddBarcodeUserItem(context.Background(), dtos.BarcodeRequest{Barcode: "3017620422003", Amount: 2, Unit: "unit"}, owner)
+	if err != nil {
+		t.Fatalf("second AddBarcodeUserItem() error = %v", err)
+	}
+	if again.Item.ID != resp.Item.ID || again.Amount != 2 || again.Unit != "unit" {
+		t.Errorf("second AddBarcodeUserItem() = %+v, want 2 unit of item %d", again, resp.Item.ID)
+	}
+
+	var count int64
+	if err := db.Model(&models.Item{}).Where("name = ?", "Nutella").Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("catalog has %d Nutella items, want 1", count)
+	}
+}
+
+func TestAddBarcodeUserItemMatchesCatalog(t *testing.T) {
+	db := openTestDB(t)
+	owner := createTestOwner(t, db, "barcode-match")
+	pepsi := createTestItem(t, db, "Pepsi")
+	queue := &fakeQueue{}
+	repo := newBarcodeRepository(db, queue)
+
+	// Scanned as UPC-A, stored as EAN-13 in the product database
+	resp, err := repo.AddBarcodeUserItem(context.Background(), dtos.BarcodeRequest{Barcode: "012000161155"}, owner)
+	if err != nil {
+		t.Fatalf("AddBarcodeUserItem() error = %v", err)
+	}
+	if resp.Item.ID != pepsi.ID {
+		t.Errorf("AddBarcodeUserItem() item = %+v, want the existing Pepsi item", resp.Item)
+	}
+	if len(queue.added) != 0 {
+		t.Errorf("queued %+v, want nothing for an enriched item", queue.added)
+	}
+}
+
+func TestAddBarcodeUserItemUnknownCode(t *testing.T) {
+	db := openTestDB(t)
+	owner := createTestOwner(t, db, "barcode-unknown")
+	queue := &fakeQueue{}
+	repo := newBarcodeRepository(db, queue)
+
+	_, err := repo.AddBarcodeUserItem(context.Background(), dtos.BarcodeRequest{Barcode: "4006381333931"}, owner)
+	if !errors.Is(err, ErrUnknownBarcode) {
+		t.Errorf("AddBarcodeUserItem() error = %v, want %v", err, ErrUnknownBarcode)
+	}
+	if len(queue.added) != 0 {
+		t.Errorf("queued %+v for an unknown code, want nothing", queue.added)
+	}
+
+	// Linking the code to an item by hand makes it known
+	item := createTestItem(t, db, "Pencils")
+	resp, err := repo.AddBarcodeUserItem(context.Background(), dtos.BarcodeRequest{Barcode: "4006381333931", ItemID: item.ID}, owner)
+	if err != nil {
+		t.Fatalf("AddBarcodeUserItem() with an item error = %v", err)
+	}
+	if resp.Item.ID != item.ID || resp.Amount != 1 || resp.Unit != "unit" {
+		t.Errorf("AddBarcodeUserItem() with an item = %+v, want 1 unit of item %d", resp, item.ID)
+	}
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+)
+
+func TestCookRecipeSkipsUnconvertibleLots(t *testing.T) {
+	db := openTestDB(t)
+	owner := createTestOwner(t, db, "cook")
+	flour := createTestItem(t, db, "Flour")
+	bags := createTestLot(t, db, owner, flour, 2, "unit")
+	createTestLot(t, db, owner, flour, 300, "g")
+
+	recipe := models.Recipe{
+		Title:       "Bread",
+		Servings:    2,
+		Ingredients: []models.RecipeItem{{ItemID: flour.ID, Amount: 0.25, Unit: "kg"}},
+	}
+	if err := db.Omit("Ingredients.Item", "Ingredients.Recipe").Create(&recipe).Error; err != nil {
+		t.Fatal(err)
+	}
+	repo := NewUserItemRepository(db, nil, nil, nil, nil)
+
+	// Twice the recipe needs 0.5 kg; the bags do not convert, the 300 g lot does
+	resp, err := repo.CookRecipe(recipe.ID, owner, 4)
+	if err != nil {
+		t.Fatalf("CookRecipe() error = %v", err)
+	}
+
+	if len(resp.Changes) != 1 || resp.Changes[0].Unit != "g" || resp.Changes[0].Before != 300 || resp.Changes[0].After != 0 {
+		t.Errorf("CookRecipe() changes = %+v, want 300 g used up", resp.Changes)
+	}
+	if len(resp.Shortfalls) != 1 {
+		t.Fatalf("CookRecipe() shortfalls = %+v, want one", resp.Shortfalls)
+	}
+	shortfall := resp.Shortfalls[0]
+	if shortfall.Unit != "kg" || shortfall.Required != 0.5 || shortfall.Available != 0.3 || shortfall.Missing != 0.2 {
+		t.Errorf("CookRecipe() shortfall = %+v, want 0.2 of 0.5 kg missing", shortfall)
+	}
+
+	var left models.UserItem
+	if err := db.First(&left, bags.ID).Error; err != nil || left.Amount != 2 {
+		t.Errorf("lot in units = %+v, %v, want it untouched", left, err)
+	}
+}
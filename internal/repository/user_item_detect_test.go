@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/detector"
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+	"gorm.io/gorm"
+)
+
+var dragonFruit = detector.DetectedItem{Name: "Dragon fruit", Amount: 2, Unit: "unit", Confidence: 0.6}
+
+func newDetectRepository(db *gorm.DB, queue ItemQueueRepository, d detector.Detector) UserItemRepository {
+	return NewUserItemRepository(db, queue, d, NewItemMatchRepository(db, 0), nil)
+}
+
+func TestDetectUserItemsWithoutDetector(t *testing.T) {
+	repo := NewUserItemRepository(nil, nil, nil, nil, nil)
+
+	if _, err := repo.DetectUserItems(context.Background(), nil, models.DetectSource, Owner{UserID: 1}); !errors.Is(err, detector.ErrNotConfigured) {
+		t.Errorf("DetectUserItems() error = %v, want %v", err, detector.ErrNotConfigured)
+	}
+	if _, err := repo.ScanUserItems(context.Background(), nil, models.DetectSource, Owner{UserID: 1}); !errors.Is(err, detector.ErrNotConfigured) {
+		t.Errorf("ScanUserItems() error = %v, want %v", err, detector.ErrNotConfigured)
+	}
+}
+
+func TestDetectUserItemsDetectorError(t *testing.T) {
+	fake := detector.NewFakeDetector(detector.SampleItems...)
+	fake.Err = errors.New("service unavailable")
+	repo := NewUserItemRepository(nil, nil, fake, nil, nil)
+
+	if _, err := repo.DetectUserItems(context.Background(), nil, models.DetectSource, Owner{UserID: 1}); !errors.Is(err, fake.Err) {
+		t.Errorf("DetectUserItems() error = %v, want %v", err, fake.Err)
+	}
+	if _, err := repo.ScanUserItems(context.Background(), nil, models.DetectSource, Owner{UserID: 1}); !errors.Is(err, fake.Err) {
+		t.Errorf("ScanUserItems() error = %v, want %v", err, fake.Err)
+	}
+}
+
+func TestDetectUserItems(t *testing.T) {
+	for _, source := range []models.PantryEventSource{models.DetectSource, models.PredictSource} {
+		t.Run(string(source), func(t *testing.T) {
+			db := openTestDB(t)
+			owner := createTestOwner(t, db, "detect-"+string(source))
+			apple := createTestItem(t, db, "Apple")
+			milk := createTestItem(t, db, "Milk")
+			queue := &fakeQueue{}
+			fake := detector.NewFakeDetector(append(detector.SampleItems, dragonFruit)...)
+			repo := newDetectRepository(db, queue, fake)
+
+			resp, err := repo.DetectUserItems(context.Background(), nil, source, owner)
+			if err != nil {
+				t.Fatalf("DetectUserItems() error = %v", err)
+			}
+
+			if len(resp.UserItems) != 2 {
+				t.Fatalf("DetectUserItems() added %d items, want 2", len(resp.UserItems))
+			}
+			if resp.UserItems[0].Item.ID != apple.ID || resp.UserItems[0].Amount != 3 || resp.UserItems[0].Unit != "unit" {
+				t.Errorf("first added item = %+v, want 3 unit of Apple", resp.UserItems[0])
+			}
+			if resp.UserItems[1].Item.ID != milk.ID || resp.UserItems[1].Amount != 1 || resp.UserItems[1].Unit != "l" {
+				t.Errorf("second added item = %+v, want 1 l of Milk", resp.UserItems[1])
+			}
+
+			if resp.Scan == nil {
+				t.Fatal("DetectUserItems() returned no scan for the unmatched detection")
+			}
+			if resp.Scan.Status != string(models.ScanPending) || resp.Scan.Source != string(source) {
+				t.Errorf("scan status, source = %s, %s, want pending, %s", resp.Scan.Status, resp.Scan.Source, source)
+			}
+			if len(resp.Scan.Lines) != 1 || resp.Scan.Lines[0].Name != dragonFruit.Name || resp.Scan.Lines[0].Item != nil {
+				t.Errorf("scan lines = %+v, want one unassigned Dragon fruit line", resp.Scan.Lines)
+			}
+
+			var events []models.PantryEvent
+			if err := db.Where("user_id = ?", owner.UserID).Find(&events).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != 2 {
+				t.Fatalf("logged %d pantry events, want 2", len(events))
+			}
+			for _, event := range events {
+				if event.Type != models.AddedEvent || event.Source != source {
+					t.Errorf("pantry event %+v, want an added event from %s", event, source)
+				}
+			}
+
+			if len(queue.added) != 0 {
+				t.Errorf("queued %d items for enrichment, want none", len(queue.added))
+			}
+		})
+	}
+}
+
+func TestScanUserItemsCommit(t *testing.T) {
+	db := openTestDB(t)
+	owner := createTestOwner(t, db, "scan")
+	apple := createTestItem(t, db, "Apple")
+	queue := &fakeQueue{}
+	fake := detector.NewFakeDetector(append(detector.SampleItems, dragonFruit)...)
+	repo := newDetectRepository(db, queue, fake)
+
+	scan, err := repo.ScanUserItems(context.Background(), nil, models.DetectSource, owner)
+	if err != nil {
+		t.Fatalf("ScanUserItems() error = %v", err)
+	}
+	if len(scan.Lines) != 3 {
+		t.Fatalf("ScanUserItems() stored %d lines, want 3", len(scan.Lines))
+	}
+	if scan.Lines[0].Item == nil || scan.Lines[0].Item.ID != apple.ID {
+		t.Errorf("first line item = %+v, want Apple", scan.Lines[0].Item)
+	}
+
+	var count int64
+	if err := db.Model(&models.UserItem{}).Where("user_id = ?", owner.UserID).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("ScanUserItems() added %d pantry items, want none before the commit", count)
+	}
+
+	amount := float32(5)
+	req := dtos.ScanCommitRequest{Lines: []dtos.ScanLineDecision{
+		{ID: scan.Lines[0].ID, Action: "edit", Amount: &amount},
+		{ID: scan.Lines[1].ID, Action: "reject"},
+		{ID: scan.Lines[2].ID, Action: "accept"},
+	}}
+	resp, err := repo.CommitScan(context.Background(), req, scan.ID, owner)
+	if err != nil {
+		t.Fatalf("CommitScan() error = %v", err)
+	}
+
+	if len(resp.UserItems) != 2 {
+		t.Fatalf("CommitScan() added %d items, want 2", len(resp.UserItems))
+	}
+	if resp.UserItems[0].Item.ID != apple.ID || resp.UserItems[0].Amount != 5 {
+		t.Errorf("first added item = %+v, want 5 of Apple", resp.UserItems[0])
+	}
+	created := resp.UserItems[1].Item
+	if created.Name != dragonFruit.Name {
+		t.Errorf("second added item = %+v, want a new Dragon fruit item", created)
+	}
+
+	if len(queue.added) != 1 || queue.added[0].ItemID != created.ID || queue.added[0].Priority != models.HighPriority {
+		t.Errorf("queued %+v, want only the new item at high priority", queue.added)
+	}
+
+	if _, err := repo.CommitScan(context.Background(), req, scan.ID, owner); !errors.Is(err, ErrScanNotPending) {
+		t.Errorf("second CommitScan() error = %v, want %v", err, ErrScanNotPending)
+	}
+}
+
+func TestImportReceiptCommit(t *testing.T) {
+	db := openTestDB(t)
+	owner := createTestOwner(t, db, "receipt")
+	apple := createTestItem(t, db, "Apple")
+	fake := detector.NewFakeDetector()
+	fake.Receipt.Lines = append(fake.Receipt.Lines, detector.ReceiptLine{Name: "Dragon fruit", Amount: 2, Unit: "unit", Price: 4.5})
+	repo := newDetectRepository(db, &fakeQueue{}, fake)
+
+	resp, err := repo.ImportReceipt(context.Background(), nil, owner)
+	if err != nil {
+		t.Fatalf("ImportReceipt() error = %v", err)
+	}
+	if len(resp.UserItems) != 1 || resp.UserItems[0].Item.ID != apple.ID {
+		t.Errorf("ImportReceipt() added %+v, want only Apple", resp.UserItems)
+	}
+	if resp.Scan == nil || len(resp.Scan.Lines) != 2 {
+		t.Fatalf("ImportReceipt() scan = %+v, want Milk and Dragon fruit to review", resp.Scan)
+	}
+
+	req := dtos.ScanCommitRequest{Lines: []dtos.ScanLineDecision{
+		{ID: resp.Scan.Lines[0].ID, Action: "reject"},
+		{ID: resp.Scan.Lines[1].ID, Action: "accept"},
+	}}
+	committed, err := repo.CommitScan(context.Background(), req, resp.Scan.ID, owner)
+	if err != nil {
+		t.Fatalf("CommitScan() error = %v", err)
+	}
+	if len(committed.UserItems) != 1 {
+		t.Fatalf("CommitScan() added %d items, want 1", len(committed.UserItems))
+	}
+
+	var lines []models.PurchaseLine
+	if err := db.Order("id").Find(&lines, "purchase_id = ?", resp.Purchase.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("purchase has %d lines, want 3", len(lines))
+	}
+	if lines[1].ItemID != nil {
+		t.Errorf("rejected Milk line has item %d, want none", *lines[1].ItemID)
+	}
+	if lines[2].ItemID == nil || *lines[2].ItemID != committed.UserItems[0].Item.ID {
+		t.Errorf("committed Dragon fruit line has item %v, want %d", lines[2].ItemID, committed.UserItems[0].Item.ID)
+	}
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+)
+
+func TestDiscardUserItemWithoutUnit(t *testing.T) {
+	db := openTestDB(t)
+	owner := createTestOwner(t, db, "discard")
+	flour := createTestItem(t, db, "Flour")
+	createTestLot(t, db, owner, flour, 200, "g")
+	createTestLot(t, db, owner, flour, 300, "g")
+	createTestPurchase(t, db, owner, flour, 1, "kg", 4)
+	repo := NewUserItemRepository(db, nil, nil, nil, nil)
+
+	// Without a unit the amount is in the lots' unit
+	amount := float32(250)
+	resp, err := repo.DiscardUserItem(dtos.DiscardRequest{Reason: string(models.SpoiledReason), Amount: &amount}, flour.ID, owner)
+	if err != nil {
+		t.Fatalf("DiscardUserItem() error = %v", err)
+	}
+	if resp.Discarded != 250 || resp.Unit != "g" {
+		t.Errorf("DiscardUserItem() discarded %v %s, want 250 g", resp.Discarded, resp.Unit)
+	}
+	if resp.EstimatedCost == nil || *resp.EstimatedCost != 1 {
+		t.Errorf("DiscardUserItem() estimated cost = %v, want 1", resp.EstimatedCost)
+	}
+
+	var left float64
+	if err := db.Model(&models.UserItem{}).Where("user_id = ? AND item_id = ?", owner.UserID, flour.ID).Select("COALESCE(SUM(amount), 0)").Scan(&left).Error; err != nil {
+		t.Fatal(err)
+	}
+	if left != 250 {
+		t.Errorf("%v g left, want 250", left)
+	}
+
+	// Without an amount everything left is thrown away
+	resp, err = repo.DiscardUserItem(dtos.DiscardRequest{Reason: string(models.ExpiredReason)}, flour.ID, owner)
+	if err != nil {
+		t.Fatalf("DiscardUserItem() of everything error = %v", err)
+	}
+	if resp.Discarded != 250 || resp.Unit != "g" {
+		t.Errorf("DiscardUserItem() of everything discarded %v %s, want 250 g", resp.Discarded, resp.Unit)
+	}
+	if resp.EstimatedCost == nil || *resp.EstimatedCost != 1 {
+		t.Errorf("DiscardUserItem() of everything estimated cost = %v, want 1", resp.EstimatedCost)
+	}
+	if len(resp.UserItem.Lots) != 0 {
+		t.Errorf("DiscardUserItem() of everything left lots %+v", resp.UserItem.Lots)
+	}
+}
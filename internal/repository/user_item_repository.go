@@ -2,236 +2,1244 @@ package repository
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/GroceryTrak/GroceryTrakService/internal/detector"
 	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
 	"github.com/GroceryTrak/GroceryTrakService/internal/models"
-	"github.com/sashabaranov/go-openai"
+	"github.com/GroceryTrak/GroceryTrakService/internal/planner"
+	"github.com/GroceryTrak/GroceryTrakService/internal/productdb"
+	"github.com/GroceryTrak/GroceryTrakService/internal/shelflife"
+	"github.com/GroceryTrak/GroceryTrakService/internal/units"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// amountEpsilon absorbs float rounding when an amount is used up
+const amountEpsilon = 1e-4
+
+var (
+	ErrScanNotPending      = errors.New("scan has already been committed or discarded")
+	ErrInvalidScanDecision = errors.New("invalid scan decision")
+	ErrUnknownBarcode      = errors.New("barcode is not known to the catalog or the product database")
+	ErrPriceWithoutAdded   = errors.New("a price can only be given when the amount goes up")
 )
 
 type UserItemRepository interface {
-	GetAllUserItems(userID uint) (dtos.UserItemsResponse, error)
-	GetUserItem(itemID, userID uint) (dtos.UserItemResponse, error)
-	CreateUserItem(req dtos.UserItemRequest, userID uint) (dtos.UserItemResponse, error)
-	UpdateUserItem(req dtos.UserItemRequest, itemID, userID uint) (dtos.UserItemResponse, error)
-	DeleteUserItem(itemID, userID uint) error
-	SearchUserItems(query dtos.UserItemQuery, userID uint) (dtos.UserItemsResponse, error)
-	PredictUserItems(items []string, userID uint) (dtos.UserItemsResponse, error)
-	DetectUserItems(imageData []byte, userID uint, apiKey string) (dtos.UserItemsResponse, error)
+	GetAllUserItems(owner Owner) (dtos.UserItemsResponse, error)
+	GetUserItem(itemID uint, owner Owner) (dtos.UserItemResponse, error)
+	CreateUserItem(req dtos.UserItemRequest, owner Owner) (dtos.UserItemResponse, error)
+	UpdateUserItem(req dtos.UserItemRequest, itemID uint, owner Owner) (dtos.UserItemResponse, error)
+	DeleteUserItem(itemID uint, owner Owner) error
+	UpdateUserItemLot(req dtos.UserItemLotRequest, lotID uint, owner Owner) (dtos.UserItemResponse, error)
+	DeleteUserItemLot(lotID uint, owner Owner) error
+	DiscardUserItem(req dtos.DiscardRequest, itemID uint, owner Owner) (dtos.DiscardResponse, error)
+	GetUserItemHistory(itemID uint, owner Owner) (dtos.PantryEventsResponse, error)
+	GetWasteReport(query dtos.WasteReportQuery, owner Owner) (dtos.WasteReportResponse, error)
+	SearchUserItems(query dtos.UserItemQuery, owner Owner) (dtos.UserItemsResponse, error)
+	GetExpiringUserItems(within time.Duration, owner Owner) (dtos.UserItemsResponse, error)
+	AddBarcodeUserItem(ctx context.Context, req dtos.BarcodeRequest, owner Owner) (dtos.UserItemResponse, error)
+	DetectUserItems(ctx context.Context, imageData []byte, source models.PantryEventSource, owner Owner) (dtos.UserItemsResponse, error)
+	ScanUserItems(ctx context.Context, imageData []byte, source models.PantryEventSource, owner Owner) (dtos.ScanResponse, error)
+	GetScan(scanID uint, owner Owner) (dtos.ScanResponse, error)
+	CommitScan(ctx context.Context, req dtos.ScanCommitRequest, scanID uint, owner Owner) (dtos.UserItemsResponse, error)
+	DiscardScan(scanID uint, owner Owner) error
+	ImportReceipt(ctx context.Context, imageData []byte, owner Owner) (dtos.ReceiptResponse, error)
+	CookRecipe(recipeID uint, owner Owner, servings float32) (dtos.CookRecipeResponse, error)
 }
 
 type UserItemRepositoryImpl struct {
-	db    *gorm.DB
-	queue ItemQueueRepository
+	db       *gorm.DB
+	queue    ItemQueueRepository
+	detector detector.Detector
+	matcher  ItemMatchRepository
+	products productdb.Client
 }
 
-func NewUserItemRepository(db *gorm.DB, queue ItemQueueRepository) UserItemRepository {
+func NewUserItemRepository(db *gorm.DB, queue ItemQueueRepository, detector detector.Detector, matcher ItemMatchRepository, products productdb.Client) UserItemRepository {
 	return &UserItemRepositoryImpl{
-		db:    db,
-		queue: queue,
+		db:       db,
+		queue:    queue,
+		detector: detector,
+		matcher:  matcher,
+		products: products,
 	}
 }
 
-func (r *UserItemRepositoryImpl) GetAllUserItems(userID uint) (dtos.UserItemsResponse, error) {
-	var userItems []models.UserItem
-	if err := r.db.Where("user_id = ?", userID).Find(&userItems).Error; err != nil {
-		return dtos.UserItemsResponse{}, err
+// fifoOrder sorts lots in the order they should be used: first to expire, then oldest purchase
+const fifoOrder = "user_items.expires_at ASC NULLS LAST, user_items.purchased_at ASC NULLS LAST, user_items.id ASC"
+
+func toUserItemLotResponse(lot models.UserItem) dtos.UserItemLotResponse {
+	return dtos.UserItemLotResponse{
+		ID:          lot.ID,
+		Amount:      lot.Amount,
+		Unit:        lot.Unit,
+		PurchasedAt: lot.PurchasedAt,
+		ExpiresAt:   lot.ExpiresAt,
+		Location:    string(lot.Location),
 	}
+}
 
+// aggregateUserItems groups lots, given in FIFO order, into one response per item. Amounts are
+// summed in the unit of the first lot; lots whose unit cannot be converted are listed but not summed.
+func aggregateUserItems(lots []models.UserItem) []dtos.UserItemResponse {
+	index := make(map[uint]int)
 	var userItemResponses []dtos.UserItemResponse
-	for _, userItem := range userItems {
-		var item models.Item
-		if err := r.db.First(&item, "id = ?", userItem.ItemID).Error; err != nil {
-			return dtos.UserItemsResponse{}, err
+	for _, lot := range lots {
+		i, ok := index[lot.ItemID]
+		if !ok {
+			i = len(userItemResponses)
+			index[lot.ItemID] = i
+			userItemResponses = append(userItemResponses, dtos.UserItemResponse{
+				Item:        toItemResponse(lot.Item),
+				Unit:        lot.Unit,
+				PurchasedAt: lot.PurchasedAt,
+				ExpiresAt:   lot.ExpiresAt,
+				Location:    string(lot.Location),
+				Lots:        []dtos.UserItemLotResponse{},
+			})
 		}
 
-		userItemResponses = append(userItemResponses, dtos.UserItemResponse{
-			Item: dtos.ItemResponse{
-				ID:            item.ID,
-				Name:          item.Name,
-				Image:         item.Image,
-				SpoonacularID: item.SpoonacularID,
-			},
-			Amount: userItem.Amount,
-			Unit:   userItem.Unit,
-		})
+		userItemResponse := &userItemResponses[i]
+		if amount, err := units.Convert(float64(lot.Amount), lot.Unit, userItemResponse.Unit, itemDensity(lot.Item)); err == nil {
+			userItemResponse.Amount += float32(amount)
+		}
+		userItemResponse.Lots = append(userItemResponse.Lots, toUserItemLotResponse(lot))
+	}
+
+	return userItemResponses
+}
+
+func toUserItemResponse(lot models.UserItem, item models.Item) dtos.UserItemResponse {
+	lot.Item = item
+	return aggregateUserItems([]models.UserItem{lot})[0]
+}
+
+// prefillUserItem fills in the purchase date, storage location and expiry date of a new lot
+// from the item's category when the client did not provide them
+func prefillUserItem(userItem *models.UserItem, item models.Item) {
+	if userItem.PurchasedAt == nil {
+		now := time.Now()
+		userItem.PurchasedAt = &now
+	}
+	if userItem.Location == "" {
+		userItem.Location = shelflife.DefaultLocation(item.Category)
+	}
+	if userItem.ExpiresAt == nil {
+		userItem.ExpiresAt = shelflife.ExpiresAt(item, userItem.Location, *userItem.PurchasedAt)
+	}
+}
+
+// applyLotDetails updates the dates and location of a lot when they are given. Moving a lot to
+// another location without a new expiry date re-estimates it from the purchase date.
+func applyLotDetails(lot *models.UserItem, purchasedAt, expiresAt *time.Time, location string) {
+	if purchasedAt != nil {
+		lot.PurchasedAt = purchasedAt
+	}
+	if location != "" && models.StorageLocation(location) != lot.Location {
+		lot.Location = models.StorageLocation(location)
+		if expiresAt == nil && lot.PurchasedAt != nil {
+			lot.ExpiresAt = shelflife.ExpiresAt(lot.Item, lot.Location, *lot.PurchasedAt)
+		}
+	}
+	if expiresAt != nil {
+		lot.ExpiresAt = expiresAt
+	}
+}
+
+// findLots returns the owner's lots of an item in FIFO order, locking them when lock is set
+func findLots(db *gorm.DB, itemID uint, owner Owner, lock bool) ([]models.UserItem, error) {
+	if lock {
+		db = db.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "user_items"}})
+	}
+
+	var lots []models.UserItem
+	if err := db.Joins("Item").
+		Scopes(owner.scope("user_items")).
+		Where("user_items.item_id = ?", itemID).
+		Order(fifoOrder).
+		Find(&lots).Error; err != nil {
+		return nil, err
+	}
+	return lots, nil
+}
+
+// totalAmount sums lots in unit, skipping the ones that cannot be converted
+func totalAmount(lots []models.UserItem, unit string) float64 {
+	var total float64
+	for _, lot := range lots {
+		if amount, err := units.Convert(float64(lot.Amount), lot.Unit, unit, itemDensity(lot.Item)); err == nil {
+			total += amount
+		}
+	}
+	return total
+}
+
+// pantryChange says who is changing the pantry and why, for the events logged along the way.
+// reason is only set when food is wasted.
+type pantryChange struct {
+	eventType models.PantryEventType
+	source    models.PantryEventSource
+	reason    *models.WasteReason
+	owner     Owner
+}
+
+// log appends an event for amount (in unit, negative when taken out) changing in lot. Changes
+// too small to matter are not logged.
+func (c pantryChange) log(db *gorm.DB, lot models.UserItem, amount float32, unit string) error {
+	if math.Abs(float64(amount)) <= amountEpsilon {
+		return nil
+	}
+
+	event := models.PantryEvent{
+		UserID:      c.owner.UserID,
+		HouseholdID: c.owner.HouseholdID,
+		ItemID:      lot.ItemID,
+		LotID:       lot.ID,
+		Type:        c.eventType,
+		Source:      c.source,
+		Amount:      amount,
+		Unit:        unit,
+		Reason:      c.reason,
+	}
+	return db.Omit(clause.Associations).Create(&event).Error
+}
+
+// wasteChange is the change for throwing food away for reason
+func wasteChange(reason models.WasteReason, owner Owner) pantryChange {
+	change := pantryChange{eventType: models.DiscardedEvent, source: models.ManualSource, reason: &reason, owner: owner}
+	if reason == models.ExpiredReason {
+		change.eventType = models.ExpiredEvent
+	}
+	return change
+}
+
+// removal is the change for deleting lot without saying why: a lot past its expiry date was
+// wasted, anything else is taken to be a correction of the pantry
+func removal(lot models.UserItem, owner Owner) pantryChange {
+	if lot.ExpiresAt != nil && lot.ExpiresAt.Before(time.Now()) {
+		return wasteChange(models.ExpiredReason, owner)
+	}
+	return pantryChange{eventType: models.DiscardedEvent, source: models.ManualSource, owner: owner}
+}
+
+// removeLots deletes lots, logging each with the change changeFor gives for it
+func removeLots(tx *gorm.DB, lots []models.UserItem, changeFor func(models.UserItem) pantryChange) error {
+	for _, lot := range lots {
+		if err := tx.Delete(&models.UserItem{}, "id = ?", lot.ID).Error; err != nil {
+			return err
+		}
+		if err := changeFor(lot).log(tx, lot, -lot.Amount, lot.Unit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// consumeLots takes amount (in unit) out of lots, given in FIFO order, deleting the lots that are
+// used up and logging change for each lot touched. Lots whose unit cannot be converted are
+// skipped. It returns how much was consumed.
+func consumeLots(tx *gorm.DB, lots []models.UserItem, amount float64, unit string, change pantryChange) (float64, error) {
+	remaining := amount
+	for i := range lots {
+		if remaining <= amountEpsilon {
+			break
+		}
+
+		lot := &lots[i]
+		density := itemDensity(lot.Item)
+		available, err := units.Convert(float64(lot.Amount), lot.Unit, unit, density)
+		if err != nil || available <= 0 {
+			continue
+		}
+
+		if available-remaining <= amountEpsilon {
+			if err := tx.Delete(&models.UserItem{}, "id = ?", lot.ID).Error; err != nil {
+				return 0, err
+			}
+			if err := change.log(tx, *lot, -lot.Amount, lot.Unit); err != nil {
+				return 0, err
+			}
+			remaining -= available
+			lot.Amount = 0
+			continue
+		}
+
+		left, err := units.Convert(available-remaining, unit, lot.Unit, density)
+		if err != nil {
+			return 0, err
+		}
+		used := lot.Amount - float32(left)
+		lot.Amount = float32(left)
+		if err := tx.Model(&models.UserItem{}).Where("id = ?", lot.ID).Update("amount", lot.Amount).Error; err != nil {
+			return 0, err
+		}
+		if err := change.log(tx, *lot, -used, lot.Unit); err != nil {
+			return 0, err
+		}
+		remaining = 0
+	}
+
+	return amount - max(remaining, 0), nil
+}
+
+// getUserItem aggregates the owner's lots of an item, failing with gorm.ErrRecordNotFound when
+// there are none
+func (r *UserItemRepositoryImpl) getUserItem(db *gorm.DB, itemID uint, owner Owner) (dtos.UserItemResponse, error) {
+	lots, err := findLots(db, itemID, owner, false)
+	if err != nil {
+		return dtos.UserItemResponse{}, err
+	}
+	if len(lots) == 0 {
+		return dtos.UserItemResponse{}, gorm.ErrRecordNotFound
+	}
+
+	return aggregateUserItems(lots)[0], nil
+}
+
+func (r *UserItemRepositoryImpl) GetAllUserItems(owner Owner) (dtos.UserItemsResponse, error) {
+	var userItems []models.UserItem
+	if err := r.db.Joins("Item").Scopes(owner.scope("user_items")).Order(fifoOrder).Find(&userItems).Error; err != nil {
+		return dtos.UserItemsResponse{}, err
 	}
 
 	return dtos.UserItemsResponse{
-		UserItems: userItemResponses,
+		UserItems: aggregateUserItems(userItems),
 	}, nil
 }
 
-func (r *UserItemRepositoryImpl) GetUserItem(itemID, userID uint) (dtos.UserItemResponse, error) {
-	var userItem models.UserItem
-	if err := r.db.First(&userItem, "item_id = ? AND user_id = ?", itemID, userID).Error; err != nil {
+func (r *UserItemRepositoryImpl) GetUserItem(itemID uint, owner Owner) (dtos.UserItemResponse, error) {
+	userItem, err := r.getUserItem(r.db, itemID, owner)
+	if err != nil {
 		return dtos.UserItemResponse{}, err
 	}
 
+	prioritizeEnrichment(context.Background(), r.queue, models.Item{
+		ID:               userItem.Item.ID,
+		Name:             userItem.Item.Name,
+		EnrichmentStatus: models.EnrichmentStatus(userItem.Item.EnrichmentStatus),
+	})
+	return userItem, nil
+}
+
+// CreateUserItem adds a new lot of an item to the owner's pantry
+func (r *UserItemRepositoryImpl) CreateUserItem(req dtos.UserItemRequest, owner Owner) (dtos.UserItemResponse, error) {
 	var item models.Item
-	if err := r.db.First(&item, "id = ?", itemID).Error; err != nil {
+	if err := r.db.First(&item, "id = ?", req.ItemID).Error; err != nil {
 		return dtos.UserItemResponse{}, err
 	}
 
-	return dtos.UserItemResponse{
-		Item: dtos.ItemResponse{
-			ID:            item.ID,
-			Name:          item.Name,
-			Image:         item.Image,
-			SpoonacularID: item.SpoonacularID,
-		},
-		Amount: userItem.Amount,
-		Unit:   userItem.Unit,
-	}, nil
-}
-
-func (r *UserItemRepositoryImpl) CreateUserItem(req dtos.UserItemRequest, userID uint) (dtos.UserItemResponse, error) {
 	userItem := models.UserItem{
-		UserID: userID,
-		ItemID: req.ItemID,
-		Amount: req.Amount,
-		Unit:   req.Unit,
+		UserID:      owner.UserID,
+		HouseholdID: owner.HouseholdID,
+		ItemID:      req.ItemID,
+		Amount:      req.Amount,
+		Unit:        units.Canonical(req.Unit),
+		PurchasedAt: req.PurchasedAt,
+		ExpiresAt:   req.ExpiresAt,
+		Location:    models.StorageLocation(req.Location),
 	}
+	prefillUserItem(&userItem, item)
 
-	if err := r.db.Create(&userItem).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&userItem).Error; err != nil {
+			return err
+		}
+		change := pantryChange{eventType: models.AddedEvent, source: models.ManualSource, owner: owner}
+		if err := change.log(tx, userItem, userItem.Amount, userItem.Unit); err != nil {
+			return err
+		}
+		if req.Price != nil {
+			return recordPurchase(tx, req, item, userItem.Amount, userItem.Unit, owner)
+		}
+		return nil
+	})
+	if err != nil {
 		return dtos.UserItemResponse{}, err
 	}
 
-	var item models.Item
-	if err := r.db.First(&item, "id = ?", userItem.ItemID).Error; err != nil {
+	prioritizeEnrichment(context.Background(), r.queue, item)
+	return toUserItemResponse(userItem, item), nil
+}
+
+// UpdateUserItem sets the total amount of an item across its lots. A lower amount is consumed
+// FIFO; a higher one is added to the most recent lot, and recorded as a purchase when a price is
+// given. A price without an increase is refused, since nothing was bought. Either way the change
+// is logged as an adjustment, or as an addition when it was bought. Dates and location are only
+// changed when given, and then apply to every remaining lot.
+func (r *UserItemRepositoryImpl) UpdateUserItem(req dtos.UserItemRequest, itemID uint, owner Owner) (dtos.UserItemResponse, error) {
+	var userItem dtos.UserItemResponse
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		lots, err := findLots(tx, itemID, owner, true)
+		if err != nil {
+			return err
+		}
+		if len(lots) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		unit := units.Canonical(req.Unit)
+		diff := float64(req.Amount) - totalAmount(lots, unit)
+		if req.Price != nil && diff <= amountEpsilon {
+			return ErrPriceWithoutAdded
+		}
+		change := pantryChange{eventType: models.AdjustedEvent, source: models.ManualSource, owner: owner}
+		if diff < -amountEpsilon {
+			if _, err := consumeLots(tx, lots, -diff, unit, change); err != nil {
+				return err
+			}
+		} else if diff > amountEpsilon {
+			if req.Price != nil {
+				change.eventType = models.AddedEvent
+				if err := recordPurchase(tx, req, lots[0].Item, float32(diff), unit, owner); err != nil {
+					return err
+				}
+			}
+			newest := &lots[len(lots)-1]
+			if added, err := units.Convert(diff, unit, newest.Unit, itemDensity(newest.Item)); err == nil {
+				newest.Amount += float32(added)
+			} else {
+				// The newest lot's amount is replaced, so it is logged as taken out first
+				if err := change.log(tx, *newest, -newest.Amount, newest.Unit); err != nil {
+					return err
+				}
+				newest.Amount = float32(diff)
+				newest.Unit = unit
+			}
+			if err := change.log(tx, *newest, float32(diff), unit); err != nil {
+				return err
+			}
+		}
+
+		for i := range lots {
+			lot := &lots[i]
+			if lot.Amount <= 0 {
+				continue
+			}
+			applyLotDetails(lot, req.PurchasedAt, req.ExpiresAt, req.Location)
+			if err := tx.Omit("Item").Save(lot).Error; err != nil {
+				return err
+			}
+		}
+
+		userItem, err = r.getUserItem(tx, itemID, owner)
+		if err == gorm.ErrRecordNotFound {
+			// Setting the amount to zero used up every lot
+			userItem = dtos.UserItemResponse{Item: toItemResponse(lots[0].Item), Unit: unit, Lots: []dtos.UserItemLotResponse{}}
+			return nil
+		}
+		return err
+	})
+	if err != nil {
 		return dtos.UserItemResponse{}, err
 	}
 
-	return dtos.UserItemResponse{
-		Item: dtos.ItemResponse{
-			ID:            item.ID,
-			Name:          item.Name,
-			Image:         item.Image,
-			SpoonacularID: item.SpoonacularID,
-		},
-		Amount: userItem.Amount,
-		Unit:   userItem.Unit,
-	}, nil
+	return userItem, nil
 }
 
-func (r *UserItemRepositoryImpl) UpdateUserItem(req dtos.UserItemRequest, itemID, userID uint) (dtos.UserItemResponse, error) {
-	var userItem models.UserItem
-	if err := r.db.First(&userItem, "item_id = ? AND user_id = ?", itemID, userID).Error; err != nil {
-		return dtos.UserItemResponse{}, err
+// DeleteUserItem removes every lot of an item from the owner's pantry, logging each as discarded,
+// or as expired when it was past its expiry date
+func (r *UserItemRepositoryImpl) DeleteUserItem(itemID uint, owner Owner) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		lots, err := findLots(tx, itemID, owner, true)
+		if err != nil {
+			return err
+		}
+
+		return removeLots(tx, lots, func(lot models.UserItem) pantryChange {
+			return removal(lot, owner)
+		})
+	})
+}
+
+// DiscardUserItem throws an item away for a reason: the given amount, taken from the lots that
+// expire first, or every lot when no amount is given. It reports how much was wasted and what
+// that cost, going by the owner's purchase history.
+func (r *UserItemRepositoryImpl) DiscardUserItem(req dtos.DiscardRequest, itemID uint, owner Owner) (dtos.DiscardResponse, error) {
+	var resp dtos.DiscardResponse
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		lots, err := findLots(tx, itemID, owner, true)
+		if err != nil {
+			return err
+		}
+		if len(lots) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// Canonical reads a blank unit as "unit", so the lots' own unit is picked first
+		unit := lots[0].Unit
+		if strings.TrimSpace(req.Unit) != "" {
+			unit = units.Canonical(req.Unit)
+		}
+		change := wasteChange(models.WasteReason(req.Reason), owner)
+
+		var discarded float64
+		if req.Amount == nil {
+			discarded = totalAmount(lots, unit)
+			err = removeLots(tx, lots, func(models.UserItem) pantryChange { return change })
+		} else {
+			discarded, err = consumeLots(tx, lots, float64(*req.Amount), unit, change)
+		}
+		if err != nil {
+			return err
+		}
+		resp.Discarded = float32(discarded)
+		resp.Unit = unit
+
+		priced, err := findPricedLines(tx, []uint{itemID}, owner)
+		if err != nil {
+			return err
+		}
+		if price, ok := averageUnitPrice(priced, unit, itemDensity(lots[0].Item)); ok {
+			cost := roundCents(price * discarded)
+			resp.EstimatedCost = &cost
+		}
+
+		resp.UserItem, err = r.getUserItem(tx, itemID, owner)
+		if err == gorm.ErrRecordNotFound {
+			resp.UserItem = dtos.UserItemResponse{Item: toItemResponse(lots[0].Item), Unit: unit, Lots: []dtos.UserItemLotResponse{}}
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return dtos.DiscardResponse{}, err
 	}
 
-	userItem.Amount = req.Amount
-	userItem.Unit = req.Unit
+	return resp, nil
+}
+
+// UpdateUserItemLot replaces the amount and unit of a single lot, deleting it when the amount
+// is zero, and returns the item it belongs to. The difference is logged as an adjustment.
+func (r *UserItemRepositoryImpl) UpdateUserItemLot(req dtos.UserItemLotRequest, lotID uint, owner Owner) (dtos.UserItemResponse, error) {
+	var userItem dtos.UserItemResponse
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var lot models.UserItem
+		if err := tx.Joins("Item").Scopes(owner.scope("user_items")).First(&lot, "user_items.id = ?", lotID).Error; err != nil {
+			return err
+		}
+
+		if req.Amount <= 0 {
+			if err := tx.Delete(&models.UserItem{}, "id = ?", lot.ID).Error; err != nil {
+				return err
+			}
+			change := removal(lot, owner)
+			if err := change.log(tx, lot, -lot.Amount, lot.Unit); err != nil {
+				return err
+			}
+		} else {
+			old := lot
+			lot.Amount = req.Amount
+			lot.Unit = units.Canonical(req.Unit)
+			applyLotDetails(&lot, req.PurchasedAt, req.ExpiresAt, req.Location)
+			if err := tx.Omit("Item").Save(&lot).Error; err != nil {
+				return err
+			}
+
+			change := pantryChange{eventType: models.AdjustedEvent, source: models.ManualSource, owner: owner}
+			before, err := units.Convert(float64(old.Amount), old.Unit, lot.Unit, itemDensity(lot.Item))
+			if err == nil {
+				if err := change.log(tx, lot, lot.Amount-float32(before), lot.Unit); err != nil {
+					return err
+				}
+			} else {
+				// Amounts in units that do not convert are logged as the old one taken out and the new one added
+				if err := change.log(tx, old, -old.Amount, old.Unit); err != nil {
+					return err
+				}
+				if err := change.log(tx, lot, lot.Amount, lot.Unit); err != nil {
+					return err
+				}
+			}
+		}
 
-	if err := r.db.Save(&userItem).Error; err != nil {
+		var err error
+		userItem, err = r.getUserItem(tx, lot.ItemID, owner)
+		if err == gorm.ErrRecordNotFound {
+			userItem = dtos.UserItemResponse{Item: toItemResponse(lot.Item), Unit: lot.Unit, Lots: []dtos.UserItemLotResponse{}}
+			return nil
+		}
+		return err
+	})
+	if err != nil {
 		return dtos.UserItemResponse{}, err
 	}
 
+	return userItem, nil
+}
+
+func (r *UserItemRepositoryImpl) DeleteUserItemLot(lotID uint, owner Owner) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var lot models.UserItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(owner.scope("user_items")).First(&lot, "user_items.id = ?", lotID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&models.UserItem{}, "id = ?", lot.ID).Error; err != nil {
+			return err
+		}
+		change := removal(lot, owner)
+		return change.log(tx, lot, -lot.Amount, lot.Unit)
+	})
+}
+
+// GetUserItemHistory lists the changes to the owner's lots of an item, newest first, including
+// lots that have since been used up or deleted
+func (r *UserItemRepositoryImpl) GetUserItemHistory(itemID uint, owner Owner) (dtos.PantryEventsResponse, error) {
 	var item models.Item
-	if err := r.db.First(&item, "id = ?", userItem.ItemID).Error; err != nil {
-		return dtos.UserItemResponse{}, err
+	if err := r.db.First(&item, itemID).Error; err != nil {
+		return dtos.PantryEventsResponse{}, err
 	}
 
-	return dtos.UserItemResponse{
-		Item: dtos.ItemResponse{
-			ID:            item.ID,
-			Name:          item.Name,
-			Image:         item.Image,
-			SpoonacularID: item.SpoonacularID,
-		},
-		Amount: userItem.Amount,
-		Unit:   userItem.Unit,
-	}, nil
+	var events []models.PantryEvent
+	if err := r.db.Scopes(owner.scope("pantry_events")).
+		Where("pantry_events.item_id = ?", itemID).
+		Order("pantry_events.created_at DESC, pantry_events.id DESC").
+		Find(&events).Error; err != nil {
+		return dtos.PantryEventsResponse{}, err
+	}
+
+	resp := dtos.PantryEventsResponse{
+		Item:   toItemResponse(item),
+		Events: make([]dtos.PantryEventResponse, len(events)),
+	}
+	for i, event := range events {
+		resp.Events[i] = dtos.PantryEventResponse{
+			ID:        event.ID,
+			LotID:     event.LotID,
+			UserID:    event.UserID,
+			Type:      string(event.Type),
+			Source:    string(event.Source),
+			Amount:    event.Amount,
+			Unit:      event.Unit,
+			CreatedAt: event.CreatedAt,
+		}
+		if event.Reason != nil {
+			resp.Events[i].Reason = string(*event.Reason)
+		}
+	}
+
+	return resp, nil
 }
 
-func (r *UserItemRepositoryImpl) DeleteUserItem(itemID, userID uint) error {
-	if err := r.db.Delete(&models.UserItem{}, "item_id = ? AND user_id = ?", itemID, userID).Error; err != nil {
-		return err
+// wasteMonth accumulates one month of a waste report
+type wasteMonth struct {
+	dtos.WasteMonthResponse
+	reasons map[string]*dtos.WasteReasonResponse
+	items   map[uint]*dtos.WastedItemResponse
+}
+
+// GetWasteReport sums the food the owner threw away in the period, month by month, with the
+// items wasted most. Lots deleted without a reason count as corrections, not waste, unless they
+// had expired.
+func (r *UserItemRepositoryImpl) GetWasteReport(query dtos.WasteReportQuery, owner Owner) (dtos.WasteReportResponse, error) {
+	var events []models.PantryEvent
+	if err := r.db.Preload("Item").
+		Scopes(owner.scope("pantry_events")).
+		Where("pantry_events.reason IS NOT NULL").
+		Where("pantry_events.created_at >= ? AND pantry_events.created_at < ?", query.From, query.To.AddDate(0, 0, 1)).
+		Order("pantry_events.created_at, pantry_events.id").
+		Find(&events).Error; err != nil {
+		return dtos.WasteReportResponse{}, err
 	}
-	return nil
+
+	pricedByItem := make(map[uint][]pricedLine)
+	if len(events) > 0 {
+		itemIDs := make([]uint, 0, len(events))
+		for _, event := range events {
+			itemIDs = append(itemIDs, event.ItemID)
+		}
+		priced, err := findPricedLines(r.db, itemIDs, owner)
+		if err != nil {
+			return dtos.WasteReportResponse{}, err
+		}
+		for _, line := range priced {
+			pricedByItem[line.ItemID] = append(pricedByItem[line.ItemID], line)
+		}
+	}
+
+	var months []*wasteMonth
+	byMonth := make(map[string]*wasteMonth)
+	for start := monthStart(query.From); !start.After(query.To); start = start.AddDate(0, 1, 0) {
+		month := &wasteMonth{
+			WasteMonthResponse: dtos.WasteMonthResponse{Month: start.Format("2006-01")},
+			reasons:            make(map[string]*dtos.WasteReasonResponse),
+			items:              make(map[uint]*dtos.WastedItemResponse),
+		}
+		months = append(months, month)
+		byMonth[month.Month] = month
+	}
+
+	for _, event := range events {
+		month := byMonth[event.CreatedAt.In(query.From.Location()).Format("2006-01")]
+		if month == nil {
+			continue
+		}
+
+		wasted := -float64(event.Amount)
+		density := itemDensity(event.Item)
+		price, priced := averageUnitPrice(pricedByItem[event.ItemID], event.Unit, density)
+		cost := price * wasted
+
+		month.Discards++
+		month.EstimatedCost += cost
+
+		reason := month.reasons[string(*event.Reason)]
+		if reason == nil {
+			reason = &dtos.WasteReasonResponse{Reason: string(*event.Reason)}
+			month.reasons[reason.Reason] = reason
+		}
+		reason.Discards++
+		reason.EstimatedCost += cost
+
+		item := month.items[event.ItemID]
+		if item == nil {
+			item = &dtos.WastedItemResponse{Item: toItemResponse(event.Item), Unit: event.Unit}
+			month.items[event.ItemID] = item
+		}
+		item.Discards++
+		if amount, err := units.Convert(wasted, event.Unit, item.Unit, density); err == nil {
+			item.Amount += float32(amount)
+		}
+		if priced {
+			if item.EstimatedCost == nil {
+				item.EstimatedCost = new(float64)
+			}
+			*item.EstimatedCost += cost
+		}
+	}
+
+	report := dtos.WasteReportResponse{
+		From:   query.From,
+		To:     query.To,
+		Months: make([]dtos.WasteMonthResponse, 0, len(months)),
+	}
+	for _, month := range months {
+		month.EstimatedCost = roundCents(month.EstimatedCost)
+		month.Reasons = make([]dtos.WasteReasonResponse, 0, len(month.reasons))
+		for _, reason := range month.reasons {
+			reason.EstimatedCost = roundCents(reason.EstimatedCost)
+			month.Reasons = append(month.Reasons, *reason)
+		}
+		sort.Slice(month.Reasons, func(i, j int) bool {
+			return month.Reasons[i].Discards > month.Reasons[j].Discards ||
+				month.Reasons[i].Discards == month.Reasons[j].Discards && month.Reasons[i].Reason < month.Reasons[j].Reason
+		})
+
+		month.Items = make([]dtos.WastedItemResponse, 0, len(month.items))
+		for _, item := range month.items {
+			if item.EstimatedCost != nil {
+				*item.EstimatedCost = roundCents(*item.EstimatedCost)
+			}
+			month.Items = append(month.Items, *item)
+		}
+		sort.Slice(month.Items, func(i, j int) bool {
+			a, b := month.Items[i], month.Items[j]
+			var costA, costB float64
+			if a.EstimatedCost != nil {
+				costA = *a.EstimatedCost
+			}
+			if b.EstimatedCost != nil {
+				costB = *b.EstimatedCost
+			}
+			if costA != costB {
+				return costA > costB
+			}
+			if a.Discards != b.Discards {
+				return a.Discards > b.Discards
+			}
+			return a.Item.ID < b.Item.ID
+		})
+		if query.Limit > 0 && len(month.Items) > query.Limit {
+			month.Items = month.Items[:query.Limit]
+		}
+
+		report.Discards += month.Discards
+		report.EstimatedCost += month.EstimatedCost
+		report.Months = append(report.Months, month.WasteMonthResponse)
+	}
+	report.EstimatedCost = roundCents(report.EstimatedCost)
+
+	return report, nil
 }
 
-func (r *UserItemRepositoryImpl) SearchUserItems(query dtos.UserItemQuery, userID uint) (dtos.UserItemsResponse, error) {
+func (r *UserItemRepositoryImpl) SearchUserItems(query dtos.UserItemQuery, owner Owner) (dtos.UserItemsResponse, error) {
 	var userItems []models.UserItem
 	searchTerm := "%" + query.Name + "%"
 
-	result := r.db.Where("user_id = ?", userID).Find(&userItems)
+	result := r.db.Joins("Item").Scopes(owner.scope("user_items")).Where("\"Item\".name LIKE ?", searchTerm).Order(fifoOrder).Find(&userItems)
 	if result.Error != nil {
 		return dtos.UserItemsResponse{}, result.Error
 	}
 
-	var userItemResponses []dtos.UserItemResponse
-	for _, userItem := range userItems {
-		var item models.Item
-		if err := r.db.Where("id = ? AND name LIKE ?", userItem.ItemID, searchTerm).First(&item).Error; err != nil {
-			continue
-		}
+	return dtos.UserItemsResponse{
+		UserItems: aggregateUserItems(userItems),
+	}, nil
+}
 
-		userItemResponses = append(userItemResponses, dtos.UserItemResponse{
-			Item: dtos.ItemResponse{
-				ID:            item.ID,
-				Name:          item.Name,
-				Image:         item.Image,
-				SpoonacularID: item.SpoonacularID,
-			},
-			Amount: userItem.Amount,
-			Unit:   userItem.Unit,
-		})
+// GetExpiringUserItems returns the owner's lots that expire before now+within, including those
+// already expired, grouped by item with the soonest first
+func (r *UserItemRepositoryImpl) GetExpiringUserItems(within time.Duration, owner Owner) (dtos.UserItemsResponse, error) {
+	var userItems []models.UserItem
+	if err := r.db.Joins("Item").
+		Scopes(owner.scope("user_items")).
+		Where("user_items.expires_at IS NOT NULL AND user_items.expires_at <= ?", time.Now().Add(within)).
+		Order(fifoOrder).
+		Find(&userItems).Error; err != nil {
+		return dtos.UserItemsResponse{}, err
 	}
 
 	return dtos.UserItemsResponse{
-		UserItems: userItemResponses,
+		UserItems: aggregateUserItems(userItems),
 	}, nil
 }
 
-func (r *UserItemRepositoryImpl) PredictUserItems(items []string, userID uint) (dtos.UserItemsResponse, error) {
+// findOrCreateItem resolves a name the user confirmed to an item, creating it when no known item
+// matches it confidently. Either way the user is adding it, so it goes to the front of the
+// enrichment queue if it has not been enriched.
+func (r *UserItemRepositoryImpl) findOrCreateItem(ctx context.Context, name string) (models.Item, error) {
+	match, err := r.matcher.MatchItem(name)
+	if err != nil {
+		return models.Item{}, err
+	}
+	if match.Confident {
+		prioritizeEnrichment(ctx, r.queue, *match.Item)
+		return *match.Item, nil
+	}
+
+	item := models.Item{
+		Name:          name,
+		Image:         "",
+		SpoonacularID: 0,
+		Nutrients:     []models.ItemNutrient{},
+	}
+	if err := r.db.Create(&item).Error; err != nil {
+		return models.Item{}, err
+	}
+
+	if r.queue != nil {
+		queueItem := models.QueueItem{
+			ItemID:    item.ID,
+			Name:      item.Name,
+			CreatedAt: time.Now(),
+			Priority:  models.HighPriority,
+		}
+		if err := r.queue.AddItem(ctx, queueItem); err != nil {
+			log.Printf("Failed to add item to enrichment queue: %v", err)
+		}
+	}
+
+	return item, nil
+}
+
+// AddBarcodeUserItem adds a scanned package to the owner's pantry as a new lot
+func (r *UserItemRepositoryImpl) AddBarcodeUserItem(ctx context.Context, req dtos.BarcodeRequest, owner Owner) (dtos.UserItemResponse, error) {
+	code, err := productdb.Normalize(req.Barcode)
+	if err != nil {
+		return dtos.UserItemResponse{}, err
+	}
+
+	barcode, err := r.resolveBarcode(ctx, code, req.ItemID)
+	if err != nil {
+		return dtos.UserItemResponse{}, err
+	}
+	prioritizeEnrichment(ctx, r.queue, barcode.Item)
+
+	userItem := models.UserItem{
+		UserID:      owner.UserID,
+		HouseholdID: owner.HouseholdID,
+		ItemID:      barcode.ItemID,
+		Amount:      req.Amount,
+		Unit:        units.Canonical(req.Unit),
+		PurchasedAt: req.PurchasedAt,
+		ExpiresAt:   req.ExpiresAt,
+		Location:    models.StorageLocation(req.Location),
+	}
+	if userItem.Amount <= 0 {
+		userItem.Amount, userItem.Unit = 1, "unit"
+		if barcode.Amount > 0 {
+			userItem.Amount, userItem.Unit = barcode.Amount, barcode.Unit
+		}
+	}
+	prefillUserItem(&userItem, barcode.Item)
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&userItem).Error; err != nil {
+			return err
+		}
+		change := pantryChange{eventType: models.AddedEvent, source: models.BarcodeSource, owner: owner}
+		return change.log(tx, userItem, userItem.Amount, userItem.Unit)
+	})
+	if err != nil {
+		return dtos.UserItemResponse{}, err
+	}
+
+	return toUserItemResponse(userItem, barcode.Item), nil
+}
+
+// resolveBarcode finds the item a code belongs to. An unknown code is linked to itemID when one
+// is given, else looked up in the product database; the product is then matched against the
+// catalog, and a new item is created and queued for enrichment when nothing matches.
+func (r *UserItemRepositoryImpl) resolveBarcode(ctx context.Context, code string, itemID uint) (models.ItemBarcode, error) {
+	var barcode models.ItemBarcode
+	err := r.db.Preload("Item").First(&barcode, "code = ?", code).Error
+	if err == nil {
+		return barcode, nil
+	} else if err != gorm.ErrRecordNotFound {
+		return models.ItemBarcode{}, err
+	}
+
+	barcode = models.ItemBarcode{Code: code}
+	if itemID != 0 {
+		if err := r.db.First(&barcode.Item, itemID).Error; err != nil {
+			return models.ItemBarcode{}, err
+		}
+	} else {
+		if r.products == nil {
+			return models.ItemBarcode{}, ErrUnknownBarcode
+		}
+		product, err := r.products.Lookup(ctx, code)
+		if errors.Is(err, productdb.ErrNotFound) {
+			return models.ItemBarcode{}, ErrUnknownBarcode
+		} else if err != nil {
+			return models.ItemBarcode{}, err
+		}
+
+		barcode.Item, err = r.findOrCreateItem(ctx, product.Name)
+		if err != nil {
+			return models.ItemBarcode{}, err
+		}
+		if product.Amount > 0 {
+			barcode.Amount = float32(product.Amount)
+			barcode.Unit = units.Canonical(product.Unit)
+		}
+	}
+	barcode.ItemID = barcode.Item.ID
+
+	// Another scan of the same code may have linked it meanwhile; its link wins
+	if err := r.db.Omit("Item").Clauses(clause.OnConflict{DoNothing: true}).Create(&barcode).Error; err != nil {
+		return models.ItemBarcode{}, err
+	}
+	if err := r.db.Preload("Item").First(&barcode, "code = ?", code).Error; err != nil {
+		return models.ItemBarcode{}, err
+	}
+
+	return barcode, nil
+}
+
+// addDetectedItem adds a detected item to the owner's pantry. An amount makes it a new purchase
+// and so a lot of its own; without one it only gets an empty lot when the pantry has none of it.
+// source is where the detection came from, for the pantry log.
+func (r *UserItemRepositoryImpl) addDetectedItem(db *gorm.DB, item models.Item, amount float32, unit string, source models.PantryEventSource, owner Owner) (dtos.UserItemResponse, error) {
+	if amount <= 0 {
+		userItem, err := r.getUserItem(db, item.ID, owner)
+		if err != gorm.ErrRecordNotFound {
+			return userItem, err
+		}
+	}
+
+	userItem := models.UserItem{
+		UserID:      owner.UserID,
+		HouseholdID: owner.HouseholdID,
+		ItemID:      item.ID,
+		Amount:      amount,
+		Unit:        unit,
+	}
+	prefillUserItem(&userItem, item)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&userItem).Error; err != nil {
+			return err
+		}
+		change := pantryChange{eventType: models.AddedEvent, source: source, owner: owner}
+		return change.log(tx, userItem, userItem.Amount, userItem.Unit)
+	})
+	if err != nil {
+		return dtos.UserItemResponse{}, err
+	}
+
+	return toUserItemResponse(userItem, item), nil
+}
+
+// DetectUserItems runs the configured detector on an image and adds the items it confidently
+// matches to the owner's pantry right away. Names that match no known item well enough are not
+// guessed at: they are kept as a pending scan, returned with the result, for the user to review.
+// source tells the pantry log which endpoint the image came from.
+func (r *UserItemRepositoryImpl) DetectUserItems(ctx context.Context, imageData []byte, source models.PantryEventSource, owner Owner) (dtos.UserItemsResponse, error) {
+	if r.detector == nil {
+		return dtos.UserItemsResponse{}, detector.ErrNotConfigured
+	}
+
+	detectedItems, err := r.detector.Detect(ctx, imageData)
+	if err != nil {
+		return dtos.UserItemsResponse{}, err
+	}
+
 	var userItemResponses []dtos.UserItemResponse
+	var proposals []models.ScanLine
 
-	for _, class := range items {
-		if class == "" {
+	for _, detectedItem := range detectedItems {
+		match, err := r.matcher.MatchItem(detectedItem.Name)
+		if err != nil {
+			return dtos.UserItemsResponse{}, err
+		}
+		if !match.Confident {
+			proposals = append(proposals, toScanLine(detectedItem, match))
 			continue
 		}
+		prioritizeEnrichment(ctx, r.queue, *match.Item)
+
+		userItem, err := r.addDetectedItem(r.db, *match.Item, float32(detectedItem.Amount), units.Canonical(detectedItem.Unit), source, owner)
+		if err != nil {
+			return dtos.UserItemsResponse{}, err
+		}
+		userItemResponses = append(userItemResponses, userItem)
+	}
+
+	resp := dtos.UserItemsResponse{
+		UserItems: userItemResponses,
+	}
+	if len(proposals) > 0 {
+		scan, err := r.createScan(proposals, source, owner)
+		if err != nil {
+			return dtos.UserItemsResponse{}, err
+		}
+		resp.Scan = &scan
+	}
+
+	return resp, nil
+}
 
-		var existingItem models.Item
-		if err := r.db.Where("name LIKE ?", "%"+class+"%").First(&existingItem).Error; err == nil {
+// toScanLine records a detection with its best match. Only a confident match is assigned; a
+// weaker one is kept as a suggestion so that committing the line as-is creates a new item.
+func toScanLine(detectedItem detector.DetectedItem, match ItemMatch) models.ScanLine {
+	line := models.ScanLine{
+		Name:       detectedItem.Name,
+		Amount:     float32(detectedItem.Amount),
+		Unit:       units.Canonical(detectedItem.Unit),
+		Confidence: float32(detectedItem.Confidence),
+		MatchScore: float32(match.Score),
+	}
+	if match.Item != nil {
+		if match.Confident {
+			line.ItemID = &match.Item.ID
 		} else {
-			newItem := models.Item{
-				Name:  class,
-				Image: "",
-			}
-			if err := r.db.Create(&newItem).Error; err != nil {
-				return dtos.UserItemsResponse{}, err
+			line.SuggestedItemID = &match.Item.ID
+		}
+	}
+	if detectedItem.Box != nil {
+		line.Box = &models.BoundingBox{
+			X:      detectedItem.Box.X,
+			Y:      detectedItem.Box.Y,
+			Width:  detectedItem.Box.Width,
+			Height: detectedItem.Box.Height,
+		}
+	}
+	return line
+}
+
+func toScanResponse(scan models.Scan) dtos.ScanResponse {
+	lines := make([]dtos.ScanLineResponse, len(scan.Lines))
+	for i, line := range scan.Lines {
+		lines[i] = dtos.ScanLineResponse{
+			ID:         line.ID,
+			Name:       line.Name,
+			Amount:     line.Amount,
+			Unit:       line.Unit,
+			Confidence: line.Confidence,
+			MatchScore: line.MatchScore,
+		}
+		if line.Item != nil {
+			item := toItemResponse(*line.Item)
+			lines[i].Item = &item
+		}
+		if line.SuggestedItem != nil {
+			suggestion := toItemResponse(*line.SuggestedItem)
+			lines[i].Suggestion = &suggestion
+		}
+		if line.Box != nil {
+			lines[i].Box = &dtos.BoundingBoxResponse{
+				X:      line.Box.X,
+				Y:      line.Box.Y,
+				Width:  line.Box.Width,
+				Height: line.Box.Height,
 			}
-			existingItem = newItem
 		}
+	}
 
-		userItem := models.UserItem{
-			UserID: userID,
-			ItemID: existingItem.ID,
+	return dtos.ScanResponse{
+		ID:        scan.ID,
+		Status:    string(scan.Status),
+		Source:    string(scan.Source),
+		CreatedAt: scan.CreatedAt,
+		Lines:     lines,
+	}
+}
+
+func (r *UserItemRepositoryImpl) findScan(scanID uint, owner Owner) (models.Scan, error) {
+	var scan models.Scan
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("Lines.Item").Preload("Lines.SuggestedItem").Scopes(owner.scope("scans")).First(&scan, "scans.id = ?", scanID).Error
+	return scan, err
+}
+
+// ScanUserItems runs the configured detector on an image and stores the results as a pending
+// scan for the user to review; the pantry is left untouched until the scan is committed
+func (r *UserItemRepositoryImpl) ScanUserItems(ctx context.Context, imageData []byte, source models.PantryEventSource, owner Owner) (dtos.ScanResponse, error) {
+	if r.detector == nil {
+		return dtos.ScanResponse{}, detector.ErrNotConfigured
+	}
+
+	detectedItems, err := r.detector.Detect(ctx, imageData)
+	if err != nil {
+		return dtos.ScanResponse{}, err
+	}
+
+	lines := make([]models.ScanLine, 0, len(detectedItems))
+	for _, detectedItem := range detectedItems {
+		match, err := r.matcher.MatchItem(detectedItem.Name)
+		if err != nil {
+			return dtos.ScanResponse{}, err
 		}
+		lines = append(lines, toScanLine(detectedItem, match))
+	}
+
+	return r.createScan(lines, source, owner)
+}
 
-		var existingUserItem models.UserItem
-		if err := r.db.Where("user_id = ? AND item_id = ?", userItem.UserID, userItem.ItemID).First(&existingUserItem).Error; err != nil {
-			if err := r.db.Create(&userItem).Error; err != nil {
+func (r *UserItemRepositoryImpl) createScan(lines []models.ScanLine, source models.PantryEventSource, owner Owner) (dtos.ScanResponse, error) {
+	scan := models.Scan{
+		UserID:      owner.UserID,
+		HouseholdID: owner.HouseholdID,
+		Status:      models.ScanPending,
+		Source:      source,
+		Lines:       lines,
+	}
+	if err := r.db.Omit("Lines.Item", "Lines.SuggestedItem", "Lines.PurchaseLine").Create(&scan).Error; err != nil {
+		return dtos.ScanResponse{}, err
+	}
+
+	return r.GetScan(scan.ID, owner)
+}
+
+func (r *UserItemRepositoryImpl) GetScan(scanID uint, owner Owner) (dtos.ScanResponse, error) {
+	scan, err := r.findScan(scanID, owner)
+	if err != nil {
+		return dtos.ScanResponse{}, err
+	}
+
+	return toScanResponse(scan), nil
+}
+
+// CommitScan applies the user's review of a pending scan: accepted and edited lines are added to
+// the pantry the same way a direct detection would add them, everything else is dropped
+func (r *UserItemRepositoryImpl) CommitScan(ctx context.Context, req dtos.ScanCommitRequest, scanID uint, owner Owner) (dtos.UserItemsResponse, error) {
+	scan, err := r.findScan(scanID, owner)
+	if err != nil {
+		return dtos.UserItemsResponse{}, err
+	}
+	if scan.Status != models.ScanPending {
+		return dtos.UserItemsResponse{}, ErrScanNotPending
+	}
+
+	lines := make(map[uint]models.ScanLine, len(scan.Lines))
+	for _, line := range scan.Lines {
+		lines[line.ID] = line
+	}
+
+	// Resolve every kept line to an item first, so that new items exist before the pantry
+	// transaction and the enrichment queue can pick them up
+	type keptLine struct {
+		item           models.Item
+		amount         float32
+		unit           string
+		purchaseLineID *uint
+	}
+	var kept []keptLine
+	for _, decision := range req.Lines {
+		line, ok := lines[decision.ID]
+		if !ok {
+			return dtos.UserItemsResponse{}, fmt.Errorf("%w: line %d is not part of the scan", ErrInvalidScanDecision, decision.ID)
+		}
+
+		switch decision.Action {
+		case "reject":
+			continue
+		case "edit":
+			if decision.Name != "" {
+				line.Name = decision.Name
+				line.ItemID = nil
+			}
+			if decision.ItemID != nil {
+				line.ItemID = decision.ItemID
+			}
+			if decision.Amount != nil {
+				line.Amount = *decision.Amount
+			}
+			if decision.Unit != "" {
+				line.Unit = units.Canonical(decision.Unit)
+			}
+		case "accept":
+		default:
+			return dtos.UserItemsResponse{}, fmt.Errorf("%w: unknown action %q", ErrInvalidScanDecision, decision.Action)
+		}
+
+		var item models.Item
+		if line.ItemID != nil {
+			if err := r.db.First(&item, *line.ItemID).Error; err != nil {
 				return dtos.UserItemsResponse{}, err
 			}
+			prioritizeEnrichment(ctx, r.queue, item)
+		} else if item, err = r.findOrCreateItem(ctx, line.Name); err != nil {
+			return dtos.UserItemsResponse{}, err
 		}
 
-		userItemResponses = append(userItemResponses, dtos.UserItemResponse{
-			Item: dtos.ItemResponse{
-				ID:            existingItem.ID,
-				Name:          existingItem.Name,
-				Image:         existingItem.Image,
-				SpoonacularID: existingItem.SpoonacularID,
-			},
-			Amount: userItem.Amount,
-			Unit:   userItem.Unit,
-		})
+		kept = append(kept, keptLine{item: item, amount: line.Amount, unit: line.Unit, purchaseLineID: line.PurchaseLineID})
+	}
+
+	var userItemResponses []dtos.UserItemResponse
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		// Claim the scan so that it cannot be committed twice
+		result := tx.Model(&models.Scan{}).
+			Where("id = ? AND status = ?", scan.ID, models.ScanPending).
+			Update("status", models.ScanCommitted)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrScanNotPending
+		}
+
+		for _, line := range kept {
+			userItem, err := r.addDetectedItem(tx, line.item, line.amount, line.unit, scan.Source, owner)
+			if err != nil {
+				return err
+			}
+			userItemResponses = append(userItemResponses, userItem)
+
+			// A reviewed receipt line prices the item from now on
+			if line.purchaseLineID != nil {
+				err := tx.Model(&models.PurchaseLine{}).
+					Where("id = ? AND item_id IS NULL", *line.purchaseLineID).
+					Update("item_id", line.item.ID).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return dtos.UserItemsResponse{}, err
 	}
 
 	return dtos.UserItemsResponse{
@@ -239,127 +1247,240 @@ func (r *UserItemRepositoryImpl) PredictUserItems(items []string, userID uint) (
 	}, nil
 }
 
-func (r *UserItemRepositoryImpl) DetectUserItems(imageData []byte, userID uint, apiKey string) (dtos.UserItemsResponse, error) {
-	client := openai.NewClient(apiKey)
-	imageBase64 := base64.StdEncoding.EncodeToString(imageData)
-	prompt := `You are a grocery item detector. Analyze the image and identify all grocery items. For each item, provide:
-1. The name of the item, uppercase first letter
-2. The amount (as a whole number or decimal number with two decimal places)
-3. The unit of measurement (e.g., unit, kg, g, lb, oz, etc.)
-
-Return ONLY a JSON array of objects with these exact keys: name, amount, unit.
-Example response:
-[{"name":"Apple","amount":1,"unit":"unit"},{"name":"Milk","amount":1.5,"unit":"L"}]
-
-Do not include any other text, explanations, or formatting. Return only the JSON array.`
-
-	resp, err := client.CreateChatCompletion(
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model: "gpt-4.1-mini",
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role: "user",
-					MultiContent: []openai.ChatMessagePart{
-						{
-							Type: "text",
-							Text: prompt,
-						},
-						{
-							Type: "image_url",
-							ImageURL: &openai.ChatMessageImageURL{
-								URL: fmt.Sprintf("data:image/jpeg;base64,%s", imageBase64),
-							},
-						},
-					},
-				},
-			},
-		},
-	)
+// DiscardScan drops a pending scan without touching the pantry
+func (r *UserItemRepositoryImpl) DiscardScan(scanID uint, owner Owner) error {
+	scan, err := r.findScan(scanID, owner)
 	if err != nil {
-		return dtos.UserItemsResponse{}, err
+		return err
 	}
 
-	var detectedItems []struct {
-		Name   string  `json:"name"`
-		Amount float64 `json:"amount"`
-		Unit   string  `json:"unit"`
+	result := r.db.Model(&models.Scan{}).
+		Where("id = ? AND status = ?", scan.ID, models.ScanPending).
+		Update("status", models.ScanDiscarded)
+	if result.Error != nil {
+		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return ErrScanNotPending
+	}
+	return nil
+}
 
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &detectedItems); err != nil {
-		return dtos.UserItemsResponse{}, err
+// ImportReceipt reads a receipt photo and records it as a purchase. Lines that confidently match
+// a known item are added to the owner's pantry as lots bought on the receipt's date; the others
+// are recorded without an item and kept as a pending scan, returned with the result, for the
+// user to review. Committing the scan links the purchase lines to the items chosen.
+func (r *UserItemRepositoryImpl) ImportReceipt(ctx context.Context, imageData []byte, owner Owner) (dtos.ReceiptResponse, error) {
+	if r.detector == nil {
+		return dtos.ReceiptResponse{}, detector.ErrNotConfigured
+	}
+	reader, ok := r.detector.(detector.ReceiptReader)
+	if !ok {
+		return dtos.ReceiptResponse{}, detector.ErrReceiptsNotSupported
 	}
 
-	var userItemResponses []dtos.UserItemResponse
+	receipt, err := reader.ReadReceipt(ctx, imageData)
+	if err != nil {
+		return dtos.ReceiptResponse{}, err
+	}
 
-	for _, detectedItem := range detectedItems {
-		var item models.Item
-		if err := r.db.Where("name = ?", detectedItem.Name).First(&item).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				item = models.Item{
-					Name:          detectedItem.Name,
-					Image:         "",
-					SpoonacularID: 0,
-					Nutrients:     []models.ItemNutrient{},
-				}
-				if err := r.db.Create(&item).Error; err != nil {
-					return dtos.UserItemsResponse{}, err
-				}
+	purchase := models.Purchase{
+		UserID:      owner.UserID,
+		HouseholdID: owner.HouseholdID,
+		Store:       receipt.Store,
+		PurchasedAt: time.Now(),
+		Currency:    receipt.Currency,
+		Total:       receipt.Total,
+		Lines:       make([]models.PurchaseLine, 0, len(receipt.Lines)),
+	}
+	if receipt.Date != nil {
+		purchase.PurchasedAt = *receipt.Date
+	}
 
-				if r.queue != nil {
-					queueItem := models.QueueItem{
-						ItemID:    item.ID,
-						Name:      item.Name,
-						CreatedAt: time.Now(),
-						Priority:  models.DefaultPriority,
-					}
-					if err := r.queue.AddItem(context.Background(), queueItem); err != nil {
-						log.Printf("Failed to add item to enrichment queue: %v", err)
-					}
-				}
-			} else {
-				return dtos.UserItemsResponse{}, err
+	var lots []models.UserItem
+	var proposals []models.ScanLine
+	// proposalLines[i] is the index in purchase.Lines of the line proposals[i] was read from
+	var proposalLines []int
+	for _, receiptLine := range receipt.Lines {
+		line := models.PurchaseLine{
+			Name:   receiptLine.Name,
+			Amount: float32(receiptLine.Amount),
+			Unit:   units.Canonical(receiptLine.Unit),
+			Price:  receiptLine.Price,
+		}
+		// Every receipt line is something bought, if only one of it
+		if line.Amount <= 0 {
+			line.Amount, line.Unit = 1, "unit"
+		}
+
+		match, err := r.matcher.MatchItem(receiptLine.Name)
+		if err != nil {
+			return dtos.ReceiptResponse{}, err
+		}
+		if !match.Confident {
+			proposals = append(proposals, toScanLine(detector.DetectedItem{
+				Name:       line.Name,
+				Amount:     float64(line.Amount),
+				Unit:       line.Unit,
+				Confidence: 1,
+			}, match))
+			proposalLines = append(proposalLines, len(purchase.Lines))
+			purchase.Lines = append(purchase.Lines, line)
+			continue
+		}
+
+		line.ItemID = &match.Item.ID
+		line.Item = match.Item
+		purchase.Lines = append(purchase.Lines, line)
+		prioritizeEnrichment(ctx, r.queue, *match.Item)
+
+		purchasedAt := purchase.PurchasedAt
+		lot := models.UserItem{
+			UserID:      owner.UserID,
+			HouseholdID: owner.HouseholdID,
+			ItemID:      match.Item.ID,
+			Amount:      line.Amount,
+			Unit:        line.Unit,
+			PurchasedAt: &purchasedAt,
+			Item:        *match.Item,
+		}
+		prefillUserItem(&lot, *match.Item)
+		lots = append(lots, lot)
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Lines.Item").Create(&purchase).Error; err != nil {
+			return err
+		}
+		if len(lots) > 0 {
+			if err := tx.Omit("Item").Create(&lots).Error; err != nil {
+				return err
+			}
+		}
+		change := pantryChange{eventType: models.AddedEvent, source: models.ReceiptSource, owner: owner}
+		for _, lot := range lots {
+			if err := change.log(tx, lot, lot.Amount, lot.Unit); err != nil {
+				return err
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return dtos.ReceiptResponse{}, err
+	}
 
-		userItem := models.UserItem{
-			UserID: userID,
-			ItemID: item.ID,
-			Amount: float32(detectedItem.Amount),
-			Unit:   detectedItem.Unit,
+	resp := dtos.ReceiptResponse{
+		Purchase:  toPurchaseResponse(purchase),
+		UserItems: aggregateUserItems(lots),
+	}
+	if len(proposals) > 0 {
+		for i := range proposals {
+			proposals[i].PurchaseLineID = &purchase.Lines[proposalLines[i]].ID
+		}
+		scan, err := r.createScan(proposals, models.ReceiptSource, owner)
+		if err != nil {
+			return dtos.ReceiptResponse{}, err
 		}
+		resp.Scan = &scan
+	}
+
+	return resp, nil
+}
 
-		var existingUserItem models.UserItem
-		if err := r.db.Where("user_id = ? AND item_id = ?", userID, item.ID).First(&existingUserItem).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				if err := r.db.Create(&userItem).Error; err != nil {
-					return dtos.UserItemsResponse{}, err
+// CookRecipe deducts the recipe's ingredients, scaled to servings, from the owner's pantry in a
+// single transaction, using up the lots that expire first. Lots that reach zero are deleted;
+// whatever the pantry could not cover is reported as a shortfall.
+func (r *UserItemRepositoryImpl) CookRecipe(recipeID uint, owner Owner, servings float32) (dtos.CookRecipeResponse, error) {
+	var recipe models.Recipe
+	if err := r.db.Preload("Ingredients.Item").First(&recipe, recipeID).Error; err != nil {
+		return dtos.CookRecipeResponse{}, err
+	}
+
+	if servings <= 0 {
+		servings = recipe.Servings
+	}
+	factor := planner.ScaleFactor(recipe.Servings, servings)
+
+	resp := dtos.CookRecipeResponse{
+		RecipeID:   recipe.ID,
+		Servings:   servings,
+		Changes:    []dtos.PantryChangeResponse{},
+		Shortfalls: []dtos.MissingItemResponse{},
+	}
+
+	change := pantryChange{eventType: models.ConsumedEvent, source: models.CookSource, owner: owner}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, ingredient := range recipe.Ingredients {
+			required := float64(ingredient.Amount * factor)
+			itemResponse := toItemResponse(ingredient.Item)
+
+			lots, err := findLots(tx, ingredient.ItemID, owner, true)
+			if err != nil {
+				return err
+			}
+
+			// Work in the unit of the oldest lot the recipe's unit converts to, so the change
+			// reads like the pantry does; lots in units that do not convert are left alone
+			recipeUnit := units.Canonical(ingredient.Unit)
+			density := itemDensity(ingredient.Item)
+			var unit string
+			var needed float64
+			found := false
+			for _, lot := range lots {
+				if needed, err = units.Convert(required, recipeUnit, lot.Unit, density); err == nil {
+					unit, found = lot.Unit, true
+					break
 				}
-			} else {
-				return dtos.UserItemsResponse{}, err
 			}
-		} else {
-			existingUserItem.Amount = float32(detectedItem.Amount)
-			existingUserItem.Unit = detectedItem.Unit
-			if err := r.db.Save(&existingUserItem).Error; err != nil {
-				return dtos.UserItemsResponse{}, err
+			if !found {
+				resp.Shortfalls = append(resp.Shortfalls, dtos.MissingItemResponse{
+					Item:     itemResponse,
+					Required: float32(required),
+					Missing:  float32(required),
+					Unit:     recipeUnit,
+				})
+				continue
 			}
-			userItem = existingUserItem
-		}
 
-		userItemResponses = append(userItemResponses, dtos.UserItemResponse{
-			Item: dtos.ItemResponse{
-				ID:            item.ID,
-				Name:          item.Name,
-				Image:         item.Image,
-				SpoonacularID: item.SpoonacularID,
-			},
-			Amount: userItem.Amount,
-			Unit:   userItem.Unit,
-		})
+			before := totalAmount(lots, unit)
+			consumed, err := consumeLots(tx, lots, needed, unit, change)
+			if err != nil {
+				return err
+			}
+
+			after := before - consumed
+			if after < amountEpsilon {
+				after = 0
+			}
+
+			resp.Changes = append(resp.Changes, dtos.PantryChangeResponse{
+				Item:    itemResponse,
+				Before:  float32(before),
+				After:   float32(after),
+				Unit:    unit,
+				Removed: after == 0,
+			})
+
+			// Shortfalls are in the recipe's unit, whether or not the pantry has any of it
+			if needed-consumed > amountEpsilon {
+				available, err := units.Convert(consumed, unit, recipeUnit, density)
+				if err != nil {
+					return err
+				}
+				resp.Shortfalls = append(resp.Shortfalls, dtos.MissingItemResponse{
+					Item:      itemResponse,
+					Required:  float32(required),
+					Available: float32(available),
+					Missing:   float32(required - available),
+					Unit:      recipeUnit,
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return dtos.CookRecipeResponse{}, err
 	}
 
-	return dtos.UserItemsResponse{
-		UserItems: userItemResponses,
-	}, nil
+	return resp, nil
 }
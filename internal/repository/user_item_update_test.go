@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/dtos"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+)
+
+func TestUpdateUserItemPrice(t *testing.T) {
+	db := openTestDB(t)
+	owner := createTestOwner(t, db, "update-price")
+	milk := createTestItem(t, db, "Milk")
+	createTestLot(t, db, owner, milk, 2, "l")
+	repo := NewUserItemRepository(db, nil, nil, nil, nil)
+	price := 1.5
+
+	// Nothing was bought when the amount stays or goes down
+	for _, amount := range []float32{2, 1} {
+		_, err := repo.UpdateUserItem(dtos.UserItemRequest{Amount: amount, Unit: "l", Price: &price}, milk.ID, owner)
+		if !errors.Is(err, ErrPriceWithoutAdded) {
+			t.Errorf("UpdateUserItem() to %v l with a price error = %v, want %v", amount, err, ErrPriceWithoutAdded)
+		}
+	}
+
+	resp, err := repo.UpdateUserItem(dtos.UserItemRequest{Amount: 3, Unit: "l", Price: &price}, milk.ID, owner)
+	if err != nil {
+		t.Fatalf("UpdateUserItem() error = %v", err)
+	}
+	if resp.Amount != 3 {
+		t.Errorf("UpdateUserItem() amount = %v, want 3", resp.Amount)
+	}
+
+	var lines []models.PurchaseLine
+	if err := db.Find(&lines, "item_id = ?", milk.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || lines[0].Amount != 1 || lines[0].Unit != "l" || lines[0].Price != price {
+		t.Errorf("purchase lines = %+v, want 1 l bought for %v", lines, price)
+	}
+}
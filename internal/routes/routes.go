@@ -2,11 +2,14 @@ package routes
 
 import (
 	"os"
+	"strconv"
 
 	"github.com/GroceryTrak/GroceryTrakService/config"
 	_ "github.com/GroceryTrak/GroceryTrakService/docs"
 	"github.com/GroceryTrak/GroceryTrakService/internal/handlers"
+	"github.com/GroceryTrak/GroceryTrakService/internal/matching"
 	"github.com/GroceryTrak/GroceryTrakService/internal/middlewares"
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
 	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
@@ -20,20 +23,48 @@ func InitQueue(redisClient *redis.Client) {
 	itemQueueRepo = repository.NewItemQueueRepository(redisClient)
 }
 
-func SetupDependencies() (*handlers.ItemHandler, *handlers.AuthHandler, *handlers.RecipeHandler, *handlers.UserItemHandler) {
-	itemRepo := repository.NewItemRepository(config.DB)
+type Handlers struct {
+	Item         *handlers.ItemHandler
+	Auth         *handlers.AuthHandler
+	Recipe       *handlers.RecipeHandler
+	UserItem     *handlers.UserItemHandler
+	ShoppingList *handlers.ShoppingListHandler
+	Household    *handlers.HouseholdHandler
+	Purchase     *handlers.PurchaseHandler
+	Budget       *handlers.BudgetHandler
+	Queue        *handlers.QueueHandler
+}
+
+func SetupDependencies() Handlers {
+	itemRepo := repository.NewItemRepository(config.DB, itemQueueRepo)
 	authRepo := repository.NewAuthRepository(config.DB)
 	recipeRepo := repository.NewRecipeRepository(config.DB, config.SpoonacularClient, itemQueueRepo)
-	userItemRepo := repository.NewUserItemRepository(config.DB, itemQueueRepo)
+	matchThreshold, err := strconv.ParseFloat(os.Getenv("ITEM_MATCH_THRESHOLD"), 64)
+	if err != nil {
+		matchThreshold = matching.DefaultThreshold
+	}
+	itemMatchRepo := repository.NewItemMatchRepository(config.DB, matchThreshold)
+	userItemRepo := repository.NewUserItemRepository(config.DB, itemQueueRepo, config.Detector, itemMatchRepo, config.ProductDB)
+	shoppingListRepo := repository.NewShoppingListRepository(config.DB)
+	householdRepo := repository.NewHouseholdRepository(config.DB)
+	purchaseRepo := repository.NewPurchaseRepository(config.DB)
+	budgetRepo := repository.NewBudgetRepository(config.DB)
 
-	return handlers.NewItemHandler(itemRepo),
-		handlers.NewAuthHandler(authRepo),
-		handlers.NewRecipeHandler(recipeRepo),
-		handlers.NewUserItemHandler(userItemRepo)
+	return Handlers{
+		Item:         handlers.NewItemHandler(itemRepo, itemMatchRepo),
+		Auth:         handlers.NewAuthHandler(authRepo),
+		Recipe:       handlers.NewRecipeHandler(recipeRepo, userItemRepo, shoppingListRepo, householdRepo),
+		UserItem:     handlers.NewUserItemHandler(userItemRepo, householdRepo),
+		ShoppingList: handlers.NewShoppingListHandler(shoppingListRepo, householdRepo),
+		Household:    handlers.NewHouseholdHandler(householdRepo),
+		Purchase:     handlers.NewPurchaseHandler(purchaseRepo, householdRepo),
+		Budget:       handlers.NewBudgetHandler(budgetRepo, householdRepo),
+		Queue:        handlers.NewQueueHandler(itemQueueRepo, config.SpoonacularClient),
+	}
 }
 
 func SetupRoutes(r *chi.Mux) {
-	itemHandler, authHandler, recipeHandler, userItemHandler := SetupDependencies()
+	h := SetupDependencies()
 
 	env := os.Getenv("ENV")
 	flutterURL := os.Getenv("FLUTTER_URL")
@@ -46,7 +77,7 @@ func SetupRoutes(r *chi.Mux) {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   allowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Content-Type", "Authorization"},
+		AllowedHeaders:   []string{"Accept", "Content-Type", "Authorization", handlers.HouseholdHeader},
 		AllowCredentials: true,
 	}))
 	r.Use(middlewares.SecurityHeadersMiddleware)
@@ -67,35 +98,163 @@ func SetupRoutes(r *chi.Mux) {
 	})
 
 	r.Route("/auth", func(r chi.Router) {
-		r.Post("/register", authHandler.RegisterHandler)
-		r.Post("/login", authHandler.LoginHandler)
+		r.Post("/register", h.Auth.RegisterHandler)
+		r.Post("/login", h.Auth.LoginHandler)
+		r.Post("/refresh", h.Auth.RefreshHandler)
+		r.Post("/logout", h.Auth.LogoutHandler)
 	})
 
 	r.Route("/item", func(r chi.Router) {
-		r.Get("/{id}", itemHandler.GetItemHandler)
-		r.Post("/", itemHandler.CreateItemHandler)
-		r.Put("/{id}", itemHandler.UpdateItemHandler)
-		r.Delete("/{id}", itemHandler.DeleteItemHandler)
-		r.Get("/search", itemHandler.SearchItemsHandler)
+		r.Get("/{id}", h.Item.GetItemHandler)
+		r.Get("/search", h.Item.SearchItemsHandler)
+		r.Get("/{id}/alias", h.Item.GetItemAliasesHandler)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.AuthMiddleware)
+			r.Use(middlewares.RequireRole(models.AdminRole))
+
+			r.Post("/", h.Item.CreateItemHandler)
+			r.Get("/duplicates", h.Item.FindDuplicateItemsHandler)
+			r.Post("/merge", h.Item.MergeItemsHandler)
+			r.Put("/{id}", h.Item.UpdateItemHandler)
+			r.Delete("/{id}", h.Item.DeleteItemHandler)
+			r.Post("/{id}/alias", h.Item.AddItemAliasHandler)
+			r.Delete("/{id}/alias/{alias_id}", h.Item.DeleteItemAliasHandler)
+		})
 	})
 
 	r.Route("/recipe", func(r chi.Router) {
-		r.Get("/{id}", recipeHandler.GetRecipeHandler)
-		r.Post("/", recipeHandler.CreateRecipeHandler)
-		r.Put("/{id}", recipeHandler.UpdateRecipeHandler)
-		r.Delete("/{id}", recipeHandler.DeleteRecipeHandler)
-		r.Get("/search", recipeHandler.SearchRecipesHandler)
+		r.Get("/{id}", h.Recipe.GetRecipeHandler)
+		r.Get("/search", h.Recipe.SearchRecipesHandler)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.AuthMiddleware)
+
+			r.Get("/suggest", h.Recipe.SuggestRecipesHandler)
+			r.Post("/{id}/missing", h.Recipe.MissingItemsHandler)
+			r.Post("/{id}/cook", h.Recipe.CookRecipeHandler)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middlewares.AuthMiddleware)
+			r.Use(middlewares.RequireRole(models.AdminRole))
+
+			r.Post("/", h.Recipe.CreateRecipeHandler)
+			r.Put("/{id}", h.Recipe.UpdateRecipeHandler)
+			r.Delete("/{id}", h.Recipe.DeleteRecipeHandler)
+		})
+	})
+
+	r.Route("/queue", func(r chi.Router) {
+		r.Use(middlewares.AuthMiddleware)
+		r.Use(middlewares.RequireRole(models.AdminRole))
+
+		r.Get("/", h.Queue.GetQueueStatsHandler)
+		r.Get("/quota", h.Queue.GetQuotaHandler)
+		r.Get("/dead", h.Queue.GetDeadItemsHandler)
+		r.Post("/dead/requeue", h.Queue.RequeueDeadItemsHandler)
+		r.Post("/dead/{item_id}/requeue", h.Queue.RequeueDeadItemHandler)
 	})
 
 	r.Route("/user_item", func(r chi.Router) {
 		r.Use(middlewares.AuthMiddleware)
+		userItemRoutes(r, h)
+	})
 
-		r.Get("/", userItemHandler.GetAllUserItemsHandler)
-		r.Get("/{item_id}", userItemHandler.GetUserItemHandler)
-		r.Post("/", userItemHandler.CreateUserItemHandler)
-		r.Put("/{item_id}", userItemHandler.UpdateUserItemHandler)
-		r.Delete("/{item_id}", userItemHandler.DeleteUserItemHandler)
-		r.Post("/predict", userItemHandler.PredictUserItemsHandler)
-		r.Post("/detect", userItemHandler.DetectUserItemsHandler)
+	r.Route("/shopping_list", func(r chi.Router) {
+		r.Use(middlewares.AuthMiddleware)
+		shoppingListRoutes(r, h)
 	})
+
+	r.Route("/purchase", func(r chi.Router) {
+		r.Use(middlewares.AuthMiddleware)
+		purchaseRoutes(r, h)
+	})
+
+	r.Route("/budget", func(r chi.Router) {
+		r.Use(middlewares.AuthMiddleware)
+		budgetRoutes(r, h)
+	})
+
+	r.Route("/report", func(r chi.Router) {
+		r.Use(middlewares.AuthMiddleware)
+		reportRoutes(r, h)
+	})
+
+	r.Route("/household", func(r chi.Router) {
+		r.Use(middlewares.AuthMiddleware)
+
+		r.Get("/", h.Household.GetAllHouseholdsHandler)
+		r.Post("/", h.Household.CreateHouseholdHandler)
+		r.Post("/join", h.Household.JoinHouseholdHandler)
+
+		r.Route("/{household_id}", func(r chi.Router) {
+			r.Get("/", h.Household.GetHouseholdHandler)
+			r.Put("/", h.Household.UpdateHouseholdHandler)
+			r.Delete("/", h.Household.DeleteHouseholdHandler)
+			r.Post("/invite", h.Household.RegenerateInviteCodeHandler)
+			r.Post("/leave", h.Household.LeaveHouseholdHandler)
+			r.Put("/member/{user_id}", h.Household.UpdateHouseholdMemberHandler)
+			r.Delete("/member/{user_id}", h.Household.RemoveHouseholdMemberHandler)
+
+			// The shared pantry, lists, purchases and budgets, the same as sending the X-Household-ID header
+			r.Route("/user_item", func(r chi.Router) { userItemRoutes(r, h) })
+			r.Route("/shopping_list", func(r chi.Router) { shoppingListRoutes(r, h) })
+			r.Route("/purchase", func(r chi.Router) { purchaseRoutes(r, h) })
+			r.Route("/budget", func(r chi.Router) { budgetRoutes(r, h) })
+			r.Route("/report", func(r chi.Router) { reportRoutes(r, h) })
+		})
+	})
+}
+
+func userItemRoutes(r chi.Router, h Handlers) {
+	r.Get("/", h.UserItem.GetAllUserItemsHandler)
+	r.Get("/expiring", h.UserItem.GetExpiringUserItemsHandler)
+	r.Get("/{item_id}", h.UserItem.GetUserItemHandler)
+	r.Get("/{item_id}/history", h.UserItem.GetUserItemHistoryHandler)
+	r.Post("/{item_id}/discard", h.UserItem.DiscardUserItemHandler)
+	r.Post("/", h.UserItem.CreateUserItemHandler)
+	r.Put("/{item_id}", h.UserItem.UpdateUserItemHandler)
+	r.Delete("/{item_id}", h.UserItem.DeleteUserItemHandler)
+	r.Put("/lot/{lot_id}", h.UserItem.UpdateUserItemLotHandler)
+	r.Delete("/lot/{lot_id}", h.UserItem.DeleteUserItemLotHandler)
+	r.Post("/predict", h.UserItem.PredictUserItemsHandler)
+	r.Post("/detect", h.UserItem.DetectUserItemsHandler)
+	r.Post("/barcode", h.UserItem.AddBarcodeUserItemHandler)
+	r.Post("/receipt", h.UserItem.ImportReceiptHandler)
+	r.Get("/scan/{scan_id}", h.UserItem.GetScanHandler)
+	r.Post("/scan/{scan_id}/commit", h.UserItem.CommitScanHandler)
+	r.Delete("/scan/{scan_id}", h.UserItem.DiscardScanHandler)
+}
+
+func shoppingListRoutes(r chi.Router, h Handlers) {
+	r.Get("/", h.ShoppingList.GetAllShoppingListsHandler)
+	r.Post("/", h.ShoppingList.CreateShoppingListHandler)
+	r.Get("/{id}", h.ShoppingList.GetShoppingListHandler)
+	r.Put("/{id}", h.ShoppingList.UpdateShoppingListHandler)
+	r.Delete("/{id}", h.ShoppingList.DeleteShoppingListHandler)
+	r.Post("/{id}/item", h.ShoppingList.AddShoppingListItemHandler)
+	r.Put("/{id}/item/{item_id}", h.ShoppingList.UpdateShoppingListItemHandler)
+	r.Put("/{id}/item/{item_id}/check", h.ShoppingList.CheckShoppingListItemHandler)
+	r.Delete("/{id}/item/{item_id}", h.ShoppingList.DeleteShoppingListItemHandler)
+	r.Post("/{id}/purchase", h.ShoppingList.MovePurchasedItemsHandler)
+}
+
+func purchaseRoutes(r chi.Router, h Handlers) {
+	r.Get("/", h.Purchase.GetPurchasesHandler)
+	r.Post("/", h.Purchase.CreatePurchaseHandler)
+	r.Get("/{id}", h.Purchase.GetPurchaseHandler)
+	r.Delete("/{id}", h.Purchase.DeletePurchaseHandler)
+	r.Get("/price/{item_id}", h.Purchase.GetItemPriceHistoryHandler)
+}
+
+func budgetRoutes(r chi.Router, h Handlers) {
+	r.Get("/", h.Budget.GetBudgetsHandler)
+	r.Put("/", h.Budget.SetBudgetHandler)
+	r.Delete("/{id}", h.Budget.DeleteBudgetHandler)
+}
+
+func reportRoutes(r chi.Router, h Handlers) {
+	r.Get("/spend", h.Budget.GetSpendReportHandler)
+	r.Get("/waste", h.UserItem.GetWasteReportHandler)
 }
@@ -0,0 +1,112 @@
+package shelflife
+
+import (
+	"strings"
+	"time"
+
+	"github.com/GroceryTrak/GroceryTrakService/internal/models"
+)
+
+// defaultDays is the typical shelf life in days of an opened or fresh item per category and
+// storage location. A missing location means the category is not usually stored there.
+var defaultDays = map[models.ItemCategory]map[models.StorageLocation]int{
+	models.ProduceCategory:   {models.PantryLocation: 5, models.FridgeLocation: 10, models.FreezerLocation: 240},
+	models.DairyCategory:     {models.FridgeLocation: 10, models.FreezerLocation: 90},
+	models.MeatCategory:      {models.FridgeLocation: 3, models.FreezerLocation: 180},
+	models.SeafoodCategory:   {models.FridgeLocation: 2, models.FreezerLocation: 120},
+	models.BakeryCategory:    {models.PantryLocation: 4, models.FridgeLocation: 7, models.FreezerLocation: 90},
+	models.FrozenCategory:    {models.FridgeLocation: 2, models.FreezerLocation: 180},
+	models.DryGoodsCategory:  {models.PantryLocation: 365, models.FridgeLocation: 365, models.FreezerLocation: 730},
+	models.BeverageCategory:  {models.PantryLocation: 180, models.FridgeLocation: 180},
+	models.CondimentCategory: {models.PantryLocation: 180, models.FridgeLocation: 365},
+	models.OtherCategory:     {models.PantryLocation: 30, models.FridgeLocation: 14, models.FreezerLocation: 180},
+}
+
+var defaultLocations = map[models.ItemCategory]models.StorageLocation{
+	models.DairyCategory:   models.FridgeLocation,
+	models.MeatCategory:    models.FridgeLocation,
+	models.SeafoodCategory: models.FridgeLocation,
+	models.FrozenCategory:  models.FreezerLocation,
+}
+
+// IsValidLocation reports whether location is one of the known storage locations
+func IsValidLocation(location models.StorageLocation) bool {
+	switch location {
+	case models.PantryLocation, models.FridgeLocation, models.FreezerLocation:
+		return true
+	}
+	return false
+}
+
+// IsValidCategory reports whether category is one of the known item categories
+func IsValidCategory(category models.ItemCategory) bool {
+	_, ok := defaultDays[category]
+	return ok
+}
+
+// DefaultLocation returns where an item of the given category is usually stored
+func DefaultLocation(category models.ItemCategory) models.StorageLocation {
+	if location, ok := defaultLocations[category]; ok {
+		return location
+	}
+	return models.PantryLocation
+}
+
+// Days returns the shelf life of item at location. The item's own override applies to its
+// default location; other locations fall back to the category defaults.
+func Days(item models.Item, location models.StorageLocation) (int, bool) {
+	if item.ShelfLifeDays != nil && location == DefaultLocation(item.Category) {
+		return *item.ShelfLifeDays, true
+	}
+
+	byLocation, ok := defaultDays[item.Category]
+	if !ok {
+		byLocation = defaultDays[models.OtherCategory]
+	}
+	days, ok := byLocation[location]
+	return days, ok
+}
+
+// ExpiresAt estimates the expiry date of item bought at purchasedAt and stored at location
+func ExpiresAt(item models.Item, location models.StorageLocation, purchasedAt time.Time) *time.Time {
+	days, ok := Days(item, location)
+	if !ok {
+		return nil
+	}
+
+	expiresAt := purchasedAt.AddDate(0, 0, days)
+	return &expiresAt
+}
+
+// CategoryFromAisle maps a Spoonacular aisle such as "Milk, Eggs, Other Dairy" to a category
+func CategoryFromAisle(aisle string) models.ItemCategory {
+	aisle = strings.ToLower(aisle)
+	switch {
+	case aisle == "":
+		return models.OtherCategory
+	case strings.Contains(aisle, "frozen"):
+		return models.FrozenCategory
+	case strings.Contains(aisle, "produce"):
+		return models.ProduceCategory
+	case strings.Contains(aisle, "dairy"), strings.Contains(aisle, "cheese"), strings.Contains(aisle, "milk"):
+		return models.DairyCategory
+	case strings.Contains(aisle, "seafood"):
+		return models.SeafoodCategory
+	case strings.Contains(aisle, "meat"):
+		return models.MeatCategory
+	case strings.Contains(aisle, "bake"), strings.Contains(aisle, "bread"):
+		return models.BakeryCategory
+	case strings.Contains(aisle, "beverage"), strings.Contains(aisle, "coffee"), strings.Contains(aisle, "tea"),
+		strings.Contains(aisle, "alcoholic"):
+		return models.BeverageCategory
+	case strings.Contains(aisle, "condiment"), strings.Contains(aisle, "spices"), strings.Contains(aisle, "oil"),
+		strings.Contains(aisle, "sauce"):
+		return models.CondimentCategory
+	case strings.Contains(aisle, "pasta"), strings.Contains(aisle, "rice"), strings.Contains(aisle, "cereal"),
+		strings.Contains(aisle, "canned"), strings.Contains(aisle, "baking"), strings.Contains(aisle, "nuts"),
+		strings.Contains(aisle, "snacks"):
+		return models.DryGoodsCategory
+	default:
+		return models.OtherCategory
+	}
+}
@@ -0,0 +1,155 @@
+package units
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Dimension int
+
+const (
+	Unknown Dimension = iota
+	Mass
+	Volume
+	Count
+)
+
+func (d Dimension) String() string {
+	switch d {
+	case Mass:
+		return "mass"
+	case Volume:
+		return "volume"
+	case Count:
+		return "count"
+	default:
+		return "unknown"
+	}
+}
+
+// Unit is a canonical unit of measure. Factor converts one of this unit into the base unit of
+// its dimension: grams for mass, milliliters for volume and pieces for count.
+type Unit struct {
+	Name      string
+	Dimension Dimension
+	Factor    float64
+}
+
+var ErrIncompatible = errors.New("incompatible units")
+
+var canonicalUnits = map[string]Unit{
+	"mg":    {Name: "mg", Dimension: Mass, Factor: 0.001},
+	"g":     {Name: "g", Dimension: Mass, Factor: 1},
+	"kg":    {Name: "kg", Dimension: Mass, Factor: 1000},
+	"oz":    {Name: "oz", Dimension: Mass, Factor: 28.349523125},
+	"lb":    {Name: "lb", Dimension: Mass, Factor: 453.59237},
+	"ml":    {Name: "ml", Dimension: Volume, Factor: 1},
+	"cl":    {Name: "cl", Dimension: Volume, Factor: 10},
+	"dl":    {Name: "dl", Dimension: Volume, Factor: 100},
+	"l":     {Name: "l", Dimension: Volume, Factor: 1000},
+	"tsp":   {Name: "tsp", Dimension: Volume, Factor: 4.92892159375},
+	"tbsp":  {Name: "tbsp", Dimension: Volume, Factor: 14.78676478125},
+	"fl oz": {Name: "fl oz", Dimension: Volume, Factor: 29.5735295625},
+	"cup":   {Name: "cup", Dimension: Volume, Factor: 236.5882365},
+	"pint":  {Name: "pint", Dimension: Volume, Factor: 473.176473},
+	"quart": {Name: "quart", Dimension: Volume, Factor: 946.352946},
+	"gal":   {Name: "gal", Dimension: Volume, Factor: 3785.411784},
+	"unit":  {Name: "unit", Dimension: Count, Factor: 1},
+	"dozen": {Name: "dozen", Dimension: Count, Factor: 12},
+}
+
+var aliases = map[string]string{
+	"milligram": "mg", "milligrams": "mg",
+	"gram": "g", "grams": "g", "gr": "g", "grs": "g",
+	"kilogram": "kg", "kilograms": "kg", "kgs": "kg", "kilo": "kg", "kilos": "kg",
+	"ounce": "oz", "ounces": "oz",
+	"pound": "lb", "pounds": "lb", "lbs": "lb",
+	"milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml", "mls": "ml",
+	"centiliter": "cl", "centiliters": "cl", "centilitre": "cl", "centilitres": "cl",
+	"deciliter": "dl", "deciliters": "dl", "decilitre": "dl", "decilitres": "dl",
+	"liter": "l", "liters": "l", "litre": "l", "litres": "l", "ltr": "l",
+	"teaspoon": "tsp", "teaspoons": "tsp", "tsps": "tsp",
+	"tablespoon": "tbsp", "tablespoons": "tbsp", "tbsps": "tbsp", "tbs": "tbsp",
+	"fluid ounce": "fl oz", "fluid ounces": "fl oz", "floz": "fl oz", "fl. oz": "fl oz",
+	"cups": "cup", "c": "cup",
+	"pints": "pint", "pt": "pint",
+	"quarts": "quart", "qt": "quart",
+	"gallon": "gal", "gallons": "gal",
+	"units": "unit", "piece": "unit", "pieces": "unit", "pc": "unit", "pcs": "unit",
+	"each": "unit", "ea": "unit", "item": "unit", "items": "unit", "serving": "unit", "servings": "unit",
+	"small": "unit", "medium": "unit", "large": "unit",
+	"dozens": "dozen",
+}
+
+func normalize(unit string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(unit)), ".")
+}
+
+// Lookup resolves a free-form unit string such as "Grams" or "cups" to its canonical unit.
+// An empty unit is treated as a count.
+func Lookup(unit string) (Unit, bool) {
+	name := normalize(unit)
+	if name == "" {
+		return canonicalUnits["unit"], true
+	}
+	if u, ok := canonicalUnits[name]; ok {
+		return u, true
+	}
+	if alias, ok := aliases[name]; ok {
+		return canonicalUnits[alias], true
+	}
+	return Unit{}, false
+}
+
+// Canonical returns the canonical spelling of a unit, or the trimmed input if it is unknown
+func Canonical(unit string) string {
+	if u, ok := Lookup(unit); ok {
+		return u.Name
+	}
+	return strings.TrimSpace(unit)
+}
+
+// Convertible reports whether amounts in from can be expressed in to. A density in g/ml makes
+// mass and volume convertible; pass 0 when the density is unknown.
+func Convertible(from, to string, density float64) bool {
+	_, err := Convert(1, from, to, density)
+	return err == nil
+}
+
+// Convert expresses amount of from in to, using density (g/ml) to cross between mass and volume
+func Convert(amount float64, from, to string, density float64) (float64, error) {
+	fromUnit, ok := Lookup(from)
+	toUnit, ok2 := Lookup(to)
+	if !ok || !ok2 {
+		if normalize(from) == normalize(to) {
+			return amount, nil
+		}
+		return 0, fmt.Errorf("%w: %q and %q", ErrIncompatible, from, to)
+	}
+
+	base := amount * fromUnit.Factor
+	if fromUnit.Dimension != toUnit.Dimension {
+		switch {
+		case density <= 0:
+			return 0, fmt.Errorf("%w: %s and %s need a density", ErrIncompatible, fromUnit.Dimension, toUnit.Dimension)
+		case fromUnit.Dimension == Volume && toUnit.Dimension == Mass:
+			base *= density
+		case fromUnit.Dimension == Mass && toUnit.Dimension == Volume:
+			base /= density
+		default:
+			return 0, fmt.Errorf("%w: %s and %s", ErrIncompatible, fromUnit.Dimension, toUnit.Dimension)
+		}
+	}
+
+	return base / toUnit.Factor, nil
+}
+
+// Sum adds b (in unitB) to a (in unitA) and returns the total in unitA
+func Sum(a float64, unitA string, b float64, unitB string, density float64) (float64, error) {
+	converted, err := Convert(b, unitB, unitA, density)
+	if err != nil {
+		return 0, err
+	}
+	return a + converted, nil
+}
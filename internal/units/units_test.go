@@ -0,0 +1,70 @@
+package units
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := map[string]string{
+		"Grams":        "g",
+		" cups ":       "cup",
+		"tbsp.":        "tbsp",
+		"Fluid Ounces": "fl oz",
+		"pcs":          "unit",
+		"":             "unit",
+		"pinch":        "pinch",
+	}
+	for unit, want := range tests {
+		if got := Canonical(unit); got != want {
+			t.Errorf("Canonical(%q) = %q, want %q", unit, got, want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		amount  float64
+		from    string
+		to      string
+		density float64
+		want    float64
+		err     error
+	}{
+		{amount: 1.5, from: "kg", to: "g", want: 1500},
+		{amount: 1, from: "lb", to: "oz", want: 16},
+		{amount: 250, from: "ml", to: "l", want: 0.25},
+		{amount: 1, from: "cup", to: "tbsp", want: 16},
+		{amount: 2, from: "dozen", to: "pieces", want: 24},
+		{amount: 3, from: "pinch", to: "Pinch", want: 3},
+		{amount: 1, from: "l", to: "g", density: 1.03, want: 1030},
+		{amount: 100, from: "g", to: "ml", density: 0.5, want: 200},
+		{amount: 1, from: "l", to: "g", err: ErrIncompatible},
+		{amount: 1, from: "unit", to: "g", density: 1, err: ErrIncompatible},
+		{amount: 1, from: "pinch", to: "g", err: ErrIncompatible},
+	}
+
+	for _, tt := range tests {
+		got, err := Convert(tt.amount, tt.from, tt.to, tt.density)
+		if !errors.Is(err, tt.err) || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Convert(%v, %q, %q, %v) = %v, %v, want %v, %v", tt.amount, tt.from, tt.to, tt.density, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestSum(t *testing.T) {
+	got, err := Sum(1, "kg", 500, "g", 0)
+	if err != nil || math.Abs(got-1.5) > 1e-9 {
+		t.Errorf("Sum(1 kg, 500 g) = %v, %v, want 1.5", got, err)
+	}
+
+	got, err = Sum(200, "g", 1, "cup", 0.5)
+	if err != nil || math.Abs(got-318.29411825) > 1e-9 {
+		t.Errorf("Sum(200 g, 1 cup) = %v, %v, want 318.29411825", got, err)
+	}
+
+	if _, err := Sum(200, "g", 1, "cup", 0); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Sum(200 g, 1 cup) without a density error = %v, want %v", err, ErrIncompatible)
+	}
+}
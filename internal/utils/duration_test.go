@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    time.Duration
+		wantErr bool
+	}{
+		{s: "3d", want: 72 * time.Hour},
+		{s: "2w", want: 14 * 24 * time.Hour},
+		{s: "1.5d", want: 36 * time.Hour},
+		{s: " 12h ", want: 12 * time.Hour},
+		{s: "90m", want: 90 * time.Minute},
+		{s: "", wantErr: true},
+		{s: "d", wantErr: true},
+		{s: "xd", wantErr: true},
+		{s: "3y", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.s)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v, want %v, error %v", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	got, err := ParseDate("2025-03-01")
+	if err != nil || !got.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseDate(day) = %v, %v", got, err)
+	}
+
+	got, err = ParseDate("2025-03-01T10:00:00+02:00")
+	if err != nil || !got.Equal(time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseDate(timestamp) = %v, %v", got, err)
+	}
+
+	if _, err := ParseDate("March 1st"); err == nil {
+		t.Error("ParseDate(\"March 1st\") returned no error")
+	}
+}
@@ -1,41 +1,254 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"os"
 	"time"
 
+	"github.com/GroceryTrak/GroceryTrakService/config"
 	"github.com/GroceryTrak/GroceryTrakService/internal/models"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
 
-func GenerateJWT(id uint, username string, role models.Role) (string, error) {
+	accessTokenType  = "access"
+	refreshTokenType = "refresh"
+
+	revokedJTIPrefix    = "revoked_jti:"
+	revokedFamilyPrefix = "revoked_token_family:"
+	familyCurrentPrefix = "token_family:"
+)
+
+var (
+	jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+
+	ErrTokenRevoked     = errors.New("token has been revoked")
+	ErrInvalidTokenType = errors.New("invalid token type")
+	ErrTokenReused      = errors.New("refresh token reuse detected")
+)
+
+// TokenClaims holds the identity carried by an access or refresh token
+type TokenClaims struct {
+	ID        uint
+	Username  string
+	Role      string
+	JTI       string
+	Family    string
+	ExpiresAt time.Time
+}
+
+// TokenPair is the result of a login or a refresh
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64
+}
+
+func generateTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func signToken(id uint, username string, role models.Role, tokenType, family string, ttl time.Duration) (string, string, error) {
+	jti, err := generateTokenID()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"id":       id,
 		"username": username,
 		"role":     role,
-		"exp":      time.Now().Add(time.Hour * 72).Unix(),
+		"type":     tokenType,
+		"fam":      family,
+		"jti":      jti,
+		"iat":      now.Unix(),
+		"exp":      now.Add(ttl).Unix(),
 	})
 
-	return token.SignedString(jwtSecret)
+	signed, err := token.SignedString(jwtSecret)
+	if err != nil {
+		return "", "", err
+	}
+	return signed, jti, nil
 }
 
-// VerifyToken parses and validates a JWT, returning the id, username and role
-func VerifyToken(tokenString string) (uint, string, string, error) {
+// GenerateJWT issues a short-lived access token belonging to the given refresh token family
+func GenerateJWT(id uint, username string, role models.Role, family string) (string, error) {
+	token, _, err := signToken(id, username, role, accessTokenType, family, AccessTokenTTL)
+	return token, err
+}
+
+// GenerateTokenPair issues an access token and a refresh token. An empty family starts a new
+// refresh token family (login); a non-empty one continues an existing family (rotation).
+func GenerateTokenPair(id uint, username string, role models.Role, family string) (TokenPair, error) {
+	if family == "" {
+		var err error
+		if family, err = generateTokenID(); err != nil {
+			return TokenPair{}, err
+		}
+	}
+
+	accessToken, err := GenerateJWT(id, username, role, family)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refreshToken, refreshJTI, err := signToken(id, username, role, refreshTokenType, family, RefreshTokenTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	// Only the latest refresh token of a family may be exchanged
+	if err := config.RedisClient.Set(config.Ctx, familyCurrentPrefix+family, refreshJTI, RefreshTokenTTL).Err(); err != nil {
+		return TokenPair{}, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func parseToken(tokenString, tokenType string) (TokenClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return jwtSecret, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return TokenClaims{}, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return TokenClaims{}, errors.New("invalid token")
+	}
+
+	if t, _ := claims["type"].(string); t != tokenType {
+		return TokenClaims{}, ErrInvalidTokenType
+	}
+
+	id, _ := claims["id"].(float64)
+	username, _ := claims["username"].(string)
+	role, _ := claims["role"].(string)
+	jti, _ := claims["jti"].(string)
+	family, _ := claims["fam"].(string)
+	if jti == "" || family == "" {
+		return TokenClaims{}, errors.New("invalid token")
+	}
+
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return TokenClaims{}, errors.New("invalid token")
+	}
+
+	result := TokenClaims{
+		ID:        uint(id),
+		Username:  username,
+		Role:      role,
+		JTI:       jti,
+		Family:    family,
+		ExpiresAt: exp.Time,
+	}
+
+	revoked, err := isRevoked(result)
+	if err != nil {
+		return TokenClaims{}, err
+	}
+	if revoked {
+		return TokenClaims{}, ErrTokenRevoked
+	}
+
+	return result, nil
+}
+
+func isRevoked(claims TokenClaims) (bool, error) {
+	n, err := config.RedisClient.Exists(config.Ctx, revokedJTIPrefix+claims.JTI, revokedFamilyPrefix+claims.Family).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return n > 0, nil
+}
+
+// VerifyToken parses and validates an access token, returning the id, username and role
+func VerifyToken(tokenString string) (uint, string, string, error) {
+	claims, err := parseToken(tokenString, accessTokenType)
 	if err != nil {
 		return 0, "", "", err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		id := uint(claims["id"].(float64))
-		username := claims["username"].(string)
-		role := claims["role"].(string)
-		return id, username, role, nil
+	return claims.ID, claims.Username, claims.Role, nil
+}
+
+// VerifyRefreshToken parses and validates a refresh token and consumes it, so it can only be
+// exchanged once. Presenting a refresh token that is no longer the latest of its family
+// revokes the whole family.
+func VerifyRefreshToken(tokenString string) (TokenClaims, error) {
+	claims, err := parseToken(tokenString, refreshTokenType)
+	if err != nil {
+		return TokenClaims{}, err
+	}
+
+	current, err := config.RedisClient.GetDel(config.Ctx, familyCurrentPrefix+claims.Family).Result()
+	if err != nil && err != redis.Nil {
+		return TokenClaims{}, fmt.Errorf("failed to get refresh token family: %w", err)
+	}
+	if current != claims.JTI {
+		if err := RevokeTokenFamily(claims.Family); err != nil {
+			return TokenClaims{}, err
+		}
+		return TokenClaims{}, ErrTokenReused
+	}
+
+	return claims, nil
+}
+
+// RevokeAccessToken blacklists a single access token until it would have expired anyway
+func RevokeAccessToken(tokenString string) error {
+	claims, err := parseToken(tokenString, accessTokenType)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Until(claims.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := config.RedisClient.Set(config.Ctx, revokedJTIPrefix+claims.JTI, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// RevokeRefreshToken revokes the family of the given refresh token without consuming it
+func RevokeRefreshToken(tokenString string) error {
+	claims, err := parseToken(tokenString, refreshTokenType)
+	if err != nil {
+		return err
 	}
 
-	return 0, "", "", err
+	return RevokeTokenFamily(claims.Family)
+}
+
+// RevokeTokenFamily revokes every access and refresh token issued from the same login
+func RevokeTokenFamily(family string) error {
+	pipe := config.RedisClient.TxPipeline()
+	pipe.Set(config.Ctx, revokedFamilyPrefix+family, 1, RefreshTokenTTL)
+	pipe.Del(config.Ctx, familyCurrentPrefix+family)
+	if _, err := pipe.Exec(config.Ctx); err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+	return nil
 }
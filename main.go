@@ -9,8 +9,6 @@ import (
 	"syscall"
 
 	"github.com/GroceryTrak/GroceryTrakService/config"
-	"github.com/GroceryTrak/GroceryTrakService/internal/handlers"
-	"github.com/GroceryTrak/GroceryTrakService/internal/repository"
 	"github.com/GroceryTrak/GroceryTrakService/internal/routes"
 	"github.com/go-chi/chi/v5"
 )
@@ -32,34 +30,42 @@ import (
 // @response 500 {object} dtos.InternalServerErrorResponse "Internal Server Error"
 func main() {
 	config.LoadConfig()
+
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
 	config.InitRedis()
 	config.InitPostgreSQL()
 	config.InitSpoonacularClient()
+	config.InitDetector()
+	config.InitProductDB()
+
+	bootstrapAdmin()
 
 	// Initialize queue repository
 	routes.InitQueue(config.RedisClient)
 
-	// Create repositories
-	itemRepo := repository.NewItemRepository(config.DB)
-	itemQueueRepo := repository.NewItemQueueRepository(config.RedisClient)
-
-	// Create and start ItemQueueHandler
-	queueHandler := handlers.NewItemQueueHandler(
-		itemQueueRepo,
-		config.SpoonacularClient,
-		itemRepo,
-	)
-
 	// Create context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Start queue handler in a goroutine
-	go func() {
-		if err := queueHandler.Start(ctx); err != nil {
-			log.Printf("Queue handler error: %v", err)
-		}
-	}()
+	// Run the enrichment worker and scheduler in the API process, unless they run as separate
+	// worker instances
+	if os.Getenv("RUN_QUEUE_WORKER") != "false" {
+		queueHandler := newQueueWorker(0)
+		go func() {
+			if err := queueHandler.Start(ctx); err != nil && err != context.Canceled {
+				log.Printf("Queue handler error: %v", err)
+			}
+		}()
+		scheduler := newEnrichmentScheduler()
+		go func() {
+			if err := scheduler.Start(ctx); err != nil && err != context.Canceled {
+				log.Printf("Enrichment scheduler error: %v", err)
+			}
+		}()
+	}
 
 	// Setup HTTP server
 	r := chi.NewRouter()